@@ -94,7 +94,8 @@ func (this *arrayQueue) Peek() interface{} {
 }
 
 func (this *arrayQueue) Clear() {
-  this.array.Clear()
+  this.array = NewArray()
+  this.array.Extend(this.array.Capacity())
   this.first = 0
   this.next = 0
 }
@@ -0,0 +1,174 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffers
+
+import . "github.com/objecthub/containerkit"
+
+
+// QueueObserver is notified about changes of observed queues. Observers may
+// implement QueueBatchObserver and QueueClearObserver to be notified about
+// EnqueueFrom and Clear with a single event.
+type QueueObserver interface {
+  Enqueue(subject Queue, elem interface{})
+  Dequeue(subject Queue, elem interface{})
+}
+
+// QueueBatchObserver is an optional extension of QueueObserver. EnqueueFrom
+// is reported to observers implementing it as a single EnqueueBatch event
+// listing all enqueued elements; other observers get an Enqueue event for
+// every element.
+type QueueBatchObserver interface {
+  EnqueueBatch(subject Queue, elements []interface{})
+}
+
+// QueueClearObserver is an optional extension of QueueObserver. Clearing a
+// queue is reported to observers implementing it as a single Clear event;
+// other observers get a Dequeue event for every element in queue order.
+type QueueClearObserver interface {
+  Clear(subject Queue)
+}
+
+// ObservedQueue is implemented by queues created by ObservableQueue classes.
+// It allows observers to be added and removed at any time.
+type ObservedQueue interface {
+  Queue
+  AddObserver(observer QueueObserver)
+  RemoveObserver(observer QueueObserver)
+}
+
+// ObservableQueue returns a class whose queues notify observers about
+// changes. The queues are initially observed by the QueueObserver elements
+// of observers, which may be nil.
+func ObservableQueue(class QueueClass, observers Container) QueueClass {
+  return &observableQueueClass{class, observers}
+}
+
+type observableQueueClass struct {
+  class QueueClass
+  observers Container
+}
+
+func (this *observableQueueClass) Embed(obj Queue) Queue {
+  res := new(observableQueue)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  if this.observers != nil {
+    this.observers.ForEach(func (o interface{}) {
+      res.AddObserver(o.(QueueObserver))
+    })
+  }
+  res.Queue = this.class.Embed(obj)
+  return res
+}
+
+func (this *observableQueueClass) New(elements... interface{}) Queue {
+  res := this.Embed(nil)
+  for i := 0; i < len(elements); i++ {
+    res.Enqueue(elements[i])
+  }
+  return res
+}
+
+func (this *observableQueueClass) From(coll Container) Queue {
+  res := this.Embed(nil)
+  res.EnqueueFrom(coll)
+  return res
+}
+
+type observableQueue struct {
+  obj Queue
+  observers Observers
+  depth int
+  batch []interface{}
+  Queue
+}
+
+func (this *observableQueue) AddObserver(observer QueueObserver) {
+  this.observers.Add(observer)
+}
+
+func (this *observableQueue) RemoveObserver(observer QueueObserver) {
+  this.observers.Remove(observer)
+}
+
+func (this *observableQueue) Enqueue(elem interface{}) {
+  this.Queue.Enqueue(elem)
+  if this.depth > 0 {
+    this.batch = append(this.batch, elem)
+    return
+  }
+  for _, o := range this.observers {
+    o.(QueueObserver).Enqueue(this.obj, elem)
+  }
+}
+
+func (this *observableQueue) Dequeue() interface{} {
+  elem := this.Queue.Dequeue()
+  if this.depth == 0 {
+    for _, o := range this.observers {
+      o.(QueueObserver).Dequeue(this.obj, elem)
+    }
+  }
+  return elem
+}
+
+func (this *observableQueue) EnqueueFrom(coll Container) {
+  this.depth++
+  this.Queue.EnqueueFrom(coll)
+  this.depth--
+  if this.depth == 0 {
+    batch := this.batch
+    this.batch = nil
+    for _, o := range this.observers {
+      if bo, ok := o.(QueueBatchObserver); ok {
+        bo.EnqueueBatch(this.obj, batch)
+      } else {
+        for _, elem := range batch {
+          o.(QueueObserver).Enqueue(this.obj, elem)
+        }
+      }
+    }
+  }
+}
+
+func (this *observableQueue) Clear() {
+  var elements []interface{}
+  if this.depth == 0 {
+    for _, o := range this.observers {
+      if _, ok := o.(QueueClearObserver); !ok {
+        for iter := this.Queue.Elements(); iter.HasNext(); {
+          elements = append(elements, iter.Next())
+        }
+        break
+      }
+    }
+  }
+  this.depth++
+  this.Queue.Clear()
+  this.depth--
+  if this.depth == 0 {
+    for _, o := range this.observers {
+      if co, ok := o.(QueueClearObserver); ok {
+        co.Clear(this.obj)
+      } else {
+        for _, elem := range elements {
+          o.(QueueObserver).Dequeue(this.obj, elem)
+        }
+      }
+    }
+  }
+}
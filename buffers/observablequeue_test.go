@@ -0,0 +1,72 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffers
+
+import "fmt"
+import "strings"
+import "testing"
+
+
+type queueRecorder struct {
+  events []string
+}
+
+func (this *queueRecorder) Enqueue(subject Queue, elem interface{}) {
+  this.events = append(this.events, fmt.Sprintf("+%v", elem))
+}
+
+func (this *queueRecorder) Dequeue(subject Queue, elem interface{}) {
+  this.events = append(this.events, fmt.Sprintf("-%v", elem))
+}
+
+// batchQueueRecorder also records batch and clear events.
+type batchQueueRecorder struct {
+  queueRecorder
+}
+
+func (this *batchQueueRecorder) EnqueueBatch(subject Queue, elements []interface{}) {
+  this.events = append(this.events, fmt.Sprintf("+%v", elements))
+}
+
+func (this *batchQueueRecorder) Clear(subject Queue) {
+  this.events = append(this.events, "clear")
+}
+
+func TestObservableQueueClass(t *testing.T) {
+  recorder := new(batchQueueRecorder)
+  q := ObservableQueue(ArrayQueue, nil).New(1).(ObservedQueue)
+  q.AddObserver(recorder)
+  q.Enqueue(2)
+  q.EnqueueFrom(ArrayQueue.New(3, 4))
+  q.Dequeue()
+  checkSize(t, q, 3, "q")
+  q.Clear()
+  expected := "+2 +[3 4] -1 clear"
+  if events := strings.Join(recorder.events, " "); events != expected {
+    t.Errorf("Unexpected events %s; expected %s", events, expected)
+  }
+}
+
+func TestObservableQueueFallbackEvents(t *testing.T) {
+  recorder := new(queueRecorder)
+  q := ObservableQueue(ArrayQueue, nil).New(1).(ObservedQueue)
+  q.AddObserver(recorder)
+  q.EnqueueFrom(ArrayQueue.New(2, 3))
+  q.Clear()
+  expected := "+2 +3 -1 -2 -3"
+  if events := strings.Join(recorder.events, " "); events != expected {
+    t.Errorf("Unexpected events %s; expected %s", events, expected)
+  }
+}
@@ -0,0 +1,72 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import "math/rand"
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+// DefaultSteps is the number of randomized operations performed by the
+// model-based checks invoked from the CheckXXXClass functions.
+const DefaultSteps = 2000
+
+// DefaultSeed is the seed of the random number generator used by the
+// model-based checks invoked from the CheckXXXClass functions.
+const DefaultSeed = 4711
+
+// universe is the number of distinct elements used by the randomized checks.
+// It is kept small so that operation sequences frequently hit existing
+// elements.
+const universe = 100
+
+func newRandom(seed int64) *rand.Rand {
+  return rand.New(rand.NewSource(seed))
+}
+
+// randomInts returns a container with up to n random elements taken from
+// the universe.
+func randomInts(rnd *rand.Rand, n int) FiniteContainer {
+  elements := make([]interface{}, rnd.Intn(n + 1))
+  for i := range elements {
+    elements[i] = rnd.Intn(universe)
+  }
+  return Enum.New(elements...)
+}
+
+// collect returns all elements of the given container in iteration order.
+func collect(coll Container) []interface{} {
+  var res []interface{}
+  for iter := coll.Elements(); iter.HasNext(); {
+    res = append(res, iter.Next())
+  }
+  return res
+}
+
+// checkFinite verifies that the size of a finite container is consistent
+// with the number of elements returned by its iterator and with IsEmpty.
+func checkFinite(t *testing.T, coll FiniteContainer, name string) bool {
+  size := coll.Size()
+  count := CountElements(coll.Elements())
+  if size != count {
+    t.Errorf("Size of %s is %d, but its iterator returns %d elements", name, size, count)
+    return false
+  }
+  if coll.IsEmpty() != (size == 0) {
+    t.Errorf("IsEmpty of %s is %t, but its size is %d", name, coll.IsEmpty(), size)
+    return false
+  }
+  return true
+}
@@ -0,0 +1,105 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/sets"
+import . "github.com/objecthub/containerkit/maps"
+import . "github.com/objecthub/containerkit/buffers"
+import . "github.com/objecthub/containerkit/impl"
+
+
+func TestHashSetConformance(t *testing.T) {
+  CheckMutableSetClass(t, HashSet)
+}
+
+func TestTreeSetConformance(t *testing.T) {
+  CheckMutableSetClass(t, TreeSet)
+}
+
+func TestBitSetConformance(t *testing.T) {
+  CheckMutableSetClass(t, BitSet)
+}
+
+func TestListSetConformance(t *testing.T) {
+  CheckMutableSetClass(t, ListSet)
+}
+
+func TestHashMapConformance(t *testing.T) {
+  CheckMutableMapClass(t, HashMap)
+}
+
+func TestNativeMapConformance(t *testing.T) {
+  CheckMutableMapClass(t, NativeMap)
+}
+
+func TestArrayQueueConformance(t *testing.T) {
+  CheckFifoQueueClass(t, ArrayQueue)
+}
+
+func TestListQueueConformance(t *testing.T) {
+  CheckFifoQueueClass(t, ListQueue)
+}
+
+func TestPriorityQueueConformance(t *testing.T) {
+  CheckQueueClass(t, PriorityQueue)
+}
+
+func TestConcurrentHashSetConformance(t *testing.T) {
+  CheckMutableSetClass(t, ConcurrentHashSet)
+}
+
+func TestConcurrentHashMapConformance(t *testing.T) {
+  CheckMutableMapClass(t, ConcurrentHashMap)
+}
+
+func TestLinkedHashSetConformance(t *testing.T) {
+  CheckMutableSetClass(t, LinkedHashSet)
+}
+
+func TestLinkedHashMapConformance(t *testing.T) {
+  CheckMutableMapClass(t, LinkedHashMap)
+}
+
+func TestAccessOrderedLinkedHashMapConformance(t *testing.T) {
+  CheckMutableMapClass(t, LinkedHashMapClass(UniversalHash, UniversalEquality, true))
+}
+
+func TestTreeMapConformance(t *testing.T) {
+  CheckMutableMapClass(t, TreeMap)
+}
+
+func TestSynchronizedMapConformance(t *testing.T) {
+  CheckMutableMapClass(t, SynchronizedMap(HashMap))
+}
+
+func TestOpenHashSetConformance(t *testing.T) {
+  CheckMutableSetClass(t, OpenHashSet)
+}
+
+func TestOpenHashMapConformance(t *testing.T) {
+  CheckMutableMapClass(t, OpenHashMap)
+}
+
+func TestConfiguredHashSetConformance(t *testing.T) {
+  CheckMutableSetClass(t, HashSetClassWithTable(UniversalHash, UniversalEquality,
+      HashTableConfig{Capacity: 2, LoadFactor: 50}))
+}
+
+func TestConfiguredHashMapConformance(t *testing.T) {
+  CheckMutableMapClass(t, HashMapClassWithTable(UniversalHash, UniversalEquality,
+      HashTableConfig{OpenAddressing: true, Capacity: 1000, LoadFactor: 95}))
+}
@@ -0,0 +1,30 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The conformance package provides reusable test functions for checking
+// that implementations of the container classes honor the contracts of
+// the interfaces they implement. The functions take a class (i.e. a
+// factory like HashSet or ArrayQueue) and exercise it with a fixed set
+// of scenarios as well as with randomized operation sequences whose
+// results are compared against a reference implementation.
+//
+// A typical use from within a test of a custom MutableSetClass looks
+// like this:
+//
+//   func TestMySetClass(t *testing.T) {
+//     conformance.CheckMutableSetClass(t, MySet)
+//   }
+//
+// All checks use small non-negative integers as elements, keys, and values.
+package conformance
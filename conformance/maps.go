@@ -0,0 +1,465 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import "fmt"
+import "strings"
+import "testing"
+import "time"
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/maps"
+
+
+// CheckMutableMapClass runs all MutableMap conformance checks for the given class.
+func CheckMutableMapClass(t *testing.T, class MutableMapClass) {
+  t.Run("Inclusion", func (t *testing.T) {
+    CheckMapInclusion(t, class)
+  })
+  t.Run("Size", func (t *testing.T) {
+    CheckMapSize(t, class)
+  })
+  t.Run("Independence", func (t *testing.T) {
+    CheckMapIndependence(t, class)
+  })
+  t.Run("Derived", func (t *testing.T) {
+    CheckMapDerived(t, class)
+  })
+  t.Run("SelfExclusion", func (t *testing.T) {
+    CheckMapSelfExclusion(t, class)
+  })
+  t.Run("LookupWhileIterating", func (t *testing.T) {
+    CheckMapLookupWhileIterating(t, class)
+  })
+  t.Run("Compute", func (t *testing.T) {
+    CheckMapCompute(t, class)
+  })
+  t.Run("EmbeddedCompute", func (t *testing.T) {
+    CheckMapEmbeddedCompute(t, class)
+  })
+  t.Run("Model", func (t *testing.T) {
+    CheckMapModel(t, class, DefaultSteps, DefaultSeed)
+  })
+}
+
+// CheckMapInclusion verifies the semantics of Include, Exclude, Clear and Get
+// as well as the behavior of the New, From and FromNative factory methods.
+func CheckMapInclusion(t *testing.T, class MutableMapClass) {
+  m := class.New()
+  checkMap(t, m, map[int]int{}, "New()")
+  m.Include(1, 10)
+  m.Include(2, 20)
+  checkMap(t, m, map[int]int{1: 10, 2: 20}, "map after Include")
+  m.Include(1, 11)
+  checkMap(t, m, map[int]int{1: 11, 2: 20}, "map after replacing a value")
+  m.Exclude(2, 42)
+  checkMap(t, m, map[int]int{1: 11}, "map after Exclude")
+  if m.GetValue(1) != 11 {
+    t.Errorf("Expected GetValue(1) to return 11; was %v", m.GetValue(1))
+  }
+  m.Include(3, nil)
+  if value, exists := m.Get(3); !exists || value != nil {
+    t.Errorf("Expected key 3 to be mapped to nil")
+  }
+  m.Clear()
+  checkMap(t, m, map[int]int{}, "map after Clear()")
+  m.Include(4, 40)
+  checkMap(t, m, map[int]int{4: 40}, "map after including into a cleared map")
+  checkMap(t, class.New(KV(5, 50), KV(6, 60), KV(5, 51)),
+           map[int]int{5: 51, 6: 60}, "New(...)")
+  checkMap(t, class.From(Enum.New(KV(7, 70), KV(8, 80))),
+           map[int]int{7: 70, 8: 80}, "From(...)")
+  checkMap(t, class.FromNative(map[interface{}]interface{}{9: 90}),
+           map[int]int{9: 90}, "FromNative(...)")
+}
+
+// CheckMapSize verifies that Size, IsEmpty and the iterator returned by
+// Elements are consistent with each other and that every key is returned
+// exactly once.
+func CheckMapSize(t *testing.T, class MutableMapClass) {
+  m := class.New()
+  for i := 0; i < 3 * universe; i++ {
+    m.Include(i % universe, i)
+  }
+  if m.Size() != universe {
+    t.Errorf("Expected size of map to be %d; was %d", universe, m.Size())
+  }
+  for i := 0; i < universe; i += 2 {
+    m.Exclude(i)
+  }
+  if m.Size() != universe / 2 {
+    t.Errorf("Expected size of map to be %d; was %d", universe / 2, m.Size())
+  }
+  if !checkFinite(t, m, "map") || !checkFinite(t, m.KeySet(), "key set") {
+    return
+  }
+  seen := make(map[interface{}]bool)
+  m.ForEach(func (elem interface{}) {
+    entry, valid := elem.(MapEntry)
+    if !valid {
+      t.Errorf("Iterator returned %v which is not a MapEntry", elem)
+      return
+    }
+    if seen[entry.Key()] {
+      t.Errorf("Iterator returned key %v twice", entry.Key())
+    }
+    seen[entry.Key()] = true
+  })
+  if n := CountElements(m.Keys().Elements()); n != m.Size() {
+    t.Errorf("Keys returned %d keys; expected %d", n, m.Size())
+  }
+  if n := CountElements(m.Values().Elements()); n != m.Size() {
+    t.Errorf("Values returned %d values; expected %d", n, m.Size())
+  }
+}
+
+// CheckMapIndependence verifies that Copy, Immutable, ProjectValues and
+// FilterKeys return maps which are independent of the original map, whereas
+// ReadOnly and KeySet return live views.
+func CheckMapIndependence(t *testing.T, class MutableMapClass) {
+  m := class.New(KV(1, 10), KV(2, 20), KV(3, 30))
+  c := m.Copy()
+  im := m.Immutable()
+  ro := m.ReadOnly()
+  keys := m.KeySet()
+  projected := m.ProjectValues(func (x interface{}) interface{} { return x.(int) + 1 })
+  filtered := m.FilterKeys(isEven)
+  m.Include(4, 40)
+  m.Include(2, 21)
+  m.Exclude(1)
+  checkMap(t, c, map[int]int{1: 10, 2: 20, 3: 30}, "copy after modifying the original")
+  checkMap(t, im, map[int]int{1: 10, 2: 20, 3: 30}, "immutable map after modifying the original")
+  checkMap(t, ro, map[int]int{2: 21, 3: 30, 4: 40}, "read-only view after modifying the original")
+  checkSet(t, keys, setModel(2, 3, 4), "key set after modifying the original")
+  checkMap(t, projected, map[int]int{1: 11, 2: 21, 3: 31}, "ProjectValues")
+  checkMap(t, filtered, map[int]int{2: 20}, "FilterKeys")
+  c.Include(5, 50)
+  c.Exclude(3)
+  checkMap(t, m, map[int]int{2: 21, 3: 30, 4: 40}, "original after modifying the copy")
+  checkMap(t, m.Class().New(KV(6, 60)), map[int]int{6: 60}, "Class().New(...)")
+}
+
+// CheckMapDerived verifies the derived operations of MutableMap, both the
+// mutating ones and the ones returning dependent maps.
+func CheckMapDerived(t *testing.T, class MutableMapClass) {
+  m := class.New(KV(1, 10), KV(2, 20), KV(3, 30), KV(4, 40))
+  other := class.New(KV(4, 41), KV(5, 51))
+  restricted := m.RestrictTo(setOf(2, 3, 5))
+  mapped := m.MapValues(func (x interface{}) interface{} { return x.(int) * 2 })
+  overridden := other.Override(m)
+  checkMap(t, restricted, map[int]int{2: 20, 3: 30}, "RestrictTo")
+  checkMap(t, mapped, map[int]int{1: 20, 2: 40, 3: 60, 4: 80}, "MapValues")
+  checkMap(t, overridden, map[int]int{1: 10, 2: 20, 3: 30, 4: 41, 5: 51}, "Override")
+  m.Include(5, 50)
+  if value, _ := restricted.Get(5); value != 50 {
+    t.Errorf("Dependent maps do not reflect changes of the underlying map")
+  }
+  m.Exclude(5)
+  m.IncludeEntry(KV(5, 50), KV(6, 60))
+  checkMap(t, m, map[int]int{1: 10, 2: 20, 3: 30, 4: 40, 5: 50, 6: 60}, "map after IncludeEntry")
+  m.IncludeFrom(Enum.New(KV(6, 61), KV(7, 70)))
+  checkMap(t, m, map[int]int{1: 10, 2: 20, 3: 30, 4: 40, 5: 50, 6: 61, 7: 70},
+           "map after IncludeFrom")
+  m.IncludeFromNative(map[interface{}]interface{}{1: 11})
+  m.ExcludeKeys(Enum.New(2, 3, 8))
+  checkMap(t, m, map[int]int{1: 11, 4: 40, 5: 50, 6: 61, 7: 70}, "map after ExcludeKeys")
+  if !m.HasKey(4) || m.HasKey(3) {
+    t.Errorf("HasKey is inconsistent with Get")
+  }
+  if f := m.Func(); f(5) != 50 {
+    t.Errorf("Func is inconsistent with Get")
+  }
+}
+
+// CheckMapSelfExclusion verifies that keys can be excluded from a map while
+// iterating over a view of the same map.
+func CheckMapSelfExclusion(t *testing.T, class MutableMapClass) {
+  m := class.New()
+  odd := make(map[int]int)
+  for i := 0; i < 1000; i++ {
+    m.Include(i, -i)
+    if i % 2 == 1 {
+      odd[i] = -i
+    }
+  }
+  m.ExcludeKeys(m.Keys().Filter(isEven))
+  checkMap(t, m, odd, "map after excluding a filtered view of its keys")
+  m.ExcludeKeys(m.KeySet())
+  checkMap(t, m, map[int]int{}, "map after excluding its key set")
+}
+
+// CheckMapLookupWhileIterating verifies that looking up keys while iterating
+// over a map visits every entry once, also for maps whose order depends on
+// lookups. Derived operations which do this must terminate.
+func CheckMapLookupWhileIterating(t *testing.T, class MutableMapClass) {
+  m := class.New(KV(1, 10), KV(2, 20), KV(3, 30))
+  lookups := map[string]func (key interface{}) {
+    "HasKey": func (key interface{}) { m.HasKey(key) },
+    "Get": func (key interface{}) { m.Get(key) },
+  }
+  for name, lookup := range lookups {
+    visited := 0
+    for iter := m.Elements(); iter.HasNext() && visited <= m.Size(); visited++ {
+      lookup(iter.Next().(MapEntry).Key())
+    }
+    if visited != m.Size() {
+      t.Errorf("Expected iteration with %s to visit %d entries; visited %d",
+               name, m.Size(), visited)
+    }
+  }
+  terminates(t, "KeySet().IsSubsetOf", func () {
+    if !m.KeySet().IsSubsetOf(m.KeySet()) {
+      t.Errorf("Expected key set to be a subset of itself")
+    }
+  })
+  terminates(t, "Diff", func () {
+    if diff := m.Diff(m, nil); !diff.IsEmpty() {
+      t.Errorf("Expected no difference of map to itself; got %v", diff)
+    }
+  })
+  checkMap(t, m, map[int]int{1: 10, 2: 20, 3: 30}, "map after lookups while iterating")
+}
+
+// terminates fails the test if f does not return within a few seconds.
+func terminates(t *testing.T, name string, f func ()) {
+  done := make(chan bool)
+  go func () {
+    f()
+    close(done)
+  }()
+  select {
+    case <-done:
+    case <-time.After(5 * time.Second):
+      t.Fatalf("%s did not terminate", name)
+  }
+}
+
+// CheckMapCompute verifies the read-modify-write operations GetOrInclude,
+// ComputeIfAbsent, ComputeIfPresent, Compute and Merge.
+func CheckMapCompute(t *testing.T, class MutableMapClass) {
+  m := class.New(KV(1, 10), KV(2, 20))
+  if value := m.GetOrInclude(1, 11); value != 10 {
+    t.Errorf("Expected GetOrInclude(1, 11) to return 10; was %v", value)
+  }
+  if value := m.GetOrInclude(3, 30); value != 30 {
+    t.Errorf("Expected GetOrInclude(3, 30) to return 30; was %v", value)
+  }
+  calls := 0
+  inc := func (key interface{}) interface{} {
+    calls++
+    return key.(int) * 10
+  }
+  if value := m.ComputeIfAbsent(2, inc); value != 20 || calls != 0 {
+    t.Errorf("Expected ComputeIfAbsent(2) to return 20 without calling f; was %v", value)
+  }
+  if value := m.ComputeIfAbsent(4, inc); value != 40 || calls != 1 {
+    t.Errorf("Expected ComputeIfAbsent(4) to return 40; was %v", value)
+  }
+  checkMap(t, m, map[int]int{1: 10, 2: 20, 3: 30, 4: 40}, "map after GetOrInclude")
+  double := func (key, value interface{}, exists bool) (interface{}, bool) {
+    if !exists {
+      return key, true
+    }
+    return value.(int) * 2, value.(int) < 40
+  }
+  if value, exists := m.ComputeIfPresent(1, double); value != 20 || !exists {
+    t.Errorf("Expected ComputeIfPresent(1) to return (20, true); was (%v, %t)", value, exists)
+  }
+  if value, exists := m.ComputeIfPresent(5, double); value != nil || exists {
+    t.Errorf("Expected ComputeIfPresent(5) to return (nil, false); was (%v, %t)", value, exists)
+  }
+  if value, exists := m.ComputeIfPresent(4, double); value != nil || exists {
+    t.Errorf("Expected ComputeIfPresent(4) to exclude 4; was (%v, %t)", value, exists)
+  }
+  if value, exists := m.Compute(6, double); value != 6 || !exists {
+    t.Errorf("Expected Compute(6) to return (6, true); was (%v, %t)", value, exists)
+  }
+  if value, exists := m.Compute(2, double); value != 40 || !exists {
+    t.Errorf("Expected Compute(2) to return (40, true); was (%v, %t)", value, exists)
+  }
+  checkMap(t, m, map[int]int{1: 20, 2: 40, 3: 30, 6: 6}, "map after Compute")
+  sum := func (x, y interface{}) interface{} { return x.(int) + y.(int) }
+  if value := m.Merge(3, 5, sum); value != 35 {
+    t.Errorf("Expected Merge(3, 5) to return 35; was %v", value)
+  }
+  if value := m.Merge(7, 5, sum); value != 5 {
+    t.Errorf("Expected Merge(7, 5) to return 5; was %v", value)
+  }
+  checkMap(t, m, map[int]int{1: 20, 2: 40, 3: 35, 6: 6, 7: 5}, "map after Merge")
+}
+
+// CheckMapEmbeddedCompute verifies that the compute and merge operations of
+// maps of the given class dispatch to the primitives of an embedding map,
+// such that wrappers which only override Include and Exclude observe every
+// change.
+func CheckMapEmbeddedCompute(t *testing.T, class MutableMapClass) {
+  CheckMapCompute(t, &recordingMapClass{class, new([]string)})
+  wrapper := &recordingMapClass{class, new([]string)}
+  m := wrapper.New(KV(1, 10), KV(2, 20))
+  *wrapper.events = nil
+  sum := func (x, y interface{}) interface{} { return x.(int) + y.(int) }
+  m.Merge(1, 5, sum)
+  m.Merge(3, 5, sum)
+  m.ComputeIfAbsent(4, func (key interface{}) interface{} { return key.(int) * 10 })
+  m.GetOrInclude(5, 50)
+  m.GetOrInclude(5, 51)
+  m.Compute(2, func (key, value interface{}, exists bool) (interface{}, bool) {
+    return nil, false
+  })
+  m.ComputeIfPresent(4, func (key, value interface{}, exists bool) (interface{}, bool) {
+    return value.(int) * 2, true
+  })
+  checkMap(t, m, map[int]int{1: 15, 3: 5, 4: 80, 5: 50}, "embedded map after Compute")
+  expected := "+1:15 +3:5 +4:40 +5:50 -2 +4:80"
+  if events := strings.Join(*wrapper.events, " "); events != expected {
+    t.Errorf("Unexpected changes %s of embedded map; expected %s", events, expected)
+  }
+}
+
+// recordingMapClass embeds maps of class into maps which record all
+// invocations of Include and Exclude.
+type recordingMapClass struct {
+  class MutableMapClass
+  events *[]string
+}
+
+func (this *recordingMapClass) Embed(obj MutableMap) MutableMap {
+  res := &recordingMap{events: this.events}
+  if obj == nil {
+    obj = res
+  }
+  res.MutableMap = this.class.Embed(obj)
+  return res
+}
+
+func (this *recordingMapClass) New(entries... MapEntry) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeEntry(entries...)
+  return res
+}
+
+func (this *recordingMapClass) From(coll Container) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+func (this *recordingMapClass) FromNative(mp map[interface{}] interface{}) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFromNative(mp)
+  return res
+}
+
+type recordingMap struct {
+  events *[]string
+  MutableMap
+}
+
+func (this *recordingMap) Include(key, value interface{}) {
+  *this.events = append(*this.events, fmt.Sprintf("+%v:%v", key, value))
+  this.MutableMap.Include(key, value)
+}
+
+func (this *recordingMap) Exclude(keys ...interface{}) {
+  for _, key := range keys {
+    *this.events = append(*this.events, fmt.Sprintf("-%v", key))
+  }
+  this.MutableMap.Exclude(keys...)
+}
+
+// CheckMapModel performs the given number of randomized operations on a new
+// map of the given class and on a reference implementation, and verifies
+// after every step that both agree.
+func CheckMapModel(t *testing.T, class MutableMapClass, steps int, seed int64) {
+  rnd := newRandom(seed)
+  m := class.New()
+  model := make(map[int]int)
+  for step := 0; step < steps; step++ {
+    var op string
+    switch k, v := rnd.Intn(universe), rnd.Intn(1000); rnd.Intn(10) {
+      case 0, 1, 2, 3:
+        op = "Include"
+        m.Include(k, v)
+        model[k] = v
+      case 4, 5:
+        op = "Exclude"
+        m.Exclude(k)
+        delete(model, k)
+      case 6:
+        op = "IncludeFrom"
+        keys := randomInts(rnd, 8)
+        m.IncludeFrom(keys.Map(func (key interface{}) interface{} {
+          return KV(key, key.(int) + v)
+        }))
+        keys.ForEach(func (key interface{}) { model[key.(int)] = key.(int) + v })
+      case 7:
+        op = "ExcludeKeys"
+        keys := randomInts(rnd, 8)
+        m.ExcludeKeys(keys)
+        keys.ForEach(func (key interface{}) { delete(model, key.(int)) })
+      case 8:
+        op = "Get"
+        value, exists := m.Get(k)
+        expected, expectedExists := model[k]
+        if exists != expectedExists || (exists && value != expected) {
+          t.Fatalf("Get(%d) returned (%v, %t) in step %d; expected (%v, %t)",
+                   k, value, exists, step, expected, expectedExists)
+        }
+      case 9:
+        if rnd.Intn(20) == 0 {
+          op = "Clear"
+          m.Clear()
+          model = make(map[int]int)
+        } else {
+          op = "Copy"
+          m = m.Copy()
+        }
+    }
+    if !checkMap(t, m, model, "map after " + op) {
+      t.Fatalf("Map diverged from model in step %d (%s)", step, op)
+    }
+  }
+}
+
+// checkMap verifies that the given map contains exactly the mappings of the
+// model.
+func checkMap(t *testing.T, m Map, model map[int]int, name string) bool {
+  if m.Size() != len(model) {
+    t.Errorf("Expected size of %s to be %d; was %d", name, len(model), m.Size())
+    return false
+  }
+  for i := 0; i < universe; i++ {
+    value, exists := m.Get(i)
+    expected, expectedExists := model[i]
+    if exists != expectedExists || (exists && value != expected) {
+      t.Errorf("Expected Get(%d) of %s to return (%v, %t); was (%v, %t)",
+               i, name, expected, expectedExists, value, exists)
+      return false
+    }
+  }
+  for iter := m.Elements(); iter.HasNext(); {
+    elem := iter.Next()
+    entry, valid := elem.(MapEntry)
+    if !valid {
+      t.Errorf("Iterator of %s returned %v which is not a MapEntry", name, elem)
+      return false
+    }
+    key, valid := entry.Key().(int)
+    if expected, exists := model[key]; !valid || !exists || entry.Value() != expected {
+      t.Errorf("Iterator of %s returned unexpected entry %v", name, entry)
+      return false
+    }
+  }
+  return checkFinite(t, m, name)
+}
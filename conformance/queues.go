@@ -0,0 +1,184 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/buffers"
+
+
+// CheckQueueClass runs all Queue conformance checks for the given class which
+// do not depend on the order in which elements get dequeued.
+func CheckQueueClass(t *testing.T, class QueueClass) {
+  t.Run("Queueing", func (t *testing.T) {
+    CheckQueueing(t, class)
+  })
+  t.Run("Independence", func (t *testing.T) {
+    CheckQueueIndependence(t, class)
+  })
+  t.Run("Model", func (t *testing.T) {
+    CheckQueueModel(t, class, false, DefaultSteps, DefaultSeed)
+  })
+}
+
+// CheckFifoQueueClass runs all Queue conformance checks for the given class
+// and, in addition, verifies that elements get dequeued in the order in
+// which they were enqueued.
+func CheckFifoQueueClass(t *testing.T, class QueueClass) {
+  CheckQueueClass(t, class)
+  t.Run("FifoModel", func (t *testing.T) {
+    CheckQueueModel(t, class, true, DefaultSteps, DefaultSeed)
+  })
+}
+
+// CheckQueueing verifies the semantics of Enqueue, Dequeue, Peek and Clear as
+// well as the behavior of the New and From factory methods.
+func CheckQueueing(t *testing.T, class QueueClass) {
+  q := class.New()
+  checkQueue(t, q, nil, false, "New()")
+  q.Enqueue(1)
+  q.Enqueue(2)
+  q.Enqueue(1)
+  checkQueue(t, q, []int{1, 2, 1}, false, "queue after Enqueue")
+  if peek, next := q.Peek(), q.Dequeue(); peek != next {
+    t.Errorf("Peek returned %v, but Dequeue returned %v", peek, next)
+  }
+  if q.Size() != 2 {
+    t.Errorf("Expected size of queue to be 2; was %d", q.Size())
+  }
+  q.Clear()
+  checkQueue(t, q, nil, false, "queue after Clear()")
+  q.Enqueue(3)
+  checkQueue(t, q, []int{3}, false, "queue after enqueuing into a cleared queue")
+  if q.Dequeue() != 3 || !q.IsEmpty() {
+    t.Errorf("Expected to dequeue 3 from a queue with a single element")
+  }
+  checkQueue(t, class.New(4, 5, 4), []int{4, 5, 4}, false, "New(4, 5, 4)")
+  checkQueue(t, class.From(Enum.New(6, 7)), []int{6, 7}, false, "From(Enum.New(6, 7))")
+}
+
+// CheckQueueIndependence verifies that Copy returns a queue that is
+// independent of the original queue.
+func CheckQueueIndependence(t *testing.T, class QueueClass) {
+  q := class.New(1, 2, 3)
+  c := q.Copy()
+  q.Enqueue(4)
+  q.Dequeue()
+  checkQueue(t, c, []int{1, 2, 3}, false, "copy after modifying the original")
+  c.Clear()
+  if q.Size() != 3 {
+    t.Errorf("Expected size of original to be 3 after clearing the copy; was %d", q.Size())
+  }
+  checkQueue(t, q.Class().New(5), []int{5}, false, "Class().New(5)")
+}
+
+// CheckQueueModel performs the given number of randomized operations on a new
+// queue of the given class and on a reference implementation, and verifies
+// after every step that both agree. If fifo is true, the order of the
+// elements is checked as well.
+func CheckQueueModel(t *testing.T, class QueueClass, fifo bool, steps int, seed int64) {
+  rnd := newRandom(seed)
+  q := class.New()
+  var model []int
+  for step := 0; step < steps; step++ {
+    var op string
+    switch k := rnd.Intn(universe); rnd.Intn(10) {
+      case 0, 1, 2, 3:
+        op = "Enqueue"
+        q.Enqueue(k)
+        model = append(model, k)
+      case 4, 5, 6:
+        if len(model) > 0 {
+          op = "Dequeue"
+          next := q.Dequeue()
+          i := indexOf(model, next)
+          if i < 0 || (fifo && i != 0) {
+            t.Fatalf("Dequeue returned unexpected element %v in step %d", next, step)
+          }
+          model = append(model[:i], model[i + 1:]...)
+        } else {
+          op = "IsEmpty"
+        }
+      case 7:
+        op = "EnqueueFrom"
+        elements := randomInts(rnd, 8)
+        q.EnqueueFrom(elements)
+        elements.ForEach(func (e interface{}) { model = append(model, e.(int)) })
+      case 8:
+        if rnd.Intn(10) == 0 {
+          op = "Clear"
+          q.Clear()
+          model = nil
+        } else {
+          op = "Copy"
+          q = q.Copy()
+        }
+      case 9:
+        op = "Peek"
+        if len(model) > 0 {
+          if peek := q.Peek(); indexOf(model, peek) < 0 || (fifo && peek != model[0]) {
+            t.Fatalf("Peek returned unexpected element %v in step %d", peek, step)
+          }
+        }
+    }
+    if !checkQueue(t, q, model, fifo, "queue after " + op) {
+      t.Fatalf("Queue diverged from model in step %d (%s)", step, op)
+    }
+  }
+}
+
+// checkQueue verifies that the given queue contains exactly the elements of
+// the model. If ordered is true, the iterator needs to return the elements in
+// the order of the model.
+func checkQueue(t *testing.T, q Queue, model []int, ordered bool, name string) bool {
+  if q.Size() != len(model) {
+    t.Errorf("Expected size of %s to be %d; was %d", name, len(model), q.Size())
+    return false
+  }
+  elements := collect(q)
+  if len(elements) != len(model) {
+    t.Errorf("Iterator of %s returned %d elements; expected %d",
+             name, len(elements), len(model))
+    return false
+  }
+  counts := make(map[interface{}]int)
+  for i, elem := range elements {
+    if ordered && elem != model[i] {
+      t.Errorf("Iterator of %s returned %v at position %d; expected %d",
+               name, elem, i, model[i])
+      return false
+    }
+    counts[elem]++
+  }
+  for _, elem := range model {
+    counts[elem]--
+  }
+  for elem, count := range counts {
+    if count != 0 {
+      t.Errorf("Iterator of %s returned element %v an unexpected number of times", name, elem)
+      return false
+    }
+  }
+  return checkFinite(t, q, name)
+}
+
+func indexOf(model []int, elem interface{}) int {
+  for i, e := range model {
+    if e == elem {
+      return i
+    }
+  }
+  return -1
+}
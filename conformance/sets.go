@@ -0,0 +1,300 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/sets"
+
+
+// CheckMutableSetClass runs all MutableSet conformance checks for the given class.
+func CheckMutableSetClass(t *testing.T, class MutableSetClass) {
+  t.Run("Inclusion", func (t *testing.T) {
+    CheckSetInclusion(t, class)
+  })
+  t.Run("Size", func (t *testing.T) {
+    CheckSetSize(t, class)
+  })
+  t.Run("Independence", func (t *testing.T) {
+    CheckSetIndependence(t, class)
+  })
+  t.Run("Derived", func (t *testing.T) {
+    CheckSetDerived(t, class)
+  })
+  t.Run("SelfExclusion", func (t *testing.T) {
+    CheckSetSelfExclusion(t, class)
+  })
+  t.Run("Model", func (t *testing.T) {
+    CheckSetModel(t, class, DefaultSteps, DefaultSeed)
+  })
+}
+
+// CheckSetInclusion verifies the semantics of Include, Exclude and Clear as
+// well as the behavior of the New and From factory methods.
+func CheckSetInclusion(t *testing.T, class MutableSetClass) {
+  s := class.New()
+  checkSet(t, s, map[int]bool{}, "New()")
+  s.Include(1, 2, 3, 2)
+  checkSet(t, s, setModel(1, 2, 3), "set after Include(1, 2, 3, 2)")
+  s.Include(1)
+  checkSet(t, s, setModel(1, 2, 3), "set after including an existing element")
+  s.Exclude(2)
+  checkSet(t, s, setModel(1, 3), "set after Exclude(2)")
+  s.Exclude(42)
+  checkSet(t, s, setModel(1, 3), "set after excluding a missing element")
+  s.Exclude(1, 3)
+  checkSet(t, s, setModel(), "set after excluding all elements")
+  s.Include(4, 5)
+  s.Clear()
+  checkSet(t, s, setModel(), "set after Clear()")
+  s.Include(6)
+  checkSet(t, s, setModel(6), "set after including into a cleared set")
+  checkSet(t, class.New(7, 8, 7), setModel(7, 8), "New(7, 8, 7)")
+  checkSet(t, class.From(Enum.New(9, 10, 9)), setModel(9, 10), "From(Enum.New(9, 10, 9))")
+  checkSet(t, class.From(Enum.Empty()), setModel(), "From(Enum.Empty())")
+}
+
+// CheckSetSize verifies that Size, IsEmpty and the iterator returned by
+// Elements are consistent with each other and that iterators never return
+// duplicates.
+func CheckSetSize(t *testing.T, class MutableSetClass) {
+  s := class.New()
+  for i := 0; i < 3 * universe; i++ {
+    s.Include(i % universe)
+    expected := i + 1
+    if expected > universe {
+      expected = universe
+    }
+    if s.Size() != expected {
+      t.Errorf("Expected size of set to be %d; was %d", expected, s.Size())
+      return
+    }
+  }
+  for i := 0; i < universe; i += 2 {
+    s.Exclude(i)
+    if s.Size() != universe - (i / 2 + 1) {
+      t.Errorf("Expected size of set to be %d; was %d", universe - (i / 2 + 1), s.Size())
+      return
+    }
+  }
+  if !checkFinite(t, s, "set") {
+    return
+  }
+  seen := make(map[interface{}]bool)
+  count := 0
+  s.ForEach(func (elem interface{}) {
+    if seen[elem] {
+      t.Errorf("Iterator returned element %v twice", elem)
+    }
+    seen[elem] = true
+    count++
+  })
+  if count != s.Size() {
+    t.Errorf("ForEach visited %d elements; expected %d", count, s.Size())
+  }
+}
+
+// CheckSetIndependence verifies that Copy, Freeze and Immutable return sets
+// which are independent of the original set, whereas ReadOnly returns a
+// live view.
+func CheckSetIndependence(t *testing.T, class MutableSetClass) {
+  s := class.New(1, 2, 3)
+  c := s.Copy()
+  f := s.Freeze()
+  im := s.Immutable()
+  ro := s.ReadOnly()
+  s.Include(4)
+  s.Exclude(1)
+  checkSet(t, c, setModel(1, 2, 3), "copy after modifying the original")
+  checkSet(t, im, setModel(1, 2, 3), "immutable set after modifying the original")
+  checkSet(t, ro, setModel(2, 3, 4), "read-only view after modifying the original")
+  if frozen := collect(f); len(frozen) != 3 {
+    t.Errorf("Expected frozen set to have 3 elements; had %d", len(frozen))
+  }
+  c.Include(5)
+  c.Exclude(2)
+  checkSet(t, s, setModel(2, 3, 4), "original after modifying the copy")
+  checkSet(t, s.Class().New(6), setModel(6), "Class().New(6)")
+}
+
+// CheckSetDerived verifies the derived operations of MutableSet, both the
+// mutating ones and the ones returning dependent sets.
+func CheckSetDerived(t *testing.T, class MutableSetClass) {
+  s := class.New(1, 2, 3, 4)
+  other := class.New(3, 4, 5, 6)
+  union := s.Union(other)
+  intersection := s.Intersection(other)
+  difference := s.Difference(other)
+  checkSet(t, union, setModel(1, 2, 3, 4, 5, 6), "Union")
+  checkSet(t, intersection, setModel(3, 4), "Intersection")
+  checkSet(t, difference, setModel(1, 2), "Difference")
+  symmetric := s.SymmetricDifference(other)
+  checkSet(t, symmetric, setModel(1, 2, 5, 6), "SymmetricDifference")
+  s.Include(7)
+  if !union.Contains(7) || intersection.Contains(7) || !difference.Contains(7) ||
+     !symmetric.Contains(7) {
+    t.Errorf("Dependent sets do not reflect changes of the underlying set")
+  }
+  s.Exclude(7)
+  if !s.ContainsAll(1, 2) || s.ContainsAll(1, 5) {
+    t.Errorf("ContainsAll is inconsistent with Contains")
+  }
+  if !s.ContainsNone(5, 6) || s.ContainsNone(1, 5) {
+    t.Errorf("ContainsNone is inconsistent with Contains")
+  }
+  if !s.ContainsSome(1, 5) || s.ContainsSome(5, 6) {
+    t.Errorf("ContainsSome is inconsistent with Contains")
+  }
+  if !s.ContainsSomeFrom(other) || s.ContainsAllFrom(other) || s.ContainsNoneFrom(other) {
+    t.Errorf("ContainsXXXFrom is inconsistent with Contains")
+  }
+  if !s.IsSubsetOf(union) || s.IsSubsetOf(other) || !union.IsSupersetOf(s) ||
+     s.IsSupersetOf(union) || s.IsDisjoint(other) || !difference.IsDisjoint(other) {
+    t.Errorf("Subset predicates are inconsistent with Contains")
+  }
+  if pred := s.Func(); !pred(1) || pred(5) {
+    t.Errorf("Func is inconsistent with Contains")
+  }
+  if n := CountElements(s.Filter(isEven).Elements()); n != 2 {
+    t.Errorf("Expected filtered set to have 2 elements; had %d", n)
+  }
+  s.IncludeFrom(Enum.New(5, 6, 1))
+  checkSet(t, s, setModel(1, 2, 3, 4, 5, 6), "set after IncludeFrom")
+  s.ExcludeFrom(Enum.New(5, 6, 7))
+  checkSet(t, s, setModel(1, 2, 3, 4), "set after ExcludeFrom")
+  s.IntersectWith(Enum.New(2, 3, 4, 8))
+  checkSet(t, s, setModel(2, 3, 4), "set after IntersectWith")
+  s.ExcludeIf(isEven)
+  checkSet(t, s, setModel(3), "set after ExcludeIf")
+}
+
+// CheckSetSelfExclusion verifies that elements can be excluded from a set
+// while iterating over the same set or a view of it.
+func CheckSetSelfExclusion(t *testing.T, class MutableSetClass) {
+  s := class.New()
+  odd := make(map[int]bool)
+  for i := 0; i < 1000; i++ {
+    s.Include(i)
+    if i % 2 == 1 {
+      odd[i] = true
+    }
+  }
+  s.ExcludeFrom(s.Filter(isEven))
+  checkSet(t, s, odd, "set after excluding a filtered view of itself")
+  s.ExcludeFrom(s)
+  checkSet(t, s, setModel(), "set after excluding itself")
+}
+
+// CheckSetModel performs the given number of randomized operations on a new
+// set of the given class and on a reference implementation, and verifies
+// after every step that both agree.
+func CheckSetModel(t *testing.T, class MutableSetClass, steps int, seed int64) {
+  rnd := newRandom(seed)
+  s := class.New()
+  model := make(map[int]bool)
+  for step := 0; step < steps; step++ {
+    var op string
+    switch k := rnd.Intn(universe); rnd.Intn(10) {
+      case 0, 1, 2:
+        op = "Include"
+        s.Include(k)
+        model[k] = true
+      case 3, 4:
+        op = "Exclude"
+        s.Exclude(k)
+        delete(model, k)
+      case 5:
+        op = "IncludeFrom"
+        elements := randomInts(rnd, 8)
+        s.IncludeFrom(elements)
+        elements.ForEach(func (e interface{}) { model[e.(int)] = true })
+      case 6:
+        op = "ExcludeFrom"
+        elements := randomInts(rnd, 8)
+        s.ExcludeFrom(elements)
+        elements.ForEach(func (e interface{}) { delete(model, e.(int)) })
+      case 7:
+        op = "IntersectWith"
+        elements := randomInts(rnd, 3 * universe)
+        s.IntersectWith(elements)
+        keep := make(map[int]bool)
+        elements.ForEach(func (e interface{}) { keep[e.(int)] = true })
+        for e := range model {
+          if !keep[e] {
+            delete(model, e)
+          }
+        }
+      case 8:
+        op = "ExcludeIf"
+        s.ExcludeIf(func (e interface{}) bool { return e.(int) % 7 == k % 7 })
+        for e := range model {
+          if e % 7 == k % 7 {
+            delete(model, e)
+          }
+        }
+      case 9:
+        if rnd.Intn(20) == 0 {
+          op = "Clear"
+          s.Clear()
+          model = make(map[int]bool)
+        } else {
+          op = "Copy"
+          s = s.Copy()
+        }
+    }
+    if !checkSet(t, s, model, "set after " + op) {
+      t.Fatalf("Set diverged from model in step %d (%s)", step, op)
+    }
+  }
+}
+
+func setModel(elements ...int) map[int]bool {
+  res := make(map[int]bool)
+  for _, e := range elements {
+    res[e] = true
+  }
+  return res
+}
+
+// checkSet verifies that the given set contains exactly the elements of the
+// model.
+func checkSet(t *testing.T, s Set, model map[int]bool, name string) bool {
+  if s.Size() != len(model) {
+    t.Errorf("Expected size of %s to be %d; was %d", name, len(model), s.Size())
+    return false
+  }
+  for i := 0; i < universe; i++ {
+    if s.Contains(i) != model[i] {
+      t.Errorf("Expected Contains(%d) of %s to be %t", i, name, model[i])
+      return false
+    }
+  }
+  for iter := s.Elements(); iter.HasNext(); {
+    elem := iter.Next()
+    if i, valid := elem.(int); !valid || !model[i] {
+      t.Errorf("Iterator of %s returned unexpected element %v", name, elem)
+      return false
+    }
+  }
+  return checkFinite(t, s, name)
+}
+
+func isEven(x interface{}) bool {
+  return x.(int) % 2 == 0
+}
+
+func setOf(elements ...interface{}) Set {
+  return ImmutableListSet.New(elements...)
+}
@@ -0,0 +1,363 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import . "github.com/objecthub/containerkit"
+
+
+// TreeNode represents a single node of an AvlTree. Nodes keep their identity
+// for as long as they are part of the tree, i.e. rebalancing the tree never
+// moves keys or values between nodes.
+type TreeNode struct {
+  Key interface{}
+  Value interface{}
+  parent *TreeNode
+  left *TreeNode
+  right *TreeNode
+  height int
+}
+
+// Next returns the node following this node in the order of the tree, or nil
+// if this is the last node.
+func (this *TreeNode) Next() *TreeNode {
+  if this.right != nil {
+    return this.right.leftmost()
+  }
+  node := this
+  for node.parent != nil && node.parent.right == node {
+    node = node.parent
+  }
+  return node.parent
+}
+
+// Prev returns the node preceding this node in the order of the tree, or nil
+// if this is the first node.
+func (this *TreeNode) Prev() *TreeNode {
+  if this.left != nil {
+    return this.left.rightmost()
+  }
+  node := this
+  for node.parent != nil && node.parent.left == node {
+    node = node.parent
+  }
+  return node.parent
+}
+
+func (this *TreeNode) leftmost() *TreeNode {
+  node := this
+  for node.left != nil {
+    node = node.left
+  }
+  return node
+}
+
+func (this *TreeNode) rightmost() *TreeNode {
+  node := this
+  for node.right != nil {
+    node = node.right
+  }
+  return node
+}
+
+// AvlTree implements a height-balanced binary search tree whose nodes are
+// ordered by a Comparison function on their keys.
+type AvlTree struct {
+  root *TreeNode
+  size int
+  comp Comparison
+}
+
+func NewAvlTree(comp Comparison) *AvlTree {
+  return &AvlTree{nil, 0, comp}
+}
+
+func (this *AvlTree) Size() int {
+  return this.size
+}
+
+func (this *AvlTree) Comparison() Comparison {
+  return this.comp
+}
+
+func (this *AvlTree) Clear() {
+  this.root = nil
+  this.size = 0
+}
+
+// FirstNode returns the node with the smallest key, or nil if the tree is empty.
+func (this *AvlTree) FirstNode() *TreeNode {
+  if this.root == nil {
+    return nil
+  }
+  return this.root.leftmost()
+}
+
+// LastNode returns the node with the largest key, or nil if the tree is empty.
+func (this *AvlTree) LastNode() *TreeNode {
+  if this.root == nil {
+    return nil
+  }
+  return this.root.rightmost()
+}
+
+// FindNode returns the node for the given key, or nil if there is none.
+func (this *AvlTree) FindNode(key interface{}) *TreeNode {
+  node := this.root
+  for node != nil {
+    c := this.comp(key, node.Key)
+    if c == 0 {
+      return node
+    } else if c < 0 {
+      node = node.left
+    } else {
+      node = node.right
+    }
+  }
+  return nil
+}
+
+// FloorNode returns the node with the largest key less than or equal to the
+// given key, or nil if there is none.
+func (this *AvlTree) FloorNode(key interface{}) *TreeNode {
+  return this.search(key, true, false)
+}
+
+// CeilingNode returns the node with the smallest key greater than or equal to
+// the given key, or nil if there is none.
+func (this *AvlTree) CeilingNode(key interface{}) *TreeNode {
+  return this.search(key, true, true)
+}
+
+// LowerNode returns the node with the largest key strictly less than the given
+// key, or nil if there is none.
+func (this *AvlTree) LowerNode(key interface{}) *TreeNode {
+  return this.search(key, false, false)
+}
+
+// HigherNode returns the node with the smallest key strictly greater than the
+// given key, or nil if there is none.
+func (this *AvlTree) HigherNode(key interface{}) *TreeNode {
+  return this.search(key, false, true)
+}
+
+func (this *AvlTree) search(key interface{}, inclusive bool, above bool) *TreeNode {
+  var best *TreeNode
+  node := this.root
+  for node != nil {
+    c := this.comp(key, node.Key)
+    if c == 0 && inclusive {
+      return node
+    } else if c < 0 || (c == 0 && !above) {
+      if above {
+        best = node
+      }
+      node = node.left
+    } else {
+      if !above {
+        best = node
+      }
+      node = node.right
+    }
+  }
+  return best
+}
+
+// InsertNode adds a new node for the given key and value to the tree and
+// returns it together with true. If there is a node for the key already,
+// this node is returned unchanged together with false.
+func (this *AvlTree) InsertNode(key, value interface{}) (*TreeNode, bool) {
+  var parent *TreeNode
+  c := 0
+  for node := this.root; node != nil; {
+    parent = node
+    c = this.comp(key, node.Key)
+    if c == 0 {
+      return node, false
+    } else if c < 0 {
+      node = node.left
+    } else {
+      node = node.right
+    }
+  }
+  res := &TreeNode{key, value, parent, nil, nil, 1}
+  if parent == nil {
+    this.root = res
+  } else if c < 0 {
+    parent.left = res
+  } else {
+    parent.right = res
+  }
+  this.size++
+  this.rebalance(parent)
+  return res, true
+}
+
+// DeleteKey removes the node for the given key from the tree and returns it.
+// DeleteKey returns nil if there is no node for the key.
+func (this *AvlTree) DeleteKey(key interface{}) *TreeNode {
+  node := this.FindNode(key)
+  if node != nil {
+    this.DeleteNode(node)
+  }
+  return node
+}
+
+// DeleteNode removes the given node from the tree.
+func (this *AvlTree) DeleteNode(node *TreeNode) {
+  if node.left != nil && node.right != nil {
+    succ := node.right.leftmost()
+    from := succ
+    if succ.parent != node {
+      from = succ.parent
+      from.left = succ.right
+      if succ.right != nil {
+        succ.right.parent = from
+      }
+      succ.right = node.right
+      node.right.parent = succ
+    }
+    succ.left = node.left
+    node.left.parent = succ
+    succ.parent = node.parent
+    succ.height = node.height
+    this.replaceChild(node.parent, node, succ)
+    this.rebalance(from)
+  } else {
+    child := node.left
+    if child == nil {
+      child = node.right
+    }
+    if child != nil {
+      child.parent = node.parent
+    }
+    this.replaceChild(node.parent, node, child)
+    this.rebalance(node.parent)
+  }
+  node.parent = nil
+  node.left = nil
+  node.right = nil
+  this.size--
+}
+
+func (this *AvlTree) replaceChild(parent, old, node *TreeNode) {
+  if parent == nil {
+    this.root = node
+  } else if parent.left == old {
+    parent.left = node
+  } else {
+    parent.right = node
+  }
+}
+
+func height(node *TreeNode) int {
+  if node == nil {
+    return 0
+  }
+  return node.height
+}
+
+func (this *TreeNode) updateHeight() {
+  l, r := height(this.left), height(this.right)
+  if l > r {
+    this.height = l + 1
+  } else {
+    this.height = r + 1
+  }
+}
+
+func (this *AvlTree) rotateLeft(node *TreeNode) *TreeNode {
+  res := node.right
+  node.right = res.left
+  if res.left != nil {
+    res.left.parent = node
+  }
+  res.parent = node.parent
+  this.replaceChild(node.parent, node, res)
+  res.left = node
+  node.parent = res
+  node.updateHeight()
+  res.updateHeight()
+  return res
+}
+
+func (this *AvlTree) rotateRight(node *TreeNode) *TreeNode {
+  res := node.left
+  node.left = res.right
+  if res.right != nil {
+    res.right.parent = node
+  }
+  res.parent = node.parent
+  this.replaceChild(node.parent, node, res)
+  res.right = node
+  node.parent = res
+  node.updateHeight()
+  res.updateHeight()
+  return res
+}
+
+func (this *AvlTree) rebalance(node *TreeNode) {
+  for ; node != nil; node = node.parent {
+    node.updateHeight()
+    balance := height(node.left) - height(node.right)
+    if balance > 1 {
+      if height(node.left.left) < height(node.left.right) {
+        this.rotateLeft(node.left)
+      }
+      node = this.rotateRight(node)
+    } else if balance < -1 {
+      if height(node.right.right) < height(node.right.left) {
+        this.rotateRight(node.right)
+      }
+      node = this.rotateLeft(node)
+    }
+  }
+}
+
+// Iterator returns an iterator over all nodes of the tree in ascending order.
+func (this *AvlTree) Iterator() *TreeNodeIterator {
+  return NewTreeNodeIterator(this.FirstNode(), this.LastNode(), true)
+}
+
+// NewTreeNodeIterator returns an iterator over the nodes starting with first
+// and ending with last. If ascending is false, the nodes are iterated in
+// descending order. If first is nil, the iterator is empty.
+func NewTreeNodeIterator(first, last *TreeNode, ascending bool) *TreeNodeIterator {
+  return &TreeNodeIterator{first, last, ascending}
+}
+
+type TreeNodeIterator struct {
+  next *TreeNode
+  last *TreeNode
+  ascending bool
+}
+
+func (this *TreeNodeIterator) HasNext() bool {
+  return this.next != nil
+}
+
+func (this *TreeNodeIterator) Next() *TreeNode {
+  if this.next == nil {
+    panic("TreeNodeIterator.Next: no next node")
+  }
+  res := this.next
+  if res == this.last {
+    this.next = nil
+  } else if this.ascending {
+    this.next = res.Next()
+  } else {
+    this.next = res.Prev()
+  }
+  return res
+}
@@ -0,0 +1,114 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import "math/rand"
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func checkAvlTree(t *testing.T, tree *AvlTree, expected map[int]bool) {
+  if tree.Size() != len(expected) {
+    t.Fatalf("Expected size of tree to be %d; was %d", len(expected), tree.Size())
+  }
+  count := 0
+  prev := -1
+  for iter := tree.Iterator(); iter.HasNext(); {
+    key := iter.Next().Key.(int)
+    if key <= prev || !expected[key] {
+      t.Fatalf("Unexpected key %d after %d", key, prev)
+    }
+    prev = key
+    count++
+  }
+  if count != len(expected) {
+    t.Fatalf("Iterator returned %d nodes; expected %d", count, len(expected))
+  }
+  checkAvlNode(t, tree.root, nil)
+}
+
+func checkAvlNode(t *testing.T, node *TreeNode, parent *TreeNode) int {
+  if node == nil {
+    return 0
+  }
+  if node.parent != parent {
+    t.Fatalf("Node %v has an inconsistent parent link", node.Key)
+  }
+  l := checkAvlNode(t, node.left, node)
+  r := checkAvlNode(t, node.right, node)
+  if l - r > 1 || r - l > 1 {
+    t.Fatalf("Node %v is unbalanced (%d vs %d)", node.Key, l, r)
+  }
+  if node.height != 1 + max(l, r) {
+    t.Fatalf("Node %v has height %d; expected %d", node.Key, node.height, 1 + max(l, r))
+  }
+  return node.height
+}
+
+func TestAvlTree(t *testing.T) {
+  tree := NewAvlTree(UniversalComparison)
+  expected := make(map[int]bool)
+  rnd := rand.New(rand.NewSource(1))
+  for i := 0; i < 5000; i++ {
+    key := rnd.Intn(200)
+    if rnd.Intn(3) == 0 {
+      node := tree.DeleteKey(key)
+      if (node != nil) != expected[key] {
+        t.Fatalf("DeleteKey(%d) is inconsistent with the tree content", key)
+      }
+      delete(expected, key)
+    } else {
+      _, added := tree.InsertNode(key, nil)
+      if added == expected[key] {
+        t.Fatalf("InsertNode(%d) is inconsistent with the tree content", key)
+      }
+      expected[key] = true
+    }
+    checkAvlTree(t, tree, expected)
+  }
+}
+
+func TestAvlTreeNavigation(t *testing.T) {
+  tree := NewAvlTree(UniversalComparison)
+  for i := 0; i < 20; i += 2 {
+    tree.InsertNode(i, nil)
+  }
+  checkNode := func (node *TreeNode, expected interface{}, name string) {
+    if (node == nil && expected != nil) || (node != nil && node.Key != expected) {
+      t.Errorf("Unexpected result of %s", name)
+    }
+  }
+  checkNode(tree.FirstNode(), 0, "FirstNode")
+  checkNode(tree.LastNode(), 18, "LastNode")
+  checkNode(tree.FloorNode(5), 4, "FloorNode(5)")
+  checkNode(tree.FloorNode(6), 6, "FloorNode(6)")
+  checkNode(tree.FloorNode(-1), nil, "FloorNode(-1)")
+  checkNode(tree.CeilingNode(5), 6, "CeilingNode(5)")
+  checkNode(tree.CeilingNode(6), 6, "CeilingNode(6)")
+  checkNode(tree.CeilingNode(19), nil, "CeilingNode(19)")
+  checkNode(tree.LowerNode(6), 4, "LowerNode(6)")
+  checkNode(tree.LowerNode(0), nil, "LowerNode(0)")
+  checkNode(tree.HigherNode(6), 8, "HigherNode(6)")
+  checkNode(tree.HigherNode(18), nil, "HigherNode(18)")
+  checkNode(tree.FindNode(8).Prev(), 6, "Prev")
+  checkNode(tree.FindNode(8).Next(), 10, "Next")
+  iter := NewTreeNodeIterator(tree.FindNode(12), tree.FindNode(6), false)
+  for _, expected := range []int{12, 10, 8, 6} {
+    checkNode(iter.Next(), expected, "descending iterator")
+  }
+  if iter.HasNext() {
+    t.Errorf("Descending iterator did not stop at the last node")
+  }
+}
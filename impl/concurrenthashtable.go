@@ -0,0 +1,223 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import "sync"
+import . "github.com/objecthub/containerkit"
+
+
+// ConcurrentHashTable is a hash table which can be accessed concurrently
+// from multiple goroutines. The key space is striped across a number of
+// segments, each being a HashTable protected by its own lock. Operations
+// on keys in different segments do not contend with each other. Iterators
+// are weakly consistent: they never block writers for longer than it takes
+// to copy a single segment and they reflect the state of every segment at
+// the time the iterator reaches it.
+type ConcurrentHashTable struct {
+  segments []*hashSegment
+  shift uint
+  hash Hashfunction
+  equals Equality
+}
+
+type hashSegment struct {
+  mutex sync.RWMutex
+  table *HashTable
+}
+
+// NewConcurrentHashTable returns a new table with at least the given number of
+// segments. The number of segments gets rounded up to a power of two.
+func NewConcurrentHashTable(concurrency int, size int, maxLoadFactor int,
+                            hash Hashfunction, equals Equality) *ConcurrentHashTable {
+  bits := uint(0)
+  for 1 << bits < concurrency {
+    bits++
+  }
+  n := 1 << bits
+  res := &ConcurrentHashTable{make([]*hashSegment, n), 64 - bits, hash, equals}
+  for i := range res.segments {
+    res.segments[i] = &hashSegment{table: NewHashTable(size / n, maxLoadFactor, hash, equals)}
+  }
+  return res
+}
+
+func (this *ConcurrentHashTable) Concurrency() int {
+  return len(this.segments)
+}
+
+func (this *ConcurrentHashTable) Hash() Hashfunction {
+  return this.hash
+}
+
+func (this *ConcurrentHashTable) Equality() Equality {
+  return this.equals
+}
+
+// segment returns the segment for the given key. The segment is determined by
+// the top bits of a scrambled hash code so that it is independent of the bucket
+// index used within the segment.
+func (this *ConcurrentHashTable) segment(key interface{}) *hashSegment {
+  if len(this.segments) == 1 {
+    return this.segments[0]
+  }
+  h := uint64(this.hash(key))
+  h ^= h >> 33
+  h *= 0xff51afd7ed558ccd
+  h ^= h >> 33
+  return this.segments[h >> this.shift]
+}
+
+// Size returns the number of entries. Since segments are counted one after
+// the other, the result is only an estimate if there are concurrent updates.
+func (this *ConcurrentHashTable) Size() int {
+  res := 0
+  for _, seg := range this.segments {
+    seg.mutex.RLock()
+    res += seg.table.Size()
+    seg.mutex.RUnlock()
+  }
+  return res
+}
+
+func (this *ConcurrentHashTable) Get(key interface{}) (value interface{}, exists bool) {
+  seg := this.segment(key)
+  seg.mutex.RLock()
+  defer seg.mutex.RUnlock()
+  if entry := seg.table.FindEntry(key); entry != nil {
+    return entry.Value, true
+  }
+  return nil, false
+}
+
+// Put maps key to value and returns the previous value, if there was one.
+func (this *ConcurrentHashTable) Put(key, value interface{}) (old interface{}, existed bool) {
+  seg := this.segment(key)
+  seg.mutex.Lock()
+  defer seg.mutex.Unlock()
+  if entry := seg.table.FindEntry(key); entry != nil {
+    old = entry.Value
+    entry.Value = value
+    return old, true
+  }
+  seg.table.AddEntry(key, value)
+  return nil, false
+}
+
+// PutIfAbsent maps key to value if there is no mapping for key yet. It returns
+// the value key is mapped to after the operation and true if this value was
+// present already.
+func (this *ConcurrentHashTable) PutIfAbsent(key, value interface{}) (actual interface{}, loaded bool) {
+  seg := this.segment(key)
+  seg.mutex.Lock()
+  defer seg.mutex.Unlock()
+  if entry := seg.table.FindEntry(key); entry != nil {
+    return entry.Value, true
+  }
+  seg.table.AddEntry(key, value)
+  return value, false
+}
+
+// Compute atomically replaces the mapping for key with the result of f. f is
+// invoked with the current value and a flag indicating whether there is a
+// mapping; it returns the new value and a flag indicating whether the key
+// should be mapped at all. Compute returns the result of f. f must not access
+// this table.
+func (this *ConcurrentHashTable) Compute(
+    key interface{},
+    f func (value interface{}, exists bool) (interface{}, bool)) (interface{}, bool) {
+  seg := this.segment(key)
+  seg.mutex.Lock()
+  defer seg.mutex.Unlock()
+  entry := seg.table.FindEntry(key)
+  var value interface{}
+  if entry != nil {
+    value = entry.Value
+  }
+  res, keep := f(value, entry != nil)
+  if !keep {
+    if entry != nil {
+      seg.table.DeleteEntry(key)
+    }
+  } else if entry != nil {
+    entry.Value = res
+  } else {
+    seg.table.AddEntry(key, res)
+  }
+  return res, keep
+}
+
+// Delete removes the mapping for key and returns the removed value, if there
+// was one.
+func (this *ConcurrentHashTable) Delete(key interface{}) (old interface{}, existed bool) {
+  seg := this.segment(key)
+  seg.mutex.Lock()
+  defer seg.mutex.Unlock()
+  if entry := seg.table.FindEntry(key); entry != nil {
+    seg.table.DeleteEntry(key)
+    return entry.Value, true
+  }
+  return nil, false
+}
+
+// Clear removes all entries, one segment after the other.
+func (this *ConcurrentHashTable) Clear() {
+  for _, seg := range this.segments {
+    seg.mutex.Lock()
+    seg.table.Clear()
+    seg.mutex.Unlock()
+  }
+}
+
+// Iterator returns a weakly consistent iterator over copies of all entries.
+func (this *ConcurrentHashTable) Iterator() *ConcurrentHashEntryIterator {
+  res := &ConcurrentHashEntryIterator{this.segments, 0, nil}
+  res.scan()
+  return res
+}
+
+type ConcurrentHashEntryIterator struct {
+  segments []*hashSegment
+  nextSegment int
+  entries []HashEntry
+}
+
+// scan copies the entries of the next non-empty segment.
+func (this *ConcurrentHashEntryIterator) scan() {
+  for len(this.entries) == 0 && this.nextSegment < len(this.segments) {
+    seg := this.segments[this.nextSegment]
+    seg.mutex.RLock()
+    this.entries = make([]HashEntry, 0, seg.table.Size())
+    for iter := seg.table.Iterator(); iter.HasNext(); {
+      entry := iter.Next()
+      this.entries = append(this.entries, HashEntry{entry.Key, entry.Value, nil})
+    }
+    seg.mutex.RUnlock()
+    this.nextSegment++
+  }
+}
+
+func (this *ConcurrentHashEntryIterator) HasNext() bool {
+  return len(this.entries) > 0
+}
+
+func (this *ConcurrentHashEntryIterator) Next() *HashEntry {
+  if len(this.entries) == 0 {
+    panic("ConcurrentHashEntryIterator.Next: no next entry")
+  }
+  res := &this.entries[0]
+  this.entries = this.entries[1:]
+  this.scan()
+  return res
+}
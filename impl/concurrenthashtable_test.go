@@ -0,0 +1,74 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import "sync"
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func TestConcurrentHashTable(t *testing.T) {
+  table := NewConcurrentHashTable(5, 64, 80, UniversalHash, UniversalEquality)
+  if table.Concurrency() != 8 {
+    t.Fatalf("Expected concurrency to be rounded up to 8; was %d", table.Concurrency())
+  }
+  var wg sync.WaitGroup
+  for g := 0; g < 8; g++ {
+    wg.Add(1)
+    go func(g int) {
+      defer wg.Done()
+      for i := 0; i < 1000; i++ {
+        table.Compute(i % 100, func (value interface{}, exists bool) (interface{}, bool) {
+          if exists {
+            return value.(int) + 1, true
+          }
+          return 1, true
+        })
+        table.PutIfAbsent(1000 + g, g)
+      }
+    }(g)
+  }
+  wg.Wait()
+  if table.Size() != 108 {
+    t.Fatalf("Expected size of table to be 108; was %d", table.Size())
+  }
+  for i := 0; i < 100; i++ {
+    if value, _ := table.Get(i); value != 80 {
+      t.Fatalf("Expected %d to be mapped to 80; was %v", i, value)
+    }
+  }
+  if actual, loaded := table.PutIfAbsent(1003, -1); !loaded || actual != 3 {
+    t.Fatalf("Expected PutIfAbsent to load 3; was %v", actual)
+  }
+  if _, kept := table.Compute(1003, func (value interface{}, exists bool) (interface{}, bool) {
+        return nil, false
+      }); kept || table.Size() != 107 {
+    t.Fatalf("Expected Compute to remove 1003")
+  }
+  count := 0
+  for iter := table.Iterator(); iter.HasNext(); count++ {
+    entry := iter.Next()
+    if value, _ := table.Get(entry.Key); value != entry.Value {
+      t.Fatalf("Unexpected entry %v -> %v", entry.Key, entry.Value)
+    }
+  }
+  if count != 107 {
+    t.Fatalf("Iterator returned %d entries; expected 107", count)
+  }
+  table.Clear()
+  if table.Size() != 0 || table.Iterator().HasNext() {
+    t.Fatalf("Expected table to be empty after Clear")
+  }
+}
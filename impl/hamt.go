@@ -0,0 +1,343 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import "math/bits"
+import . "github.com/objecthub/containerkit"
+
+
+// Hamt implements a persistent hash array mapped trie. A Hamt is never
+// modified after its creation. Instead, methods With and Without return a
+// new Hamt which shares all unaffected nodes with the original. Each level
+// of the trie consumes 5 bits of the hash code, so lookups and updates need
+// O(log32 n) steps. Keys with identical hash codes are stored in collision
+// nodes at the bottom of the trie.
+type Hamt struct {
+  root *hamtNode
+  size int
+  hash Hashfunction
+  equals Equality
+}
+
+// hamtNode is an inner node of the trie. Its bitmap determines which of the 32
+// possible children exist; children are either of type *HashEntry, *hamtNode,
+// or *hamtCollision.
+type hamtNode struct {
+  bitmap uint32
+  children []interface{}
+}
+
+// hamtCollision stores entries whose keys have the same hash code.
+type hamtCollision struct {
+  entries []*HashEntry
+}
+
+const hamtBits = 5
+const hamtMask = 1 << hamtBits - 1
+const hamtMaxShift = 64
+
+var emptyHamtNode = &hamtNode{0, nil}
+
+func NewHamt(hash Hashfunction, equals Equality) *Hamt {
+  return &Hamt{emptyHamtNode, 0, hash, equals}
+}
+
+func (this *Hamt) Size() int {
+  return this.size
+}
+
+func (this *Hamt) Hash() Hashfunction {
+  return this.hash
+}
+
+func (this *Hamt) Equality() Equality {
+  return this.equals
+}
+
+func (this *Hamt) hashOf(key interface{}) uint64 {
+  return uint64(this.hash(key))
+}
+
+// Empty returns an empty Hamt with the same hash function and equality.
+func (this *Hamt) Empty() *Hamt {
+  return NewHamt(this.hash, this.equals)
+}
+
+// FindEntry returns the entry for the given key, or nil if there is none.
+// Entries are shared between tries and must not be modified.
+func (this *Hamt) FindEntry(key interface{}) *HashEntry {
+  h := this.hashOf(key)
+  node := this.root
+  for shift := uint(0); ; shift += hamtBits {
+    bit := uint32(1) << ((h >> shift) & hamtMask)
+    if node.bitmap & bit == 0 {
+      return nil
+    }
+    switch child := node.children[node.index(bit)].(type) {
+      case *HashEntry:
+        if this.equals(key, child.Key) {
+          return child
+        }
+        return nil
+      case *hamtCollision:
+        return child.find(key, this.equals)
+      case *hamtNode:
+        node = child
+    }
+  }
+}
+
+// With returns a new Hamt which maps key to value. All other mappings are
+// taken over from this Hamt.
+func (this *Hamt) With(key, value interface{}) *Hamt {
+  root, added := this.insert(this.root, 0, this.hashOf(key), &HashEntry{key, value, nil})
+  size := this.size
+  if added {
+    size++
+  }
+  return &Hamt{root, size, this.hash, this.equals}
+}
+
+// Without returns a new Hamt without a mapping for key. If there is no
+// mapping for key, Without returns this Hamt.
+func (this *Hamt) Without(key interface{}) *Hamt {
+  child, removed := this.remove(this.root, 0, this.hashOf(key), key)
+  if !removed {
+    return this
+  }
+  root, valid := child.(*hamtNode)
+  if !valid {
+    // the root collapsed into a single entry or collision node
+    root = emptyHamtNode
+    if child != nil {
+      root = &hamtNode{this.bitFor(child, 0), []interface{}{child}}
+    }
+  }
+  return &Hamt{root, this.size - 1, this.hash, this.equals}
+}
+
+func (this *hamtNode) index(bit uint32) int {
+  return bits.OnesCount32(this.bitmap & (bit - 1))
+}
+
+func (this *hamtNode) with(bit uint32, child interface{}) *hamtNode {
+  i := this.index(bit)
+  res := &hamtNode{this.bitmap, make([]interface{}, len(this.children))}
+  copy(res.children, this.children)
+  if this.bitmap & bit == 0 {
+    res.bitmap |= bit
+    res.children = append(res.children, nil)
+    copy(res.children[i + 1:], res.children[i:])
+  }
+  res.children[i] = child
+  return res
+}
+
+func (this *hamtNode) without(bit uint32) *hamtNode {
+  i := this.index(bit)
+  res := &hamtNode{this.bitmap &^ bit, make([]interface{}, len(this.children) - 1)}
+  copy(res.children, this.children[:i])
+  copy(res.children[i:], this.children[i + 1:])
+  return res
+}
+
+// bitFor returns the bitmap bit of a leaf child (entry or collision node) at
+// the level given by shift.
+func (this *Hamt) bitFor(child interface{}, shift uint) uint32 {
+  var h uint64
+  switch leaf := child.(type) {
+    case *HashEntry:
+      h = this.hashOf(leaf.Key)
+    case *hamtCollision:
+      h = this.hashOf(leaf.entries[0].Key)
+  }
+  return uint32(1) << ((h >> shift) & hamtMask)
+}
+
+func (this *Hamt) insert(node *hamtNode,
+                         shift uint,
+                         h uint64,
+                         entry *HashEntry) (*hamtNode, bool) {
+  bit := uint32(1) << ((h >> shift) & hamtMask)
+  if node.bitmap & bit == 0 {
+    return node.with(bit, entry), true
+  }
+  switch child := node.children[node.index(bit)].(type) {
+    case *HashEntry:
+      if this.equals(entry.Key, child.Key) {
+        return node.with(bit, entry), false
+      }
+      return node.with(bit, this.merge(child, this.hashOf(child.Key), entry, h, shift + hamtBits)), true
+    case *hamtCollision:
+      if ch := this.hashOf(child.entries[0].Key); ch != h {
+        // the collision node was moved up when removing other entries
+        return node.with(bit, this.merge(child, ch, entry, h, shift + hamtBits)), true
+      }
+      collision, added := child.with(entry, this.equals)
+      return node.with(bit, collision), added
+    case *hamtNode:
+      sub, added := this.insert(child, shift + hamtBits, h, entry)
+      return node.with(bit, sub), added
+  }
+  panic("Hamt.insert: illegal node")
+}
+
+// merge creates a subtrie containing the two given leaves (entries or collision
+// nodes) whose keys differ. Only entries can have identical hash codes.
+func (this *Hamt) merge(e1 interface{}, h1 uint64,
+                        e2 interface{}, h2 uint64, shift uint) interface{} {
+  if shift >= hamtMaxShift {
+    return &hamtCollision{[]*HashEntry{e1.(*HashEntry), e2.(*HashEntry)}}
+  }
+  b1 := uint32(1) << ((h1 >> shift) & hamtMask)
+  b2 := uint32(1) << ((h2 >> shift) & hamtMask)
+  if b1 == b2 {
+    return &hamtNode{b1, []interface{}{this.merge(e1, h1, e2, h2, shift + hamtBits)}}
+  } else if b1 < b2 {
+    return &hamtNode{b1 | b2, []interface{}{e1, e2}}
+  }
+  return &hamtNode{b1 | b2, []interface{}{e2, e1}}
+}
+
+// remove returns the replacement for node after removing key, which is either
+// a *hamtNode, a leaf if the node collapsed into a single leaf, or nil if the
+// node became empty.
+func (this *Hamt) remove(node *hamtNode,
+                         shift uint,
+                         h uint64,
+                         key interface{}) (interface{}, bool) {
+  bit := uint32(1) << ((h >> shift) & hamtMask)
+  if node.bitmap & bit == 0 {
+    return node, false
+  }
+  var replacement interface{}
+  switch child := node.children[node.index(bit)].(type) {
+    case *HashEntry:
+      if !this.equals(key, child.Key) {
+        return node, false
+      }
+    case *hamtCollision:
+      collision, removed := child.without(key, this.equals)
+      if !removed {
+        return node, false
+      }
+      replacement = collision
+    case *hamtNode:
+      sub, removed := this.remove(child, shift + hamtBits, h, key)
+      if !removed {
+        return node, false
+      }
+      replacement = sub
+  }
+  var res *hamtNode
+  if replacement == nil {
+    res = node.without(bit)
+  } else {
+    res = node.with(bit, replacement)
+  }
+  if len(res.children) == 0 {
+    return nil, true
+  } else if len(res.children) == 1 {
+    if _, isNode := res.children[0].(*hamtNode); !isNode {
+      return res.children[0], true
+    }
+  }
+  return res, true
+}
+
+func (this *hamtCollision) find(key interface{}, equals Equality) *HashEntry {
+  for _, entry := range this.entries {
+    if equals(key, entry.Key) {
+      return entry
+    }
+  }
+  return nil
+}
+
+func (this *hamtCollision) with(entry *HashEntry, equals Equality) (*hamtCollision, bool) {
+  res := &hamtCollision{make([]*HashEntry, len(this.entries), len(this.entries) + 1)}
+  copy(res.entries, this.entries)
+  for i, e := range res.entries {
+    if equals(entry.Key, e.Key) {
+      res.entries[i] = entry
+      return res, false
+    }
+  }
+  res.entries = append(res.entries, entry)
+  return res, true
+}
+
+// without returns the collision node without an entry for key. If only a
+// single entry remains, this entry is returned instead of a collision node.
+func (this *hamtCollision) without(key interface{}, equals Equality) (interface{}, bool) {
+  for i, e := range this.entries {
+    if equals(key, e.Key) {
+      if len(this.entries) == 2 {
+        return this.entries[1 - i], true
+      }
+      res := &hamtCollision{make([]*HashEntry, 0, len(this.entries) - 1)}
+      res.entries = append(res.entries, this.entries[:i]...)
+      res.entries = append(res.entries, this.entries[i + 1:]...)
+      return res, true
+    }
+  }
+  return this, false
+}
+
+// Iterator returns an iterator over all entries of the trie. Entries are
+// shared between tries and must not be modified.
+func (this *Hamt) Iterator() *HamtIterator {
+  res := &HamtIterator{[]interface{}{this.root}, nil}
+  res.scan()
+  return res
+}
+
+type HamtIterator struct {
+  stack []interface{}
+  next *HashEntry
+}
+
+func (this *HamtIterator) scan() {
+  this.next = nil
+  for len(this.stack) > 0 && this.next == nil {
+    top := this.stack[len(this.stack) - 1]
+    this.stack = this.stack[:len(this.stack) - 1]
+    switch node := top.(type) {
+      case *HashEntry:
+        this.next = node
+      case *hamtCollision:
+        for i := len(node.entries) - 1; i >= 0; i-- {
+          this.stack = append(this.stack, node.entries[i])
+        }
+      case *hamtNode:
+        for i := len(node.children) - 1; i >= 0; i-- {
+          this.stack = append(this.stack, node.children[i])
+        }
+    }
+  }
+}
+
+func (this *HamtIterator) HasNext() bool {
+  return this.next != nil
+}
+
+func (this *HamtIterator) Next() *HashEntry {
+  if this.next == nil {
+    panic("HamtIterator.Next: no next entry")
+  }
+  res := this.next
+  this.scan()
+  return res
+}
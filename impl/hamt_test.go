@@ -0,0 +1,79 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import "math/rand"
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func checkHamt(t *testing.T, trie *Hamt, expected map[int]int) {
+  if trie.Size() != len(expected) {
+    t.Fatalf("Expected size of trie to be %d; was %d", len(expected), trie.Size())
+  }
+  for key, value := range expected {
+    if entry := trie.FindEntry(key); entry == nil || entry.Value != value {
+      t.Fatalf("Expected trie to map %d to %d", key, value)
+    }
+  }
+  count := 0
+  for iter := trie.Iterator(); iter.HasNext(); count++ {
+    entry := iter.Next()
+    if value, exists := expected[entry.Key.(int)]; !exists || value != entry.Value {
+      t.Fatalf("Unexpected entry %v -> %v", entry.Key, entry.Value)
+    }
+  }
+  if count != len(expected) {
+    t.Fatalf("Iterator returned %d entries; expected %d", count, len(expected))
+  }
+}
+
+func testHamt(t *testing.T, hash Hashfunction) {
+  trie := NewHamt(hash, UniversalEquality)
+  expected := make(map[int]int)
+  versions := []*Hamt{trie}
+  snapshots := []map[int]int{{}}
+  rnd := rand.New(rand.NewSource(1))
+  for i := 0; i < 3000; i++ {
+    key := rnd.Intn(300)
+    if rnd.Intn(3) == 0 {
+      trie = trie.Without(key)
+      delete(expected, key)
+    } else {
+      trie = trie.With(key, i)
+      expected[key] = i
+    }
+    checkHamt(t, trie, expected)
+    if i % 500 == 0 {
+      snapshot := make(map[int]int)
+      for k, v := range expected {
+        snapshot[k] = v
+      }
+      versions = append(versions, trie)
+      snapshots = append(snapshots, snapshot)
+    }
+  }
+  for i, version := range versions {
+    checkHamt(t, version, snapshots[i])
+  }
+}
+
+func TestHamt(t *testing.T) {
+  testHamt(t, UniversalHash)
+}
+
+func TestHamtCollisions(t *testing.T) {
+  testHamt(t, func (x interface{}) int { return x.(int) % 7 })
+}
@@ -29,6 +29,35 @@ func NewHashEntry(key, value interface{}, next *HashEntry) *HashEntry {
   return &HashEntry{key, value, next}
 }
 
+// HashTableConfig describes the hash table backing a hash map or hash set.
+type HashTableConfig struct {
+  // OpenAddressing selects an OpenHashTable instead of a HashTable.
+  OpenAddressing bool
+  // Capacity is the number of mappings the table can hold without growing.
+  Capacity int
+  // LoadFactor is the maximum load factor of the table in percent.
+  LoadFactor int
+}
+
+// DefaultHashTable is the configuration of the default hash maps and sets.
+var DefaultHashTable = HashTableConfig{false, 13, 80}
+
+// NewTable returns a new chained hash table for this configuration. It
+// ignores the OpenAddressing flag.
+func (this HashTableConfig) NewTable(hash Hashfunction, equals Equality) *HashTable {
+  loadFactor := this.LoadFactor
+  if loadFactor <= 0 {
+    loadFactor = DefaultHashTable.LoadFactor
+  }
+  return NewHashTable(this.Capacity * 100 / loadFactor + 1, loadFactor, hash, equals)
+}
+
+// NewOpenTable returns a new hash table with open addressing for this
+// configuration. It ignores the OpenAddressing flag.
+func (this HashTableConfig) NewOpenTable(hash Hashfunction, equals Equality) *OpenHashTable {
+  return NewOpenHashTable(this.Capacity, this.LoadFactor, hash, equals)
+}
+
 type HashTable struct {
   table [](*HashEntry)
   entries int
@@ -135,6 +164,42 @@ func (this *HashTable) DeleteEntry(key interface{}) {
   }
 }
 
+// Compute replaces the mapping for key with the result of f, hashing key only
+// once. f is invoked with the current value and a flag indicating whether
+// there is a mapping; it returns the new value and a flag indicating whether
+// the key should be mapped at all. Compute returns the result of f. f must
+// not modify this table.
+func (this *HashTable) Compute(
+    key interface{},
+    f func (value interface{}, exists bool) (interface{}, bool)) (interface{}, bool) {
+  b := this.bucket(key)
+  var prev *HashEntry
+  entry := this.table[b]
+  for ; entry != nil && !this.equals(key, entry.Key); prev, entry = entry, entry.Next {}
+  var value interface{}
+  if entry != nil {
+    value = entry.Value
+  }
+  res, keep := f(value, entry != nil)
+  if !keep {
+    if entry != nil {
+      if prev == nil {
+        this.table[b] = entry.Next
+      } else {
+        prev.Next = entry.Next
+      }
+      this.entries--
+    }
+  } else if entry != nil {
+    entry.Value = res
+  } else {
+    this.table[b] = NewHashEntry(key, res, this.table[b])
+    this.entries++
+    this.resizeIfNeeded()
+  }
+  return res, keep
+}
+
 func (this *HashTable) Iterator() *HashEntryIterator {
   firstBucket := len(this.table) - 1
   return &HashEntryIterator{this.table, firstBucket, this.table[firstBucket]}
@@ -0,0 +1,315 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import . "github.com/objecthub/containerkit"
+
+
+// OpenHashTable is a hash table with open addressing. Unlike HashTable, it
+// stores all mappings in a single array of slots and does not allocate per
+// mapping. Collisions are resolved with linear probing using Robin Hood
+// hashing: a mapping that got displaced further from its home slot takes the
+// slot of a mapping closer to its own home slot. This keeps probe sequences
+// short even at high load factors. Deletion shifts subsequent mappings back,
+// so that no tombstones are needed.
+//
+// The table grows when its load exceeds the maximum load factor and shrinks
+// when its load drops below a quarter of the maximum load factor, but never
+// below its initial capacity.
+//
+// Since inserting and deleting move mappings between slots, iterators are
+// fail-fast: they panic if the table was modified structurally while they
+// were in use. The only exception is deleting a mapping which an iterator
+// returned already. Iterators traverse the slots backwards, such that the
+// mappings moved by such a deletion have been returned already, too. Replacing
+// values is not a structural modification.
+type OpenHashTable struct {
+  slots []openSlot
+  entries int
+  mods int
+  deletedAt int
+  deletedFrom *openSlot
+  minSlots int
+  maxLoadFactor int
+  hash Hashfunction
+  equals Equality
+}
+
+// openSlot holds a mapping together with its mixed hash code. dist is one
+// more than the distance of the slot from the home slot of the mapping; it
+// is 0 for empty slots.
+type openSlot struct {
+  key interface{}
+  value interface{}
+  hash uint32
+  dist uint32
+}
+
+// NewOpenHashTable returns a new table which can hold capacity mappings
+// without growing. maxLoadFactor is the maximum percentage of occupied slots;
+// it is clamped to the range from 10 to 95.
+func NewOpenHashTable(capacity int, maxLoadFactor int,
+                      hash Hashfunction, equals Equality) *OpenHashTable {
+  if maxLoadFactor < 10 {
+    maxLoadFactor = 10
+  } else if maxLoadFactor > 95 {
+    maxLoadFactor = 95
+  }
+  size := 8
+  for size * maxLoadFactor < capacity * 100 {
+    size *= 2
+  }
+  return &OpenHashTable{make([]openSlot, size), 0, 0, -1, nil, size, maxLoadFactor, hash, equals}
+}
+
+func (this *OpenHashTable) Size() int {
+  return this.entries
+}
+
+// Capacity returns the number of slots of this table.
+func (this *OpenHashTable) Capacity() int {
+  return len(this.slots)
+}
+
+func (this *OpenHashTable) MaxLoadFactor() int {
+  return this.maxLoadFactor
+}
+
+func (this *OpenHashTable) Hash() Hashfunction {
+  return this.hash
+}
+
+func (this *OpenHashTable) Equality() Equality {
+  return this.equals
+}
+
+func (this *OpenHashTable) Clear() {
+  this.slots = make([]openSlot, this.minSlots)
+  this.entries = 0
+  this.mods++
+}
+
+// mixedHash spreads the bits of the hash code of key, since the home slot is
+// determined by the lowest bits only.
+func (this *OpenHashTable) mixedHash(key interface{}) uint32 {
+  h := uint64(this.hash(key))
+  h ^= h >> 33
+  h *= 0xff51afd7ed558ccd
+  h ^= h >> 33
+  h *= 0xc4ceb9fe1a85ec53
+  h ^= h >> 33
+  return uint32(h)
+}
+
+// find returns the index of the slot holding key, or -1 if key is not mapped.
+func (this *OpenHashTable) find(key interface{}, h uint32) int {
+  mask := uint32(len(this.slots) - 1)
+  i := h & mask
+  for dist := uint32(1); ; dist++ {
+    slot := &this.slots[i]
+    if slot.dist < dist {
+      return -1
+    }
+    if slot.hash == h && this.equals(key, slot.key) {
+      return int(i)
+    }
+    i = (i + 1) & mask
+  }
+}
+
+// insert adds a mapping for a key which is not mapped yet.
+func (this *OpenHashTable) insert(key, value interface{}, h uint32) {
+  this.mods++
+  if (this.entries + 1) * 100 > len(this.slots) * this.maxLoadFactor {
+    this.resize(len(this.slots) * 2)
+  }
+  this.place(openSlot{key, value, h, 1})
+  this.entries++
+}
+
+func (this *OpenHashTable) place(slot openSlot) {
+  mask := uint32(len(this.slots) - 1)
+  for i := slot.hash & mask; ; i = (i + 1) & mask {
+    if this.slots[i].dist == 0 {
+      this.slots[i] = slot
+      return
+    }
+    if this.slots[i].dist < slot.dist {
+      slot, this.slots[i] = this.slots[i], slot
+    }
+    slot.dist++
+  }
+}
+
+// deleteAt removes the mapping in slot i by shifting the following mappings
+// of the probe sequence back by one slot. It records the deleted slot, such
+// that iterators can tell whether the deletion affects them.
+func (this *OpenHashTable) deleteAt(i int) {
+  this.mods++
+  this.deletedAt = i
+  this.deletedFrom = &this.slots[0]
+  mask := len(this.slots) - 1
+  for {
+    next := (i + 1) & mask
+    if this.slots[next].dist <= 1 {
+      this.slots[i] = openSlot{}
+      break
+    }
+    this.slots[i] = this.slots[next]
+    this.slots[i].dist--
+    i = next
+  }
+  this.entries--
+  if len(this.slots) > this.minSlots &&
+     this.entries * 400 < len(this.slots) * this.maxLoadFactor {
+    this.resize(len(this.slots) / 2)
+  }
+}
+
+func (this *OpenHashTable) resize(size int) {
+  old := this.slots
+  this.slots = make([]openSlot, size)
+  for _, slot := range old {
+    if slot.dist > 0 {
+      slot.dist = 1
+      this.place(slot)
+    }
+  }
+}
+
+// Get returns the value key is mapped to.
+func (this *OpenHashTable) Get(key interface{}) (value interface{}, exists bool) {
+  if i := this.find(key, this.mixedHash(key)); i >= 0 {
+    return this.slots[i].value, true
+  }
+  return nil, false
+}
+
+// Put maps key to value. It returns the previous value of key, if there
+// was one.
+func (this *OpenHashTable) Put(key, value interface{}) (old interface{}, existed bool) {
+  h := this.mixedHash(key)
+  if i := this.find(key, h); i >= 0 {
+    old = this.slots[i].value
+    this.slots[i].value = value
+    return old, true
+  }
+  this.insert(key, value, h)
+  return nil, false
+}
+
+// Delete removes the mapping for key and returns the removed value, if there
+// was one.
+func (this *OpenHashTable) Delete(key interface{}) (old interface{}, existed bool) {
+  if i := this.find(key, this.mixedHash(key)); i >= 0 {
+    old = this.slots[i].value
+    this.deleteAt(i)
+    return old, true
+  }
+  return nil, false
+}
+
+// Compute replaces the mapping for key with the result of f, hashing key only
+// once. f is invoked with the current value and a flag indicating whether
+// there is a mapping; it returns the new value and a flag indicating whether
+// the key should be mapped at all. Compute returns the result of f. f must
+// not modify this table.
+func (this *OpenHashTable) Compute(
+    key interface{},
+    f func (value interface{}, exists bool) (interface{}, bool)) (interface{}, bool) {
+  h := this.mixedHash(key)
+  i := this.find(key, h)
+  var value interface{}
+  if i >= 0 {
+    value = this.slots[i].value
+  }
+  res, keep := f(value, i >= 0)
+  if !keep {
+    if i >= 0 {
+      this.deleteAt(i)
+    }
+  } else if i >= 0 {
+    this.slots[i].value = res
+  } else {
+    this.insert(key, res, h)
+  }
+  return res, keep
+}
+
+// Iterator returns an iterator over all mappings of this table. It starts
+// at a slot which is empty, so that no probe sequence wraps around it.
+func (this *OpenHashTable) Iterator() *OpenHashIterator {
+  start := 0
+  for this.slots[start].dist > 0 {
+    start++
+  }
+  return &OpenHashIterator{this, this.slots, this.mods, start, start, len(this.slots)}
+}
+
+// OpenHashIterator iterates over the slots of a table backwards, starting
+// at slot start. next is the slot to check next and left is the number of
+// slots which remain to be checked. A detached iterator, whose table is nil,
+// iterates over slots which the table does not use anymore after resizing.
+type OpenHashIterator struct {
+  table *OpenHashTable
+  slots []openSlot
+  mods int
+  start int
+  next int
+  left int
+}
+
+// check panics if the table was modified structurally since the last call,
+// unless a single mapping got deleted from a slot which was visited already.
+func (this *OpenHashIterator) check() {
+  table := this.table
+  if table == nil || table.mods == this.mods {
+    return
+  }
+  mask := len(this.slots) - 1
+  if table.mods == this.mods + 1 &&
+     table.deletedFrom == &this.slots[0] &&
+     (this.start - table.deletedAt) & mask < len(this.slots) - this.left {
+    this.mods = table.mods
+    if &table.slots[0] != &this.slots[0] {
+      this.table = nil
+    }
+    return
+  }
+  panic("OpenHashIterator: table modified during iteration")
+}
+
+func (this *OpenHashIterator) HasNext() bool {
+  this.check()
+  mask := len(this.slots) - 1
+  for ; this.left > 0; this.left-- {
+    if this.slots[this.next].dist > 0 {
+      return true
+    }
+    this.next = (this.next - 1) & mask
+  }
+  return false
+}
+
+// Next returns the key and the value of the next mapping.
+func (this *OpenHashIterator) Next() (key, value interface{}) {
+  if !this.HasNext() {
+    panic("OpenHashIterator.Next: no next mapping")
+  }
+  slot := &this.slots[this.next]
+  this.next = (this.next - 1) & (len(this.slots) - 1)
+  this.left--
+  return slot.key, slot.value
+}
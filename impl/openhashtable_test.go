@@ -0,0 +1,299 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import "math/rand"
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+// checkOpenHashTable verifies the mappings of table against expected, as
+// well as the Robin Hood invariant of the probe distances.
+func checkOpenHashTable(t *testing.T, table *OpenHashTable, expected map[int]int) {
+  if table.Size() != len(expected) {
+    t.Fatalf("Expected size of table to be %d; was %d", len(expected), table.Size())
+  }
+  for key, value := range expected {
+    if res, exists := table.Get(key); !exists || res != value {
+      t.Fatalf("Expected table to map %d to %d; got %v", key, value, res)
+    }
+  }
+  n := 0
+  for iter := table.Iterator(); iter.HasNext(); n++ {
+    key, value := iter.Next()
+    if expected[key.(int)] != value {
+      t.Fatalf("Iterator returned unexpected mapping %v -> %v", key, value)
+    }
+  }
+  if n != len(expected) {
+    t.Fatalf("Iterator returned %d mappings; expected %d", n, len(expected))
+  }
+  mask := len(table.slots) - 1
+  for i, slot := range table.slots {
+    if slot.dist > 0 && int(slot.hash) & mask != (i - int(slot.dist) + 1) & mask {
+      t.Fatalf("Slot %d has wrong probe distance %d", i, slot.dist)
+    }
+    if next := table.slots[(i + 1) & mask]; next.dist > slot.dist + 1 {
+      t.Fatalf("Slot %d violates the Robin Hood invariant", i + 1)
+    }
+  }
+}
+
+func TestOpenHashTableModel(t *testing.T) {
+  table := NewOpenHashTable(0, 80, UniversalHash, UniversalEquality)
+  expected := make(map[int]int)
+  rnd := rand.New(rand.NewSource(7))
+  for i := 0; i < 20000; i++ {
+    key := rnd.Intn(500)
+    switch rnd.Intn(3) {
+      case 0:
+        old, existed := table.Put(key, i)
+        if prev, ok := expected[key]; ok != existed || (ok && prev != old) {
+          t.Fatalf("Put(%d) returned %v, %v", key, old, existed)
+        }
+        expected[key] = i
+      case 1:
+        old, existed := table.Delete(key)
+        if prev, ok := expected[key]; ok != existed || (ok && prev != old) {
+          t.Fatalf("Delete(%d) returned %v, %v", key, old, existed)
+        }
+        delete(expected, key)
+      case 2:
+        table.Compute(key, func (value interface{}, exists bool) (interface{}, bool) {
+          if exists {
+            return nil, false
+          }
+          return -i, true
+        })
+        if _, ok := expected[key]; ok {
+          delete(expected, key)
+        } else {
+          expected[key] = -i
+        }
+    }
+    if i % 500 == 0 {
+      checkOpenHashTable(t, table, expected)
+    }
+  }
+  checkOpenHashTable(t, table, expected)
+}
+
+func TestOpenHashTableShrinks(t *testing.T) {
+  table := NewOpenHashTable(10, 80, UniversalHash, UniversalEquality)
+  initial := table.Capacity()
+  for i := 0; i < 10000; i++ {
+    table.Put(i, i)
+  }
+  if table.Capacity() * 80 < 10000 * 100 {
+    t.Fatalf("Capacity %d is too small for 10000 mappings", table.Capacity())
+  }
+  for i := 0; i < 10000; i++ {
+    table.Delete(i)
+  }
+  if table.Capacity() != initial {
+    t.Fatalf("Expected capacity to shrink back to %d; was %d", initial, table.Capacity())
+  }
+  table.Put(1, 1)
+  table.Clear()
+  if table.Size() != 0 || table.Capacity() != initial {
+    t.Fatalf("Clear did not reset table")
+  }
+}
+
+func TestOpenHashTableLoadFactor(t *testing.T) {
+  table := NewOpenHashTable(1000, 50, UniversalHash, UniversalEquality)
+  capacity := table.Capacity()
+  for i := 0; i < 1000; i++ {
+    table.Put(i, i)
+  }
+  if table.Capacity() != capacity || capacity * 50 < 1000 * 100 {
+    t.Fatalf("Table with initial capacity 1000 resized or too small: %d", capacity)
+  }
+}
+
+func TestOpenHashTableDeleteWhileIterating(t *testing.T) {
+  table := NewOpenHashTable(0, 80, UniversalHash, UniversalEquality)
+  for i := 0; i < 1000; i++ {
+    table.Put(i, i)
+  }
+  n := 0
+  for iter := table.Iterator(); iter.HasNext(); n++ {
+    key, _ := iter.Next()
+    table.Delete(key)
+  }
+  if n != 1000 || table.Size() != 0 || table.Capacity() != 8 {
+    t.Fatalf("Iterated over %d mappings; %d mappings remain in %d slots",
+             n, table.Size(), table.Capacity())
+  }
+}
+
+func TestOpenHashTableFailFastIterator(t *testing.T) {
+  modifications := map[string]func (table *OpenHashTable, visited interface{}) {
+    "insert": func (table *OpenHashTable, visited interface{}) { table.Put(-1, -1) },
+    "delete unvisited": func (table *OpenHashTable, visited interface{}) {
+      for i := 0; i < 10; i++ {
+        if i != visited {
+          table.Delete(i)
+          return
+        }
+      }
+    },
+    "delete twice": func (table *OpenHashTable, visited interface{}) {
+      table.Delete(visited)
+      table.Delete(visited.(int) + 100)
+    },
+  }
+  for name, modify := range modifications {
+    table := NewOpenHashTable(0, 80, UniversalHash, UniversalEquality)
+    for i := 0; i < 10; i++ {
+      table.Put(i, i)
+    }
+    iter := table.Iterator()
+    key, _ := iter.Next()
+    table.Put(key, "replaced")
+    if name == "delete twice" {
+      table.Put(key.(int) + 100, 0)
+      iter = table.Iterator()
+      key, _ = iter.Next()
+    }
+    modify(table, key)
+    func () {
+      defer func () {
+        if recover() == nil {
+          t.Errorf("Expected iterator to fail after %s", name)
+        }
+      }()
+      iter.HasNext()
+    }()
+  }
+}
+
+const benchmarkKeys = 1 << 14
+
+// benchmarkData contains distinct random keys. Tables are populated with the
+// first half; the second half is used for unsuccessful lookups.
+var benchmarkData = randomKeys(2 * benchmarkKeys)
+
+func randomKeys(n int) []interface{} {
+  rnd := rand.New(rand.NewSource(1))
+  seen := make(map[int]bool)
+  res := make([]interface{}, 0, n)
+  for len(res) < n {
+    if key := rnd.Int(); !seen[key] {
+      seen[key] = true
+      res = append(res, key)
+    }
+  }
+  return res
+}
+
+func BenchmarkOpenHashTableInsert(b *testing.B) {
+  for n := 0; n < b.N; n++ {
+    table := NewOpenHashTable(0, 80, UniversalHash, UniversalEquality)
+    for _, key := range benchmarkData[:benchmarkKeys] {
+      table.Put(key, key)
+    }
+  }
+}
+
+func BenchmarkHashTableInsert(b *testing.B) {
+  for n := 0; n < b.N; n++ {
+    table := NewHashTable(17, 80, UniversalHash, UniversalEquality)
+    for _, key := range benchmarkData[:benchmarkKeys] {
+      table.AddEntry(key, key)
+    }
+  }
+}
+
+func BenchmarkOpenHashTableLookup(b *testing.B) {
+  table := NewOpenHashTable(0, 80, UniversalHash, UniversalEquality)
+  for _, key := range benchmarkData[:benchmarkKeys] {
+    table.Put(key, key)
+  }
+  b.ResetTimer()
+  for n := 0; n < b.N; n++ {
+    table.Get(benchmarkData[n % len(benchmarkData)])
+  }
+}
+
+func BenchmarkHashTableLookup(b *testing.B) {
+  table := NewHashTable(17, 80, UniversalHash, UniversalEquality)
+  for _, key := range benchmarkData[:benchmarkKeys] {
+    table.AddEntry(key, key)
+  }
+  b.ResetTimer()
+  for n := 0; n < b.N; n++ {
+    table.FindEntry(benchmarkData[n % len(benchmarkData)])
+  }
+}
+
+func BenchmarkOpenHashTableDelete(b *testing.B) {
+  for n := 0; n < b.N; n++ {
+    b.StopTimer()
+    table := NewOpenHashTable(0, 80, UniversalHash, UniversalEquality)
+    for _, key := range benchmarkData[:benchmarkKeys] {
+      table.Put(key, key)
+    }
+    b.StartTimer()
+    for _, key := range benchmarkData[:benchmarkKeys] {
+      table.Delete(key)
+    }
+  }
+}
+
+func BenchmarkHashTableDelete(b *testing.B) {
+  for n := 0; n < b.N; n++ {
+    b.StopTimer()
+    table := NewHashTable(17, 80, UniversalHash, UniversalEquality)
+    for _, key := range benchmarkData[:benchmarkKeys] {
+      table.AddEntry(key, key)
+    }
+    b.StartTimer()
+    for _, key := range benchmarkData[:benchmarkKeys] {
+      table.DeleteEntry(key)
+    }
+  }
+}
+
+// The iterate-then-modify benchmarks drain a table by repeatedly removing the
+// first mapping of a new iterator.
+func BenchmarkOpenHashTableIterateAndDelete(b *testing.B) {
+  for n := 0; n < b.N; n++ {
+    b.StopTimer()
+    table := NewOpenHashTable(0, 80, UniversalHash, UniversalEquality)
+    for _, key := range benchmarkData[:benchmarkKeys] {
+      table.Put(key, key)
+    }
+    b.StartTimer()
+    for table.Size() > 0 {
+      key, _ := table.Iterator().Next()
+      table.Delete(key)
+    }
+  }
+}
+
+func BenchmarkHashTableIterateAndDelete(b *testing.B) {
+  for n := 0; n < b.N; n++ {
+    b.StopTimer()
+    table := NewHashTable(17, 80, UniversalHash, UniversalEquality)
+    for _, key := range benchmarkData[:benchmarkKeys] {
+      table.AddEntry(key, key)
+    }
+    b.StartTimer()
+    for table.Size() > 0 {
+      table.DeleteEntry(table.Iterator().Next().Key)
+    }
+  }
+}
@@ -0,0 +1,266 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import "sort"
+import "strings"
+
+
+// RadixNode represents a node of a RadixTree. Every node is labeled with a
+// non-empty substring of the keys below it; the concatenation of the labels
+// on the path from the root to a node is the key of the node. Only nodes for
+// which HasValue is true represent mappings.
+type RadixNode struct {
+  Key string
+  Value interface{}
+  HasValue bool
+  label string
+  children []*RadixNode
+}
+
+// child returns the index at which a child whose label starts with b is or
+// would be located, and that child if it exists.
+func (this *RadixNode) child(b byte) (int, *RadixNode) {
+  i := sort.Search(len(this.children), func (i int) bool {
+    return this.children[i].label[0] >= b
+  })
+  if i < len(this.children) && this.children[i].label[0] == b {
+    return i, this.children[i]
+  }
+  return i, nil
+}
+
+func (this *RadixNode) insertChild(i int, child *RadixNode) {
+  this.children = append(this.children, nil)
+  copy(this.children[i + 1:], this.children[i:])
+  this.children[i] = child
+}
+
+func (this *RadixNode) removeChild(i int) {
+  copy(this.children[i:], this.children[i + 1:])
+  this.children[len(this.children) - 1] = nil
+  this.children = this.children[:len(this.children) - 1]
+}
+
+// RadixTree is a compressed trie mapping strings to values. Chains of nodes
+// with a single child and no value are collapsed into a single node, so that
+// the height of the tree is bounded by the length of the longest key and
+// every inner node without a value has at least two children. Keys are
+// compared bytewise.
+type RadixTree struct {
+  root *RadixNode
+  size int
+}
+
+func NewRadixTree() *RadixTree {
+  return &RadixTree{new(RadixNode), 0}
+}
+
+func (this *RadixTree) Size() int {
+  return this.size
+}
+
+func (this *RadixTree) Clear() {
+  this.root = new(RadixNode)
+  this.size = 0
+}
+
+// Find returns the node representing the mapping for key, or nil if key is
+// not mapped.
+func (this *RadixTree) Find(key string) *RadixNode {
+  node := this.root
+  for rest := key; rest != ""; {
+    _, child := node.child(rest[0])
+    if child == nil || !strings.HasPrefix(rest, child.label) {
+      return nil
+    }
+    rest = rest[len(child.label):]
+    node = child
+  }
+  if node.HasValue {
+    return node
+  }
+  return nil
+}
+
+// Insert maps key to value. It returns true if key was not mapped before.
+func (this *RadixTree) Insert(key string, value interface{}) bool {
+  node := this.root
+  rest := key
+  for rest != "" {
+    i, child := node.child(rest[0])
+    if child == nil {
+      node.insertChild(i, &RadixNode{Key: key, Value: value, HasValue: true, label: rest})
+      this.size++
+      return true
+    }
+    common := commonPrefixLength(rest, child.label)
+    if common < len(child.label) {
+      split := &RadixNode{label: child.label[:common], children: []*RadixNode{child}}
+      child.label = child.label[common:]
+      node.children[i] = split
+      child = split
+    }
+    rest = rest[common:]
+    node = child
+  }
+  added := !node.HasValue
+  if added {
+    this.size++
+  }
+  node.Key, node.Value, node.HasValue = key, value, true
+  return added
+}
+
+// Delete removes the mapping for key and returns the removed value, if there
+// was one.
+func (this *RadixTree) Delete(key string) (old interface{}, existed bool) {
+  path := []*RadixNode{this.root}
+  node := this.root
+  for rest := key; rest != ""; {
+    _, child := node.child(rest[0])
+    if child == nil || !strings.HasPrefix(rest, child.label) {
+      return nil, false
+    }
+    rest = rest[len(child.label):]
+    node = child
+    path = append(path, node)
+  }
+  if !node.HasValue {
+    return nil, false
+  }
+  old = node.Value
+  node.Key, node.Value, node.HasValue = "", nil, false
+  this.size--
+  // Remove nodes which became obsolete and merge chains of nodes
+  for k := len(path) - 1; k > 0 && !path[k].HasValue; k-- {
+    node, parent := path[k], path[k - 1]
+    i, _ := parent.child(node.label[0])
+    if len(node.children) == 0 {
+      parent.removeChild(i)
+      continue
+    }
+    if len(node.children) == 1 {
+      child := node.children[0]
+      child.label = node.label + child.label
+      parent.children[i] = child
+    }
+    break
+  }
+  return old, true
+}
+
+// LongestPrefix returns the node representing the mapping for the longest
+// key which is a prefix of key, or nil if there is no such key.
+func (this *RadixTree) LongestPrefix(key string) *RadixNode {
+  var res *RadixNode
+  node := this.root
+  for rest := key; ; {
+    if node.HasValue {
+      res = node
+    }
+    if rest == "" {
+      return res
+    }
+    _, child := node.child(rest[0])
+    if child == nil || !strings.HasPrefix(rest, child.label) {
+      return res
+    }
+    rest = rest[len(child.label):]
+    node = child
+  }
+}
+
+// Iterator returns an iterator over all nodes with values in ascending order
+// of their keys.
+func (this *RadixTree) Iterator() *RadixNodeIterator {
+  return NewRadixNodeIterator(this.root)
+}
+
+// PrefixIterator returns an iterator over all nodes with values whose keys
+// start with prefix, in ascending order of their keys.
+func (this *RadixTree) PrefixIterator(prefix string) *RadixNodeIterator {
+  node := this.root
+  for rest := prefix; rest != ""; {
+    _, child := node.child(rest[0])
+    if child == nil {
+      return NewRadixNodeIterator(nil)
+    }
+    if strings.HasPrefix(child.label, rest) {
+      return NewRadixNodeIterator(child)
+    }
+    if !strings.HasPrefix(rest, child.label) {
+      return NewRadixNodeIterator(nil)
+    }
+    rest = rest[len(child.label):]
+    node = child
+  }
+  return NewRadixNodeIterator(node)
+}
+
+// NewRadixNodeIterator returns an iterator over all nodes with values of
+// the subtree rooted in root, in ascending order of their keys. If root is
+// nil, the iterator is empty.
+func NewRadixNodeIterator(root *RadixNode) *RadixNodeIterator {
+  res := new(RadixNodeIterator)
+  if root != nil {
+    res.stack = []*RadixNode{root}
+    res.advance()
+  }
+  return res
+}
+
+// RadixNodeIterator traverses a subtree in preorder, visiting children in
+// ascending order of their labels. This order corresponds to the ascending
+// order of the keys.
+type RadixNodeIterator struct {
+  stack []*RadixNode
+  next *RadixNode
+}
+
+func (this *RadixNodeIterator) advance() {
+  this.next = nil
+  for len(this.stack) > 0 && this.next == nil {
+    node := this.stack[len(this.stack) - 1]
+    this.stack = this.stack[:len(this.stack) - 1]
+    for i := len(node.children) - 1; i >= 0; i-- {
+      this.stack = append(this.stack, node.children[i])
+    }
+    if node.HasValue {
+      this.next = node
+    }
+  }
+}
+
+func (this *RadixNodeIterator) HasNext() bool {
+  return this.next != nil
+}
+
+func (this *RadixNodeIterator) Next() *RadixNode {
+  if this.next == nil {
+    panic("RadixNodeIterator.Next: no next node")
+  }
+  res := this.next
+  this.advance()
+  return res
+}
+
+func commonPrefixLength(s, t string) int {
+  i := 0
+  for i < len(s) && i < len(t) && s[i] == t[i] {
+    i++
+  }
+  return i
+}
@@ -0,0 +1,121 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import "math/rand"
+import "sort"
+import "strings"
+import "testing"
+
+
+// checkRadixTree verifies the mappings of tree against expected, as well as
+// the order of iteration and the compression invariant.
+func checkRadixTree(t *testing.T, tree *RadixTree, expected map[string]int) {
+  if tree.Size() != len(expected) {
+    t.Fatalf("Expected size of tree to be %d; was %d", len(expected), tree.Size())
+  }
+  keys := make([]string, 0, len(expected))
+  for key, value := range expected {
+    if node := tree.Find(key); node == nil || node.Value != value {
+      t.Fatalf("Expected tree to map %q to %d", key, value)
+    }
+    keys = append(keys, key)
+  }
+  sort.Strings(keys)
+  i := 0
+  for iter := tree.Iterator(); iter.HasNext(); i++ {
+    if node := iter.Next(); i >= len(keys) || node.Key != keys[i] {
+      t.Fatalf("Unexpected key %q at position %d of iteration", node.Key, i)
+    }
+  }
+  if i != len(keys) {
+    t.Fatalf("Iterator returned %d keys; expected %d", i, len(keys))
+  }
+  checkRadixNode(t, tree.root, "", true)
+}
+
+func checkRadixNode(t *testing.T, node *RadixNode, key string, root bool) {
+  if !root && !node.HasValue && len(node.children) < 2 {
+    t.Fatalf("Node %q without value has %d children", key, len(node.children))
+  }
+  if node.HasValue && node.Key != key {
+    t.Fatalf("Node %q has key %q", key, node.Key)
+  }
+  for i, child := range node.children {
+    if child.label == "" || (i > 0 && node.children[i - 1].label[0] >= child.label[0]) {
+      t.Fatalf("Children of node %q are not sorted by distinct labels", key)
+    }
+    checkRadixNode(t, child, key + child.label, false)
+  }
+}
+
+func randomKey(rnd *rand.Rand) string {
+  var builder strings.Builder
+  for n := rnd.Intn(6); n > 0; n-- {
+    builder.WriteByte("abc"[rnd.Intn(3)])
+  }
+  return builder.String()
+}
+
+func TestRadixTreeModel(t *testing.T) {
+  rnd := rand.New(rand.NewSource(7))
+  tree := NewRadixTree()
+  expected := make(map[string]int)
+  for step := 0; step < 3000; step++ {
+    key := randomKey(rnd)
+    if rnd.Intn(3) == 0 {
+      _, existed := tree.Delete(key)
+      if _, exists := expected[key]; exists != existed {
+        t.Fatalf("Delete(%q) returned %t in step %d", key, existed, step)
+      }
+      delete(expected, key)
+    } else {
+      _, exists := expected[key]
+      if added := tree.Insert(key, step); added == exists {
+        t.Fatalf("Insert(%q) returned %t in step %d", key, added, step)
+      }
+      expected[key] = step
+    }
+    checkRadixTree(t, tree, expected)
+  }
+}
+
+func TestRadixTreePrefixes(t *testing.T) {
+  tree := NewRadixTree()
+  for i, key := range []string{"romane", "romanus", "romulus", "rubens", "ruber", "rom", ""} {
+    tree.Insert(key, i)
+  }
+  if node := tree.LongestPrefix("romanesque"); node == nil || node.Key != "romane" {
+    t.Errorf("Expected longest prefix of romanesque to be romane")
+  }
+  if node := tree.LongestPrefix("roman"); node == nil || node.Key != "rom" {
+    t.Errorf("Expected longest prefix of roman to be rom")
+  }
+  if node := tree.LongestPrefix("x"); node == nil || node.Key != "" {
+    t.Errorf("Expected longest prefix of x to be the empty string")
+  }
+  for prefix, count := range map[string]int{"": 7, "r": 6, "rom": 4, "roma": 2,
+                                            "ru": 2, "rube": 2, "rubens": 1, "rx": 0} {
+    n := 0
+    for iter := tree.PrefixIterator(prefix); iter.HasNext(); n++ {
+      if key := iter.Next().Key; !strings.HasPrefix(key, prefix) {
+        t.Errorf("PrefixIterator(%q) returned %q", prefix, key)
+      }
+    }
+    if n != count {
+      t.Errorf("Expected %d keys with prefix %q; got %d", count, prefix, n)
+    }
+  }
+}
@@ -0,0 +1,180 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+
+// TreeBound is a lower or an upper bound of a TreeRange. The zero value
+// represents a missing bound.
+type TreeBound struct {
+  Defined bool
+  Key interface{}
+  Inclusive bool
+}
+
+// InclusiveBound returns a bound which includes key.
+func InclusiveBound(key interface{}) TreeBound {
+  return TreeBound{true, key, true}
+}
+
+// ExclusiveBound returns a bound which excludes key.
+func ExclusiveBound(key interface{}) TreeBound {
+  return TreeBound{true, key, false}
+}
+
+// TreeRange provides navigation over the nodes of an AvlTree whose keys fall
+// between a lower and an upper bound. Ranges are views: they reflect all
+// changes of the underlying tree.
+type TreeRange struct {
+  tree *AvlTree
+  lo TreeBound
+  hi TreeBound
+}
+
+// NewTreeRange returns a range of the nodes of tree between lo and hi.
+func NewTreeRange(tree *AvlTree, lo, hi TreeBound) TreeRange {
+  return TreeRange{tree, lo, hi}
+}
+
+func (this *TreeRange) Tree() *AvlTree {
+  return this.tree
+}
+
+func (this *TreeRange) IsBounded() bool {
+  return this.lo.Defined || this.hi.Defined
+}
+
+func (this *TreeRange) aboveLo(key interface{}) bool {
+  if !this.lo.Defined {
+    return true
+  }
+  c := this.tree.Comparison()(key, this.lo.Key)
+  return c > 0 || (c == 0 && this.lo.Inclusive)
+}
+
+func (this *TreeRange) belowHi(key interface{}) bool {
+  if !this.hi.Defined {
+    return true
+  }
+  c := this.tree.Comparison()(key, this.hi.Key)
+  return c < 0 || (c == 0 && this.hi.Inclusive)
+}
+
+// InRange returns true if key is between the bounds of this range.
+func (this *TreeRange) InRange(key interface{}) bool {
+  return this.aboveLo(key) && this.belowHi(key)
+}
+
+func (this *TreeRange) inRange(node *TreeNode) *TreeNode {
+  if node != nil && this.InRange(node.Key) {
+    return node
+  }
+  return nil
+}
+
+// FindNode returns the node for key if key is in range.
+func (this *TreeRange) FindNode(key interface{}) *TreeNode {
+  if this.InRange(key) {
+    return this.tree.FindNode(key)
+  }
+  return nil
+}
+
+func (this *TreeRange) FirstNode() *TreeNode {
+  if !this.lo.Defined {
+    return this.inRange(this.tree.FirstNode())
+  } else if this.lo.Inclusive {
+    return this.inRange(this.tree.CeilingNode(this.lo.Key))
+  }
+  return this.inRange(this.tree.HigherNode(this.lo.Key))
+}
+
+func (this *TreeRange) LastNode() *TreeNode {
+  if !this.hi.Defined {
+    return this.inRange(this.tree.LastNode())
+  } else if this.hi.Inclusive {
+    return this.inRange(this.tree.FloorNode(this.hi.Key))
+  }
+  return this.inRange(this.tree.LowerNode(this.hi.Key))
+}
+
+// FloorNode returns the largest node in range whose key is less than key
+// (or equal to key if inclusive is true).
+func (this *TreeRange) FloorNode(key interface{}, inclusive bool) *TreeNode {
+  if !this.belowHi(key) {
+    return this.LastNode()
+  } else if inclusive {
+    return this.inRange(this.tree.FloorNode(key))
+  }
+  return this.inRange(this.tree.LowerNode(key))
+}
+
+// CeilingNode returns the smallest node in range whose key is greater than key
+// (or equal to key if inclusive is true).
+func (this *TreeRange) CeilingNode(key interface{}, inclusive bool) *TreeNode {
+  if !this.aboveLo(key) {
+    return this.FirstNode()
+  } else if inclusive {
+    return this.inRange(this.tree.CeilingNode(key))
+  }
+  return this.inRange(this.tree.HigherNode(key))
+}
+
+// Nodes returns an iterator over all nodes in range, either in ascending or
+// in descending order.
+func (this *TreeRange) Nodes(ascending bool) *TreeNodeIterator {
+  first, last := this.FirstNode(), this.LastNode()
+  if first == nil || last == nil ||
+     this.tree.Comparison()(first.Key, last.Key) > 0 {
+    return NewTreeNodeIterator(nil, nil, ascending)
+  } else if ascending {
+    return NewTreeNodeIterator(first, last, true)
+  }
+  return NewTreeNodeIterator(last, first, false)
+}
+
+// Size returns the number of nodes in range. For bounded ranges, this takes
+// time linear in the number of nodes in range.
+func (this *TreeRange) Size() int {
+  if !this.IsBounded() {
+    return this.tree.Size()
+  }
+  res := 0
+  for iter := this.Nodes(true); iter.HasNext(); iter.Next() {
+    res++
+  }
+  return res
+}
+
+// Restrict returns a new range which is the intersection of this range and
+// the range defined by the given bounds.
+func (this *TreeRange) Restrict(lo, hi TreeBound) TreeRange {
+  res := *this
+  comp := this.tree.Comparison()
+  if lo.Defined {
+    if !res.lo.Defined {
+      res.lo = lo
+    } else if c := comp(lo.Key, res.lo.Key); c > 0 || (c == 0 && !lo.Inclusive) {
+      res.lo = lo
+    }
+  }
+  if hi.Defined {
+    if !res.hi.Defined {
+      res.hi = hi
+    } else if c := comp(hi.Key, res.hi.Key); c < 0 || (c == 0 && !hi.Inclusive) {
+      res.hi = hi
+    }
+  }
+  return res
+}
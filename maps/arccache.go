@@ -0,0 +1,216 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// ArcCache is a cache class implementing the Adaptive Replacement Cache
+// policy by Megiddo and Modha. It balances recency and frequency by keeping
+// entries seen once and entries seen at least twice in separate LRU queues.
+// The keys of recently evicted entries are remembered in ghost queues; hits
+// on ghost keys adapt the target size of the two queues. Scans only pollute
+// the queue of entries seen once.
+var ArcCache CacheClass = ArcCacheClass(UniversalHash, UniversalEquality)
+
+func ArcCacheClass(hash Hashfunction, equals Equality) CacheClass {
+  return &arcCacheClass{hash, equals}
+}
+
+type arcCacheClass struct {
+  hash Hashfunction
+  equals Equality
+}
+
+func (this *arcCacheClass) Embed(obj Cache, capacity int, we func (kv MapEntry)) Cache {
+  res := new(arcCache)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.CacheDerived = EmbeddedCache(obj)
+  res.class = this
+  res.capacity = capacity
+  res.whenEvicted = we
+  res.table = impl.NewHashTable(17, 80, this.hash, this.equals)
+  for i := range res.queues {
+    res.queues[i] = impl.NewDoubleLinkedList()
+  }
+  return res
+}
+
+func (this *arcCacheClass) New(capacity int) Cache {
+  return this.Embed(nil, capacity, nil)
+}
+
+func (this *arcCacheClass) NewWithCallback(capacity int, we func (kv MapEntry)) Cache {
+  return this.Embed(nil, capacity, we)
+}
+
+// Queues of an ARC cache (T1, T2, B1 and B2 in the paper)
+const (
+  arcRecent = iota
+  arcFrequent
+  arcRecentGhost
+  arcFrequentGhost
+)
+
+// arcCache stores cacheNodes in its hash table, including the nodes of ghost
+// keys whose values are dropped. target is the adaptive target size of the
+// arcRecent queue.
+type arcCache struct {
+  obj Cache
+  class *arcCacheClass
+  capacity int
+  whenEvicted func (kv MapEntry)
+  table *impl.HashTable
+  queues [4]*impl.DoubleLinkedList
+  target int
+  cacheStats
+  CacheDerived
+}
+
+func (this *arcCache) Size() int {
+  return this.queues[arcRecent].Length() + this.queues[arcFrequent].Length()
+}
+
+func (this *arcCache) Get(key interface{}) (value interface{}, exists bool) {
+  if entry := this.table.FindEntry(key); entry != nil {
+    if node := entry.Value.(*cacheNode); node.queue == arcRecent || node.queue == arcFrequent {
+      this.move(node, arcFrequent)
+      this.StatsCounter().RecordHit()
+      return node.value, true
+    }
+  }
+  this.StatsCounter().RecordMiss()
+  return nil, false
+}
+
+func (this *arcCache) Elements() Iterator {
+  return newCacheQueueIterator(this.queues[arcRecent], this.queues[arcFrequent])
+}
+
+func (this *arcCache) Class() CacheClass {
+  return this.class
+}
+
+func (this *arcCache) Add(key, value interface{}) {
+  if this.capacity <= 0 {
+    this.StatsCounter().RecordEviction(EvictedBySize)
+    if this.whenEvicted != nil {
+      this.whenEvicted(KV(key, value))
+    }
+    return
+  }
+  recent := this.queues[arcRecent].Length()
+  recentGhosts := this.queues[arcRecentGhost].Length()
+  frequentGhosts := this.queues[arcFrequentGhost].Length()
+  if entry := this.table.FindEntry(key); entry != nil {
+    node := entry.Value.(*cacheNode)
+    switch node.queue {
+      case arcRecentGhost:
+        this.target = min(this.capacity, this.target + max(1, frequentGhosts / recentGhosts))
+        this.replace(false)
+      case arcFrequentGhost:
+        this.target = max(0, this.target - max(1, recentGhosts / frequentGhosts))
+        this.replace(true)
+    }
+    node.value = value
+    this.move(node, arcFrequent)
+    return
+  }
+  if recent + recentGhosts >= this.capacity {
+    if recent < this.capacity {
+      this.drop(arcRecentGhost)
+      this.replace(false)
+    } else {
+      node := this.queues[arcRecent].Back().Value.(*cacheNode)
+      this.drop(arcRecent)
+      this.StatsCounter().RecordEviction(EvictedBySize)
+      if this.whenEvicted != nil {
+        this.whenEvicted(node.entry())
+      }
+    }
+  } else if recent + recentGhosts + this.queues[arcFrequent].Length() + frequentGhosts >= this.capacity {
+    if recent + recentGhosts + this.queues[arcFrequent].Length() + frequentGhosts >= 2 * this.capacity {
+      this.drop(arcFrequentGhost)
+    }
+    this.replace(false)
+  }
+  node := newCacheNode(key, value, arcRecent)
+  this.table.AddEntry(key, node)
+  this.queues[arcRecent].InsertFront(node.elem)
+}
+
+func (this *arcCache) Remove(keys ...interface{}) {
+  for _, key := range keys {
+    if entry := this.table.FindEntry(key); entry != nil {
+      node := entry.Value.(*cacheNode)
+      this.queues[node.queue].Remove(node.elem)
+      this.table.DeleteEntry(key)
+    }
+  }
+}
+
+func (this *arcCache) Clear() {
+  this.table.Clear()
+  for i := range this.queues {
+    this.queues[i] = impl.NewDoubleLinkedList()
+  }
+  this.target = 0
+}
+
+func (this *arcCache) move(node *cacheNode, queue int) {
+  this.queues[node.queue].Remove(node.elem)
+  node.queue = queue
+  this.queues[queue].InsertFront(node.elem)
+}
+
+// drop removes the least recently used node of the given queue from the
+// cache if the queue is not empty.
+func (this *arcCache) drop(queue int) {
+  if this.queues[queue].Length() > 0 {
+    node := this.queues[queue].Back().Value.(*cacheNode)
+    this.queues[queue].Remove(node.elem)
+    this.table.DeleteEntry(node.key)
+  }
+}
+
+// replace makes room for a new entry if the cache is full by evicting the
+// least recently used entry of either the recent or the frequent queue. The
+// key of the evicted entry is remembered in the corresponding ghost queue.
+func (this *arcCache) replace(frequentGhostHit bool) {
+  if this.Size() < this.capacity {
+    return
+  }
+  recent := this.queues[arcRecent].Length()
+  var node *cacheNode
+  if recent > 0 &&
+     (recent > this.target || (frequentGhostHit && recent == this.target) ||
+      this.queues[arcFrequent].Length() == 0) {
+    node = this.queues[arcRecent].Back().Value.(*cacheNode)
+    this.move(node, arcRecentGhost)
+  } else {
+    node = this.queues[arcFrequent].Back().Value.(*cacheNode)
+    this.move(node, arcFrequentGhost)
+  }
+  evicted := node.entry()
+  node.value = nil
+  this.StatsCounter().RecordEviction(EvictedBySize)
+  if this.whenEvicted != nil {
+    this.whenEvicted(evicted)
+  }
+}
@@ -0,0 +1,209 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// BiMap is a MutableMap whose values are unique, i.e. no two keys are mapped
+// to equal values. This makes it possible to look up keys by value via the
+// inverse map. What happens if a key gets mapped to a value which is mapped
+// by a different key already is defined by the BiMapConflict policy of the
+// bidirectional map.
+type BiMap interface {
+  MutableMap
+
+  // Inverse returns a live view of this map with keys and values swapped.
+  // The view shares the storage with this map: changing either map changes
+  // the other one as well. The inverse of the inverse is this map.
+  Inverse() BiMap
+
+  // Conflict returns the policy that defines how value conflicts are resolved.
+  Conflict() BiMapConflict
+}
+
+// BiMapConflict defines how Include resolves value conflicts, i.e. attempts
+// to map a key to a value which is mapped by a different key already.
+type BiMapConflict int
+
+const (
+  // BiMapError makes Include panic on value conflicts; the map is unchanged.
+  BiMapError BiMapConflict = iota
+
+  // BiMapReplace makes the new entry replace the entry of the value. If the
+  // key is mapped to another value as well, replacing both entries with a
+  // single one would lose an entry. In this case, Include panics.
+  BiMapReplace
+
+  // BiMapForce makes Include remove all entries conflicting with the new
+  // entry. This might reduce the size of the map.
+  BiMapForce
+)
+
+// BiMapClass defines the functionality of BiMap implementations. In addition
+// to the MutableMapClass methods, which return MutableMap values, it provides
+// factory methods returning BiMap values.
+type BiMapClass interface {
+  MutableMapClass
+  NewBi(entries... MapEntry) BiMap
+  FromBi(coll Container) BiMap
+}
+
+var HashBiMap BiMapClass = HashBiMapClass(UniversalHash, UniversalEquality,
+                                          UniversalHash, UniversalEquality,
+                                          BiMapError)
+
+// HashBiMapClass returns a class of bidirectional maps storing entries in two
+// hash tables. Keys are compared with keyHash and keyEquals, values with
+// valueHash and valueEquals.
+func HashBiMapClass(keyHash Hashfunction, keyEquals Equality,
+                    valueHash Hashfunction, valueEquals Equality,
+                    conflict BiMapConflict) BiMapClass {
+  return &hashBiMapClass{keyHash, keyEquals, valueHash, valueEquals, conflict}
+}
+
+type hashBiMapClass struct {
+  keyHash Hashfunction
+  keyEquals Equality
+  valueHash Hashfunction
+  valueEquals Equality
+  conflict BiMapConflict
+}
+
+func (this *hashBiMapClass) Embed(obj MutableMap) MutableMap {
+  forward := impl.NewHashTable(17, 80, this.keyHash, this.keyEquals)
+  backward := impl.NewHashTable(17, 80, this.valueHash, this.valueEquals)
+  res := newHashBiMap(obj, forward, backward, this.conflict)
+  res.inverse = newHashBiMap(nil, backward, forward, this.conflict)
+  res.inverse.inverse = res
+  return res
+}
+
+func (this *hashBiMapClass) New(entries... MapEntry) MutableMap {
+  return this.NewBi(entries...)
+}
+
+func (this *hashBiMapClass) From(coll Container) MutableMap {
+  return this.FromBi(coll)
+}
+
+func (this *hashBiMapClass) FromNative(mp map[interface{}] interface{}) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFromNative(mp)
+  return res
+}
+
+func (this *hashBiMapClass) NewBi(entries... MapEntry) BiMap {
+  res := this.Embed(nil).(*hashBiMap)
+  res.IncludeEntry(entries...)
+  return res
+}
+
+func (this *hashBiMapClass) FromBi(coll Container) BiMap {
+  res := this.Embed(nil).(*hashBiMap)
+  res.IncludeFrom(coll)
+  return res
+}
+
+// hashBiMap maps keys to values in table forward and values to keys in table
+// backward. Its inverse is a hashBiMap with the two tables swapped.
+type hashBiMap struct {
+  obj MutableMap
+  forward *impl.HashTable
+  backward *impl.HashTable
+  conflict BiMapConflict
+  inverse *hashBiMap
+  MutableMapDerived
+}
+
+func newHashBiMap(obj MutableMap,
+                  forward, backward *impl.HashTable,
+                  conflict BiMapConflict) *hashBiMap {
+  res := &hashBiMap{forward: forward, backward: backward, conflict: conflict}
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableMapDerived = EmbeddedMutableMap(obj)
+  return res
+}
+
+func (this *hashBiMap) Inverse() BiMap {
+  return this.inverse
+}
+
+func (this *hashBiMap) Conflict() BiMapConflict {
+  return this.conflict
+}
+
+func (this *hashBiMap) Size() int {
+  return this.forward.Size()
+}
+
+func (this *hashBiMap) Get(key interface{}) (value interface{}, exists bool) {
+  if entry := this.forward.FindEntry(key); entry != nil {
+    return entry.Value, true
+  }
+  return nil, false
+}
+
+func (this *hashBiMap) Elements() Iterator {
+  return &hashMapIterator{this.forward.Iterator()}
+}
+
+func (this *hashBiMap) Class() MutableMapClass {
+  return HashBiMapClass(this.forward.Hash(), this.forward.Equality(),
+                        this.backward.Hash(), this.backward.Equality(),
+                        this.conflict)
+}
+
+func (this *hashBiMap) Include(key, value interface{}) {
+  current := this.forward.FindEntry(key)
+  if owner := this.backward.FindEntry(value); owner != nil {
+    if this.forward.Equality()(owner.Value, key) {
+      return
+    }
+    switch {
+      case this.conflict == BiMapError:
+        panic("BiMap.Include: value is mapped by a different key")
+      case this.conflict == BiMapReplace && current != nil:
+        panic("BiMap.Include: replacing entries of key and value would lose an entry")
+    }
+    this.forward.DeleteEntry(owner.Value)
+    this.backward.DeleteEntry(value)
+  }
+  if current != nil {
+    this.backward.DeleteEntry(current.Value)
+    current.Value = value
+  } else {
+    this.forward.AddEntry(key, value)
+  }
+  this.backward.AddEntry(value, key)
+}
+
+func (this *hashBiMap) Exclude(keys ...interface{}) {
+  for _, key := range keys {
+    if entry := this.forward.FindEntry(key); entry != nil {
+      this.backward.DeleteEntry(entry.Value)
+      this.forward.DeleteEntry(key)
+    }
+  }
+}
+
+func (this *hashBiMap) Clear() {
+  this.forward.Clear()
+  this.backward.Clear()
+}
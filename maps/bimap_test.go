@@ -0,0 +1,91 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "strings"
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func expectPanic(t *testing.T, name string, f func ()) {
+  defer func () {
+    if recover() == nil {
+      t.Errorf("Expected %s to panic", name)
+    }
+  }()
+  f()
+}
+
+func TestHashBiMapClass(t *testing.T) {
+  codes := HashBiMap.NewBi(KV("one", 1), KV("two", 2), KV("three", 3))
+  names := codes.Inverse()
+  if names.GetValue(2) != "two" || names.Inverse() != codes {
+    t.Errorf("Inverse does not map values to keys")
+  }
+  codes.Include("two", 22)
+  if names.HasKey(2) || names.GetValue(22) != "two" || names.Size() != 3 {
+    t.Errorf("Inverse does not reflect changes")
+  }
+  names.Include(4, "four")
+  if codes.GetValue("four") != 4 {
+    t.Errorf("Changes of inverse are not reflected")
+  }
+  expectPanic(t, "conflicting Include", func () {
+    codes.Include("uno", 1)
+  })
+  codes.Include("one", 1)
+  codes.Exclude("one")
+  if names.HasKey(1) || codes.Size() != 3 {
+    t.Errorf("Exclude does not remove inverse entry")
+  }
+  codes.Clear()
+  checkSize(t, names, 0, "names")
+}
+
+func TestBiMapConflicts(t *testing.T) {
+  replace := HashBiMapClass(UniversalHash, UniversalEquality,
+                            UniversalHash, UniversalEquality, BiMapReplace)
+  m := replace.NewBi(KV(1, "a"), KV(2, "b"))
+  m.Include(3, "a")
+  if m.HasKey(1) || m.GetValue(3) != "a" || m.Size() != 2 {
+    t.Errorf("Expected entry of value a to be replaced")
+  }
+  expectPanic(t, "lossy replacement", func () {
+    m.Include(3, "b")
+  })
+  force := HashBiMapClass(UniversalHash, UniversalEquality,
+                          UniversalHash, UniversalEquality, BiMapForce)
+  m = force.NewBi(KV(1, "a"), KV(2, "b"))
+  m.Include(1, "b")
+  if m.Size() != 1 || m.GetValue(1) != "b" || m.Inverse().GetValue("b") != 1 {
+    t.Errorf("Expected conflicting entries to be removed; was %v", m)
+  }
+}
+
+func TestBiMapEqualities(t *testing.T) {
+  caseless := func (x, y interface{}) bool {
+    return strings.EqualFold(x.(string), y.(string))
+  }
+  hash := func (x interface{}) int {
+    return UniversalHash(strings.ToLower(x.(string)))
+  }
+  m := HashBiMapClass(UniversalHash, UniversalEquality, hash, caseless, BiMapError).NewBi(KV(1, "One"))
+  if m.Inverse().GetValue("ONE") != 1 {
+    t.Errorf("Expected values to be compared ignoring case")
+  }
+  expectPanic(t, "conflicting Include", func () {
+    m.Include(2, "one")
+  })
+}
@@ -15,6 +15,7 @@
 package maps
 
 import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
 
 
 type Cache interface {
@@ -63,3 +64,84 @@ type cache struct {
 func (this *cache) String() string {
   return "«" + this.FiniteContainerDerived.String() + "»"
 }
+
+// Weigher determines the weight of a cache entry. Weights must not be
+// negative and must not change while an entry is cached.
+type Weigher func (kv MapEntry) int
+
+// WeightedCache is a Cache whose capacity limits the total weight of its
+// entries instead of their number. Adding an entry evicts as many entries as
+// needed to stay within the capacity. An entry which is heavier than the
+// capacity is rejected: a previous mapping of its key gets evicted, and both
+// are passed to the eviction callback immediately.
+//
+// Weighted caches are provided by WeightedLruCacheClass, WeightedLfuCacheClass
+// and WeightedTtlCacheClass. The ARC, 2Q and W-TinyLFU policies size their
+// queues, ghost queues and segments by the number of entries; their caches
+// always limit the number of entries.
+type WeightedCache interface {
+  Cache
+
+  // Weight returns the total weight of all entries.
+  Weight() int
+
+  // MaxWeight returns the capacity of this cache.
+  MaxWeight() int
+}
+
+// WeightedCacheClass defines the functionality of WeightedCache
+// implementations. In addition to the CacheClass methods, whose capacity
+// parameter denotes the maximum total weight, it provides factory methods
+// returning WeightedCache values.
+type WeightedCacheClass interface {
+  CacheClass
+  NewWeighted(maxWeight int) WeightedCache
+  NewWeightedWithCallback(maxWeight int, whenEvicted func (kv MapEntry)) WeightedCache
+}
+
+// weigh returns the weight of a mapping from key to value. Without a
+// weigher, all entries have weight 1.
+func weigh(weigher Weigher, key, value interface{}) int {
+  if weigher == nil {
+    return 1
+  }
+  res := weigher(KV(key, value))
+  if res < 0 {
+    panic("Weigher: negative weight")
+  }
+  return res
+}
+
+// cacheNode is an entry of a cache which is linked into one of the queues
+// maintained by the replacement policy of the cache. The meaning of queue
+// depends on the policy.
+type cacheNode struct {
+  key interface{}
+  value interface{}
+  queue int
+  weight int
+  elem *impl.Element
+}
+
+func newCacheNode(key, value interface{}, queue int) *cacheNode {
+  res := &cacheNode{key: key, value: value, queue: queue}
+  res.elem = impl.NewElement(res)
+  return res
+}
+
+func (this *cacheNode) entry() MapEntry {
+  return KV(this.key, this.value)
+}
+
+// newCacheQueueIterator returns an iterator over the entries of the given
+// queues of cache nodes, starting with the most recently inserted node of
+// each queue.
+func newCacheQueueIterator(queues ...*impl.DoubleLinkedList) Iterator {
+  res := EmptyIterator
+  for i := len(queues) - 1; i >= 0; i-- {
+    res = NewCompositeIterator(NewMappedIterator(func (x interface{}) interface{} {
+      return x.(*cacheNode).entry()
+    }, queues[i].Iterator()), res)
+  }
+  return res
+}
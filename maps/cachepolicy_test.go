@@ -0,0 +1,244 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "math/rand"
+import "strings"
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+var cachePolicies = []struct {
+  name string
+  class func (hash Hashfunction, equals Equality) CacheClass
+}{
+  {"LRU", LruCacheClass},
+  {"LFU", LfuCacheClass},
+  {"ARC", ArcCacheClass},
+  {"2Q", TwoQueueCacheClass},
+  {"W-TinyLFU", TinyLfuCacheClass},
+}
+
+// Synthetic traces
+
+func zipfTrace(n int) []int {
+  r := rand.New(rand.NewSource(1))
+  zipf := rand.NewZipf(r, 1.1, 1, 9999)
+  res := make([]int, n)
+  for i := range res {
+    res[i] = int(zipf.Uint64())
+  }
+  return res
+}
+
+func loopTrace(n int, length int) []int {
+  res := make([]int, n)
+  for i := range res {
+    res[i] = i % length
+  }
+  return res
+}
+
+func scanTrace(n int) []int {
+  r := rand.New(rand.NewSource(2))
+  zipf := rand.NewZipf(r, 1.1, 1, 999)
+  res := make([]int, n)
+  for i := range res {
+    if (i / 1000) % 2 == 1 {
+      res[i] = 100000 + i
+    } else {
+      res[i] = int(zipf.Uint64())
+    }
+  }
+  return res
+}
+
+// runTrace replays trace on cache, adding every missed key, and returns the
+// hit ratio. It checks the values of hits, the capacity and the number of
+// evictions reported via evicted.
+func runTrace(t *testing.T, name string, cache Cache, capacity int, evicted *int, trace []int) float64 {
+  hits := 0
+  added := 0
+  for _, key := range trace {
+    if value, exists := cache.Get(key); exists {
+      hits++
+      if value != 2 * key {
+        t.Errorf("%s: expected value %d for key %d; got %v", name, 2 * key, key, value)
+      }
+    } else {
+      cache.Add(key, 2 * key)
+      added++
+    }
+    if cache.Size() > capacity {
+      t.Fatalf("%s: size %d exceeds capacity %d", name, cache.Size(), capacity)
+    }
+  }
+  if added - *evicted != cache.Size() {
+    t.Errorf("%s: %d entries added and %d evicted, but size is %d",
+             name, added, *evicted, cache.Size())
+  }
+  if n := CountElements(cache.Elements()); n != cache.Size() {
+    t.Errorf("%s: iterated over %d entries; size is %d", name, n, cache.Size())
+  }
+  return float64(hits) / float64(len(trace))
+}
+
+func TestCachePolicyHitRatios(t *testing.T) {
+  traces := []struct {
+    name string
+    trace []int
+  }{
+    {"zipf", zipfTrace(50000)},
+    {"loop", loopTrace(50000, 300)},
+    {"zipf+scan", scanTrace(50000)},
+  }
+  const capacity = 200
+  ratios := make(map[string]map[string]float64)
+  for _, trace := range traces {
+    ratios[trace.name] = make(map[string]float64)
+    for _, policy := range cachePolicies {
+      evicted := 0
+      cache := policy.class(UniversalHash, UniversalEquality).NewWithCallback(capacity,
+          func (kv MapEntry) { evicted++ })
+      ratio := runTrace(t, policy.name, cache, capacity, &evicted, trace.trace)
+      ratios[trace.name][policy.name] = ratio
+      t.Logf("%-10s %-10s hit ratio %.3f", trace.name, policy.name, ratio)
+    }
+  }
+  for _, policy := range cachePolicies[1:] {
+    for _, trace := range []string{"zipf", "zipf+scan"} {
+      if ratios[trace][policy.name] <= ratios[trace]["LRU"] {
+        t.Errorf("Expected %s to beat LRU on the %s trace", policy.name, trace)
+      }
+    }
+  }
+  // LFU and ARC retain no history for keys of a loop larger than the cache
+  for _, name := range []string{"2Q", "W-TinyLFU"} {
+    if ratios["loop"][name] <= ratios["loop"]["LRU"] {
+      t.Errorf("Expected %s to beat LRU on a loop larger than the cache", name)
+    }
+  }
+}
+
+func TestCachePolicyCallbacks(t *testing.T) {
+  for _, policy := range cachePolicies {
+    var evicted []MapEntry
+    cache := policy.class(UniversalHash, UniversalEquality).NewWithCallback(4,
+        func (kv MapEntry) { evicted = append(evicted, kv) })
+    for i := 0; i < 10; i++ {
+      cache.Add(i, i * i)
+    }
+    if len(evicted) != 6 || cache.Size() != 4 {
+      t.Errorf("%s: expected 6 evictions and size 4; got %d and %d",
+               policy.name, len(evicted), cache.Size())
+    }
+    for _, kv := range evicted {
+      if key := kv.Key().(int); kv.Value() != key * key || cache.HasKey(key) {
+        t.Errorf("%s: unexpected eviction of %v", policy.name, kv)
+      }
+    }
+    cache.Remove(evicted[0].Key())
+    for iter := cache.Elements(); iter.HasNext(); {
+      kv := iter.Next().(MapEntry)
+      cache.Remove(kv.Key())
+    }
+    if !cache.IsEmpty() || len(evicted) != 6 {
+      t.Errorf("%s: expected empty cache without further evictions; got %s", policy.name, cache)
+    }
+  }
+}
+
+func TestCachePolicyCustomEquality(t *testing.T) {
+  hash := func (x interface{}) int {
+    return UniversalHash(strings.ToLower(x.(string)))
+  }
+  equals := func (x, y interface{}) bool {
+    return strings.EqualFold(x.(string), y.(string))
+  }
+  for _, policy := range cachePolicies {
+    cache := policy.class(hash, equals).New(8)
+    cache.Add("Key", 1)
+    checkCacheEntry(t, cache, "KEY", 1, 1)
+    cache.Add("kEy", 2)
+    checkCacheEntry(t, cache, "key", 2, 1)
+    cache.Remove("KEY")
+    checkCacheEntry(t, cache, "key", nil, 0)
+  }
+}
+
+func TestCachePolicyClass(t *testing.T) {
+  for _, class := range []CacheClass{LruCache, LfuCache, ArcCache, TwoQueueCache, TinyLfuCache} {
+    if cache := class.New(4).(interface{ Class() CacheClass }); cache.Class() != class {
+      t.Errorf("Expected cache %v to return the class that created it", cache)
+    }
+  }
+}
+
+func TestLfuCacheEvictsLeastFrequent(t *testing.T) {
+  cache := LfuCache.New(3)
+  cache.Add("a", 1)
+  cache.Add("b", 2)
+  cache.Add("c", 3)
+  cache.Get("a")
+  cache.Get("a")
+  cache.Get("c")
+  cache.Add("d", 4)
+  checkCacheEntry(t, cache, "b", nil, 3)
+  cache.Add("e", 5)
+  checkCacheEntry(t, cache, "d", nil, 3)
+  checkCacheEntry(t, cache, "a", 1, 3)
+  checkCacheEntry(t, cache, "c", 3, 3)
+}
+
+func TestWeightedLfuCacheClass(t *testing.T) {
+  var evicted []MapEntry
+  weigher := func (kv MapEntry) int {
+    return len(kv.Value().(string))
+  }
+  cache := WeightedLfuCacheClass(UniversalHash, UniversalEquality, weigher).
+      NewWeightedWithCallback(10, func (kv MapEntry) { evicted = append(evicted, kv) })
+  recorder := cache.(StatsRecorder)
+  recorder.RecordStats(true)
+  cache.Add("a", "xxx")
+  cache.Add("b", "xxx")
+  cache.Add("c", "xx")
+  cache.Get("a")
+  cache.Get("a")
+  cache.Get("c")
+  cache.Add("d", "xxxxx")
+  if cache.Weight() != 10 || len(evicted) != 1 || evicted[0].Key() != "b" {
+    t.Errorf("Expected weight 10 after evicting b; got %d after %v", cache.Weight(), evicted)
+  }
+  cache.Add("c", "xxxxxx")
+  if cache.Weight() != 9 || len(evicted) != 2 || evicted[1].Key() != "d" {
+    t.Errorf("Expected weight 9 after evicting d; got %d after %v", cache.Weight(), evicted)
+  }
+  checkCacheEntry(t, cache, "a", "xxx", 2)
+  checkCacheEntry(t, cache, "c", "xxxxxx", 2)
+  cache.Add("a", "xxxxxxxxxxx")
+  checkCacheEntry(t, cache, "a", nil, 1)
+  if cache.Weight() != 6 || len(evicted) != 4 ||
+     evicted[2].Value() != "xxx" || evicted[3].Value() != "xxxxxxxxxxx" {
+    t.Errorf("Expected eviction of previous and overweight entry; got weight %d and evictions %v",
+             cache.Weight(), evicted)
+  }
+  if stats := recorder.Stats(); stats.Evictions[EvictedBySize] != 4 {
+    t.Errorf("Expected 4 evictions in stats; got %d", stats.Evictions[EvictedBySize])
+  }
+  cache.Remove("c")
+  if cache.Weight() != 0 || cache.MaxWeight() != 10 {
+    t.Errorf("Expected weight 0 of 10; got %d of %d", cache.Weight(), cache.MaxWeight())
+  }
+}
@@ -0,0 +1,222 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "fmt"
+import "sync/atomic"
+import "time"
+
+
+// StatsRecorder is implemented by caches which can record statistics about
+// their effectiveness. Recording is disabled initially; while it is
+// disabled, caches only pay for checking whether it is enabled.
+type StatsRecorder interface {
+  // RecordStats enables or disables recording statistics. Disabling it
+  // drops all counters.
+  RecordStats(enabled bool)
+
+  // Stats returns a snapshot of the statistics recorded so far.
+  Stats() CacheStats
+
+  // ResetStats sets all counters to zero.
+  ResetStats()
+
+  // StatsCounter returns the counter for recording statistics, or nil if
+  // recording is disabled. Recording via a nil counter has no effect.
+  StatsCounter() *StatsCounter
+}
+
+// EvictionCause defines why an entry was evicted from a cache.
+type EvictionCause int
+
+const (
+  // EvictedBySize denotes evictions to keep a cache within its capacity.
+  EvictedBySize EvictionCause = iota
+
+  // EvictedByExpiry denotes evictions of entries whose time-to-live expired.
+  EvictedByExpiry
+
+  evictionCauses
+)
+
+func (this EvictionCause) String() string {
+  switch this {
+    case EvictedBySize:
+      return "size"
+    case EvictedByExpiry:
+      return "expiry"
+  }
+  return "unknown"
+}
+
+// CacheStats is a snapshot of the statistics of a cache.
+type CacheStats struct {
+  Hits uint64
+  Misses uint64
+  Evictions [evictionCauses]uint64
+  LoadSuccesses uint64
+  LoadFailures uint64
+  TotalLoadTime time.Duration
+}
+
+// RequestCount returns the number of lookups.
+func (this CacheStats) RequestCount() uint64 {
+  return this.Hits + this.Misses
+}
+
+// HitRate returns the ratio of lookups that were hits. It is 1 if there
+// were no lookups.
+func (this CacheStats) HitRate() float64 {
+  if requests := this.RequestCount(); requests > 0 {
+    return float64(this.Hits) / float64(requests)
+  }
+  return 1
+}
+
+// MissRate returns the ratio of lookups that were misses. It is 0 if there
+// were no lookups.
+func (this CacheStats) MissRate() float64 {
+  return 1 - this.HitRate()
+}
+
+// EvictionCount returns the number of evictions for any cause.
+func (this CacheStats) EvictionCount() uint64 {
+  res := uint64(0)
+  for _, n := range this.Evictions {
+    res += n
+  }
+  return res
+}
+
+// LoadCount returns the number of loads, including failed ones.
+func (this CacheStats) LoadCount() uint64 {
+  return this.LoadSuccesses + this.LoadFailures
+}
+
+// AverageLoadTime returns the average time spent per load.
+func (this CacheStats) AverageLoadTime() time.Duration {
+  if loads := this.LoadCount(); loads > 0 {
+    return this.TotalLoadTime / time.Duration(loads)
+  }
+  return 0
+}
+
+func (this CacheStats) String() string {
+  return fmt.Sprintf("CacheStats(hits=%d, misses=%d, hitRate=%.3f, evictions=%d " +
+                     "[size=%d, expiry=%d], loadSuccesses=%d, loadFailures=%d, " +
+                     "totalLoadTime=%s)",
+                     this.Hits, this.Misses, this.HitRate(), this.EvictionCount(),
+                     this.Evictions[EvictedBySize], this.Evictions[EvictedByExpiry],
+                     this.LoadSuccesses, this.LoadFailures, this.TotalLoadTime)
+}
+
+// StatsCounter accumulates cache statistics. It is safe for concurrent use.
+// All methods of a nil StatsCounter do nothing.
+type StatsCounter struct {
+  hits atomic.Uint64
+  misses atomic.Uint64
+  evictions [evictionCauses]atomic.Uint64
+  loadSuccesses atomic.Uint64
+  loadFailures atomic.Uint64
+  loadTime atomic.Int64
+}
+
+func (this *StatsCounter) RecordHit() {
+  if this != nil {
+    this.hits.Add(1)
+  }
+}
+
+func (this *StatsCounter) RecordMiss() {
+  if this != nil {
+    this.misses.Add(1)
+  }
+}
+
+func (this *StatsCounter) RecordEviction(cause EvictionCause) {
+  if this != nil {
+    this.evictions[cause].Add(1)
+  }
+}
+
+func (this *StatsCounter) RecordLoadSuccess(loadTime time.Duration) {
+  if this != nil {
+    this.loadSuccesses.Add(1)
+    this.loadTime.Add(int64(loadTime))
+  }
+}
+
+func (this *StatsCounter) RecordLoadFailure(loadTime time.Duration) {
+  if this != nil {
+    this.loadFailures.Add(1)
+    this.loadTime.Add(int64(loadTime))
+  }
+}
+
+// Snapshot returns the current values of all counters.
+func (this *StatsCounter) Snapshot() CacheStats {
+  var res CacheStats
+  if this != nil {
+    res.Hits = this.hits.Load()
+    res.Misses = this.misses.Load()
+    for i := range this.evictions {
+      res.Evictions[i] = this.evictions[i].Load()
+    }
+    res.LoadSuccesses = this.loadSuccesses.Load()
+    res.LoadFailures = this.loadFailures.Load()
+    res.TotalLoadTime = time.Duration(this.loadTime.Load())
+  }
+  return res
+}
+
+// Reset sets all counters to zero.
+func (this *StatsCounter) Reset() {
+  if this != nil {
+    this.hits.Store(0)
+    this.misses.Store(0)
+    for i := range this.evictions {
+      this.evictions[i].Store(0)
+    }
+    this.loadSuccesses.Store(0)
+    this.loadFailures.Store(0)
+    this.loadTime.Store(0)
+  }
+}
+
+// cacheStats implements StatsRecorder. It is embedded by the caches of this
+// package.
+type cacheStats struct {
+  counter atomic.Pointer[StatsCounter]
+}
+
+func (this *cacheStats) RecordStats(enabled bool) {
+  if !enabled {
+    this.counter.Store(nil)
+  } else if this.counter.Load() == nil {
+    this.counter.CompareAndSwap(nil, new(StatsCounter))
+  }
+}
+
+func (this *cacheStats) Stats() CacheStats {
+  return this.counter.Load().Snapshot()
+}
+
+func (this *cacheStats) ResetStats() {
+  this.counter.Load().Reset()
+}
+
+func (this *cacheStats) StatsCounter() *StatsCounter {
+  return this.counter.Load()
+}
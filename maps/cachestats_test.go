@@ -0,0 +1,110 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "errors"
+import "testing"
+import "time"
+import . "github.com/objecthub/containerkit"
+
+
+func TestCacheStatsPolicies(t *testing.T) {
+  trace := zipfTrace(5000)
+  for _, policy := range cachePolicies {
+    evicted := 0
+    cache := policy.class(UniversalHash, UniversalEquality).NewWithCallback(50,
+        func (kv MapEntry) { evicted++ })
+    recorder := cache.(StatsRecorder)
+    runTrace(t, policy.name, cache, 50, &evicted, trace[:100])
+    if stats := recorder.Stats(); stats != (CacheStats{}) {
+      t.Errorf("%s: expected no statistics while disabled; got %s", policy.name, stats)
+    }
+    evicted = 0
+    cache = policy.class(UniversalHash, UniversalEquality).NewWithCallback(50,
+        func (kv MapEntry) { evicted++ })
+    recorder = cache.(StatsRecorder)
+    recorder.RecordStats(true)
+    hitRatio := runTrace(t, policy.name, cache, 50, &evicted, trace)
+    stats := recorder.Stats()
+    // runTrace calls Get once per access and adds every miss
+    if stats.RequestCount() != uint64(len(trace)) || stats.HitRate() != hitRatio {
+      t.Errorf("%s: expected %d requests with hit rate %.3f; got %s",
+               policy.name, len(trace), hitRatio, stats)
+    }
+    if stats.Evictions[EvictedBySize] != uint64(evicted) || stats.EvictionCount() != uint64(evicted) {
+      t.Errorf("%s: expected %d evictions; got %s", policy.name, evicted, stats)
+    }
+    recorder.ResetStats()
+    if stats := recorder.Stats(); stats != (CacheStats{}) {
+      t.Errorf("%s: expected no statistics after reset; got %s", policy.name, stats)
+    }
+    recorder.RecordStats(false)
+    cache.Get(-1)
+    if recorder.StatsCounter() != nil || recorder.Stats().Misses != 0 {
+      t.Errorf("%s: expected recording to be disabled", policy.name)
+    }
+  }
+}
+
+func TestCacheStatsExpiry(t *testing.T) {
+  clock := NewManualClock(time.Unix(1000, 0))
+  cache := TtlCacheClass(UniversalHash, UniversalEquality, time.Minute, ExpireAfterWrite, clock).
+      NewExpiring(2)
+  cache.(StatsRecorder).RecordStats(true)
+  cache.Add(1, "a")
+  cache.Add(2, "b")
+  cache.Add(3, "c")
+  cache.Get(2)
+  clock.Advance(time.Minute)
+  cache.Get(2)
+  cache.CleanUp()
+  stats := cache.(StatsRecorder).Stats()
+  if stats.Hits != 1 || stats.Misses != 1 || stats.HitRate() != 0.5 {
+    t.Errorf("Expected 1 hit and 1 miss; got %s", stats)
+  }
+  if stats.Evictions[EvictedBySize] != 1 || stats.Evictions[EvictedByExpiry] != 2 {
+    t.Errorf("Expected 1 eviction by size and 2 by expiry; got %s", stats)
+  }
+}
+
+func TestCacheStatsLoads(t *testing.T) {
+  failure := errors.New("failure")
+  lru := LruCache.New(8)
+  lru.(StatsRecorder).RecordStats(true)
+  cache := loadingCaches.New(lru, func (key interface{}) (interface{}, error) {
+    time.Sleep(time.Millisecond)
+    if key == "bad" {
+      return nil, failure
+    }
+    return key, nil
+  })
+  cache.Get("a")
+  cache.Get("a")
+  cache.Get("bad")
+  cache.GetAll("b", "c")
+  stats := cache.Stats()
+  if stats.LoadSuccesses != 3 || stats.LoadFailures != 1 {
+    t.Errorf("Expected 3 successful and 1 failed load; got %s", stats)
+  }
+  if stats.Hits != 1 || stats.Misses != 4 {
+    t.Errorf("Expected 1 hit and 4 misses; got %s", stats)
+  }
+  if stats.TotalLoadTime < 4 * time.Millisecond || stats.AverageLoadTime() < time.Millisecond {
+    t.Errorf("Expected load time of at least 4ms; got %s", stats)
+  }
+  if stats := loadingCaches.New(LfuCache.New(8), nil).Stats(); stats.HitRate() != 1 {
+    t.Errorf("Expected hit rate 1 without requests; got %s", stats)
+  }
+}
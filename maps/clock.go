@@ -0,0 +1,59 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "sync"
+import "time"
+
+
+// Clock is the source of the current time for caches whose entries expire.
+// Tests can use a ManualClock to control time deterministically.
+type Clock interface {
+  Now() time.Time
+}
+
+// SystemClock is a Clock returning the current system time.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct {}
+
+func (this systemClock) Now() time.Time {
+  return time.Now()
+}
+
+// ManualClock is a Clock whose time only changes when it is advanced
+// explicitly. It is safe for concurrent use.
+type ManualClock struct {
+  mutex sync.Mutex
+  now time.Time
+}
+
+// NewManualClock returns a new clock whose current time is start.
+func NewManualClock(start time.Time) *ManualClock {
+  return &ManualClock{now: start}
+}
+
+func (this *ManualClock) Now() time.Time {
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  return this.now
+}
+
+// Advance moves the current time of this clock forward by d.
+func (this *ManualClock) Advance(d time.Duration) {
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  this.now = this.now.Add(d)
+}
@@ -0,0 +1,204 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// ConcurrentMap is a MutableMap which can be accessed concurrently from
+// multiple goroutines without external synchronization. Single-key
+// operations are atomic, including GetOrInclude, ComputeIfAbsent,
+// ComputeIfPresent, Compute and Merge, unless the map is embedded by another
+// map; the functions passed to these must not access the map. Operations involving multiple keys, like IncludeFrom
+// or ExcludeKeys, are not atomic. Iterators are weakly consistent.
+type ConcurrentMap interface {
+  MutableMap
+
+  // PutIfAbsent atomically maps key to value if key is not mapped yet. It
+  // returns the value key is mapped to after the operation and true if this
+  // value was present already.
+  PutIfAbsent(key, value interface{}) (actual interface{}, loaded bool)
+}
+
+// ConcurrentHashMap creates maps which implement ConcurrentMap.
+var ConcurrentHashMap MutableMapClass =
+    ConcurrentHashMapClass(UniversalHash, UniversalEquality, 16)
+
+// ConcurrentHashMapClass returns a class for concurrent hash maps which
+// stripe their keys across at least concurrency independently locked
+// hash tables.
+func ConcurrentHashMapClass(hash Hashfunction,
+                            equals Equality,
+                            concurrency int) MutableMapClass {
+  return &concurrentHashMapClass{hash, equals, concurrency}
+}
+
+type concurrentHashMapClass struct {
+  hash Hashfunction
+  equals Equality
+  concurrency int
+}
+
+func (this *concurrentHashMapClass) Embed(obj MutableMap) MutableMap {
+  res := new(concurrentHashMap)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableMapDerived = EmbeddedMutableMap(obj)
+  res.table = impl.NewConcurrentHashTable(this.concurrency, 17 * this.concurrency, 80,
+                                          this.hash, this.equals)
+  return res
+}
+
+func (this *concurrentHashMapClass) New(entries... MapEntry) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeEntry(entries...)
+  return res
+}
+
+func (this *concurrentHashMapClass) From(coll Container) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+func (this *concurrentHashMapClass) FromNative(mp map[interface{}] interface{}) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFromNative(mp)
+  return res
+}
+
+type concurrentHashMap struct {
+  obj MutableMap
+  table *impl.ConcurrentHashTable
+  MutableMapDerived
+}
+
+func (this *concurrentHashMap) Size() int {
+  return this.table.Size()
+}
+
+func (this *concurrentHashMap) Get(key interface{}) (value interface{}, exists bool) {
+  return this.table.Get(key)
+}
+
+func (this *concurrentHashMap) Elements() Iterator {
+  return &concurrentHashMapIterator{this.table.Iterator()}
+}
+
+func (this *concurrentHashMap) Class() MutableMapClass {
+  return ConcurrentHashMapClass(this.table.Hash(),
+                                this.table.Equality(),
+                                this.table.Concurrency())
+}
+
+func (this *concurrentHashMap) Include(key, value interface{}) {
+  this.table.Put(key, value)
+}
+
+func (this *concurrentHashMap) PutIfAbsent(key, value interface{}) (actual interface{}, loaded bool) {
+  return this.table.PutIfAbsent(key, value)
+}
+
+func (this *concurrentHashMap) ComputeIfAbsent(key interface{}, f Mapping) interface{} {
+  if this.obj != this {
+    return this.MutableMapDerived.ComputeIfAbsent(key, f)
+  }
+  if value, exists := this.table.Get(key); exists {
+    return value
+  }
+  res, _ := this.table.Compute(key, func (value interface{}, exists bool) (interface{}, bool) {
+    if exists {
+      return value, true
+    }
+    return f(key), true
+  })
+  return res
+}
+
+func (this *concurrentHashMap) GetOrInclude(key, value interface{}) interface{} {
+  if this.obj != this {
+    return this.MutableMapDerived.GetOrInclude(key, value)
+  }
+  res, _ := this.table.PutIfAbsent(key, value)
+  return res
+}
+
+func (this *concurrentHashMap) ComputeIfPresent(key interface{}, f Remapping) (value interface{}, exists bool) {
+  if this.obj != this {
+    return this.MutableMapDerived.ComputeIfPresent(key, f)
+  }
+  value, exists = this.table.Compute(key, func (old interface{}, exists bool) (interface{}, bool) {
+    if exists {
+      return f(key, old, true)
+    }
+    return nil, false
+  })
+  if !exists {
+    return nil, false
+  }
+  return value, true
+}
+
+func (this *concurrentHashMap) Compute(key interface{}, f Remapping) (value interface{}, exists bool) {
+  if this.obj != this {
+    return this.MutableMapDerived.Compute(key, f)
+  }
+  value, exists = this.table.Compute(key, func (old interface{}, exists bool) (interface{}, bool) {
+    return f(key, old, exists)
+  })
+  if !exists {
+    return nil, false
+  }
+  return value, true
+}
+
+func (this *concurrentHashMap) Merge(key, value interface{}, combine Binop) interface{} {
+  if this.obj != this {
+    return this.MutableMapDerived.Merge(key, value, combine)
+  }
+  res, _ := this.table.Compute(key, func (old interface{}, exists bool) (interface{}, bool) {
+    if exists {
+      return combine(old, value), true
+    }
+    return value, true
+  })
+  return res
+}
+
+func (this *concurrentHashMap) Exclude(keys ...interface{}) {
+  for _, key := range keys {
+    this.table.Delete(key)
+  }
+}
+
+func (this *concurrentHashMap) Clear() {
+  this.table.Clear()
+}
+
+type concurrentHashMapIterator struct {
+  entryIter *impl.ConcurrentHashEntryIterator
+}
+
+func (this *concurrentHashMapIterator) HasNext() bool {
+  return this.entryIter.HasNext()
+}
+
+func (this *concurrentHashMapIterator) Next() interface{} {
+  entry := this.entryIter.Next()
+  return KV(entry.Key, entry.Value)
+}
@@ -0,0 +1,52 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "sync"
+import "testing"
+
+
+func TestConcurrentHashMapClass(t *testing.T) {
+  m := ConcurrentHashMap.New(KV(-1, 1)).(ConcurrentMap)
+  var wg sync.WaitGroup
+  calls := make([]int, 4)
+  for g := 0; g < 4; g++ {
+    wg.Add(1)
+    go func(g int) {
+      defer wg.Done()
+      for i := 0; i < 200; i++ {
+        m.ComputeIfAbsent(i, func (key interface{}) interface{} {
+          calls[g]++
+          return key.(int) * 2
+        })
+      }
+    }(g)
+  }
+  wg.Wait()
+  checkSize(t, m, 201, "m")
+  if calls[0] + calls[1] + calls[2] + calls[3] != 200 {
+    t.Errorf("Expected mapping function to be applied once per key")
+  }
+  if m.GetValue(21) != 42 {
+    t.Errorf("Expected 21 to be mapped to 42; was %v", m.GetValue(21))
+  }
+  if actual, loaded := m.PutIfAbsent(-1, 10); !loaded || actual != 1 {
+    t.Errorf("Expected PutIfAbsent to load 1; was %v", actual)
+  }
+  if actual, loaded := m.PutIfAbsent(-2, 2); loaded || actual != 2 {
+    t.Errorf("Expected PutIfAbsent to store 2; was %v", actual)
+  }
+  checkSize(t, m, 202, "m")
+}
@@ -100,6 +100,53 @@ func (this *extendedMap) Elements() Iterator {
                         this.base.Elements()))
 }
 
+// Map merging
+
+func newMergedMap(fst Map, snd Map, resolve Resolution) DependentMap {
+  res := new(mergedMap)
+  res.MapDerived = EmbeddedDependentMap(res)
+  res.fst = fst
+  res.snd = snd
+  res.resolve = resolve
+  return res
+}
+
+type mergedMap struct {
+  MapDerived
+  fst Map
+  snd Map
+  resolve Resolution
+}
+
+func (this *mergedMap) Size() int {
+  return CountElements(this.Elements())
+}
+
+func (this *mergedMap) Get(key interface{}) (value interface{}, exists bool) {
+  fst, inFst := this.fst.Get(key)
+  snd, inSnd := this.snd.Get(key)
+  switch {
+    case inFst && inSnd:
+      return this.resolve(key, fst, snd), true
+    case inFst:
+      return fst, true
+  }
+  return snd, inSnd
+}
+
+func (this *mergedMap) Elements() Iterator {
+  return NewCompositeIterator(
+      NewMappedIterator(func (x interface{}) interface{} {
+        entry := x.(MapEntry)
+        if snd, exists := this.snd.Get(entry.Key()); exists {
+          return KV(entry.Key(), this.resolve(entry.Key(), entry.Value(), snd))
+        }
+        return entry
+      }, this.fst.Elements()),
+      NewFilterIterator(KeyPredicate(Negate(this.fst.KeySet().Func())),
+                        this.snd.Elements()))
+}
+
 // Map value mapping
 
 func newValueMappedMap(mp Map, f Mapping) DependentMap {
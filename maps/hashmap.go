@@ -23,12 +23,25 @@ var HashMap MutableMapClass = HashMapClass(UniversalHash, UniversalEquality)
 var ImmutableHashMap MapClass = ImmutableMap(HashMap)
 
 func HashMapClass(hash Hashfunction, equals Equality) MutableMapClass {
-  return &hashMapClass{hash, equals}
+  return HashMapClassWithTable(hash, equals, impl.DefaultHashTable)
+}
+
+// HashMapClassWithTable returns a class of hash maps which are backed by hash
+// tables configured by table. With open addressing, maps do not allocate
+// memory per entry and shrink when entries get excluded.
+func HashMapClassWithTable(hash Hashfunction,
+                           equals Equality,
+                           table impl.HashTableConfig) MutableMapClass {
+  if table.OpenAddressing {
+    return &openHashMapClass{hash, equals, table}
+  }
+  return &hashMapClass{hash, equals, table}
 }
 
 type hashMapClass struct {
   hash Hashfunction
   equals Equality
+  table impl.HashTableConfig
 }
 
 func (this *hashMapClass) Embed(obj MutableMap) MutableMap {
@@ -37,8 +50,9 @@ func (this *hashMapClass) Embed(obj MutableMap) MutableMap {
     obj = res
   }
   res.obj = obj
-  res.MutableMapDerived = EmbeddedMutableMap(obj)
-  res.table = impl.NewHashTable(17, 80, this.hash, this.equals)
+  res.class = this
+  res.table = this.table.NewTable(this.hash, this.equals)
+  res.MutableMapDerived = embeddedHashTableMap(obj, res, res.table)
   return res
 }
 
@@ -67,6 +81,7 @@ type MutableHashMap interface {
 
 type hashMap struct {
   obj MutableMap
+  class *hashMapClass
   table *impl.HashTable
   MutableMapDerived
 }
@@ -87,7 +102,7 @@ func (this *hashMap) Elements() Iterator {
 }
 
 func (this *hashMap) Class() MutableMapClass {
-  return HashMapClass(this.table.Hash(), this.table.Equality())
+  return this.class
 }
 
 func (this *hashMap) Include(key, value interface{}) {
@@ -124,3 +139,81 @@ func (this *hashMapIterator) Next() interface{} {
 func (this *hashMap) Print() {
   this.table.Print()
 }
+
+// hashTable is implemented by hash tables which are able to update a mapping
+// with a single lookup.
+type hashTable interface {
+  Compute(key interface{},
+          f func (value interface{}, exists bool) (interface{}, bool)) (interface{}, bool)
+}
+
+// embeddedHashTableMap returns the derived methods of a map res which is
+// backed by table. If res is not embedded by another map, the compute and
+// merge operations are implemented with a single table lookup. Otherwise,
+// they are derived from the primitives of obj, such that embedding maps
+// observe all changes.
+func embeddedHashTableMap(obj MutableMap, res MutableMap, table hashTable) MutableMapDerived {
+  derived := EmbeddedMutableMap(obj)
+  if obj != res {
+    return derived
+  }
+  return &hashTableDerived{derived, table}
+}
+
+type hashTableDerived struct {
+  MutableMapDerived
+  table hashTable
+}
+
+func (this *hashTableDerived) GetOrInclude(key, value interface{}) interface{} {
+  res, _ := this.table.Compute(key, func (old interface{}, exists bool) (interface{}, bool) {
+    if exists {
+      return old, true
+    }
+    return value, true
+  })
+  return res
+}
+
+func (this *hashTableDerived) ComputeIfAbsent(key interface{}, f Mapping) interface{} {
+  res, _ := this.table.Compute(key, func (old interface{}, exists bool) (interface{}, bool) {
+    if exists {
+      return old, true
+    }
+    return f(key), true
+  })
+  return res
+}
+
+func (this *hashTableDerived) ComputeIfPresent(key interface{}, f Remapping) (value interface{}, exists bool) {
+  value, exists = this.table.Compute(key, func (old interface{}, exists bool) (interface{}, bool) {
+    if exists {
+      return f(key, old, true)
+    }
+    return nil, false
+  })
+  if !exists {
+    return nil, false
+  }
+  return value, true
+}
+
+func (this *hashTableDerived) Compute(key interface{}, f Remapping) (value interface{}, exists bool) {
+  value, exists = this.table.Compute(key, func (old interface{}, exists bool) (interface{}, bool) {
+    return f(key, old, exists)
+  })
+  if !exists {
+    return nil, false
+  }
+  return value, true
+}
+
+func (this *hashTableDerived) Merge(key, value interface{}, combine Binop) interface{} {
+  res, _ := this.table.Compute(key, func (old interface{}, exists bool) (interface{}, bool) {
+    if exists {
+      return combine(old, value), true
+    }
+    return value, true
+  })
+  return res
+}
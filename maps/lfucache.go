@@ -0,0 +1,237 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// LfuCache is a cache class which evicts the least frequently used entry.
+// Entries with the same access frequency are evicted in LRU order. All
+// operations take constant time: entries are kept in buckets, one for each
+// access frequency.
+var LfuCache CacheClass = LfuCacheClass(UniversalHash, UniversalEquality)
+
+func LfuCacheClass(hash Hashfunction, equals Equality) CacheClass {
+  return &lfuCacheClass{hash, equals, nil}
+}
+
+// WeightedLfuCacheClass returns a class of LFU caches whose capacity is a
+// maximum total weight. The weight of an entry is determined by weigher.
+func WeightedLfuCacheClass(hash Hashfunction,
+                           equals Equality,
+                           weigher Weigher) WeightedCacheClass {
+  return &lfuCacheClass{hash, equals, weigher}
+}
+
+type lfuCacheClass struct {
+  hash Hashfunction
+  equals Equality
+  weigher Weigher
+}
+
+func (this *lfuCacheClass) Embed(obj Cache, capacity int, we func (kv MapEntry)) Cache {
+  res := new(lfuCache)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.CacheDerived = EmbeddedCache(obj)
+  res.class = this
+  res.capacity = capacity
+  res.whenEvicted = we
+  res.table = impl.NewHashTable(17, 80, this.hash, this.equals)
+  res.buckets = make(map[int]*impl.DoubleLinkedList)
+  return res
+}
+
+func (this *lfuCacheClass) New(capacity int) Cache {
+  return this.Embed(nil, capacity, nil)
+}
+
+func (this *lfuCacheClass) NewWithCallback(capacity int, we func (kv MapEntry)) Cache {
+  return this.Embed(nil, capacity, we)
+}
+
+func (this *lfuCacheClass) NewWeighted(maxWeight int) WeightedCache {
+  return this.Embed(nil, maxWeight, nil).(*lfuCache)
+}
+
+func (this *lfuCacheClass) NewWeightedWithCallback(maxWeight int,
+                                                   we func (kv MapEntry)) WeightedCache {
+  return this.Embed(nil, maxWeight, we).(*lfuCache)
+}
+
+// lfuCache stores cacheNodes whose queue is the access frequency of the
+// entry. buckets maps every frequency to the list of nodes with this
+// frequency and minFreq is the smallest frequency of a node in the cache.
+// Without a weigher, every entry has weight 1, so that the capacity limits
+// the number of entries.
+type lfuCache struct {
+  obj Cache
+  class *lfuCacheClass
+  capacity int
+  weight int
+  whenEvicted func (kv MapEntry)
+  table *impl.HashTable
+  buckets map[int]*impl.DoubleLinkedList
+  minFreq int
+  cacheStats
+  CacheDerived
+}
+
+func (this *lfuCache) Size() int {
+  return this.table.Size()
+}
+
+func (this *lfuCache) Weight() int {
+  return this.weight
+}
+
+func (this *lfuCache) MaxWeight() int {
+  return this.capacity
+}
+
+func (this *lfuCache) Get(key interface{}) (value interface{}, exists bool) {
+  if entry := this.table.FindEntry(key); entry != nil {
+    node := entry.Value.(*cacheNode)
+    this.touch(node)
+    this.StatsCounter().RecordHit()
+    return node.value, true
+  }
+  this.StatsCounter().RecordMiss()
+  return nil, false
+}
+
+func (this *lfuCache) Elements() Iterator {
+  return &lfuCacheIterator{this.table.Iterator()}
+}
+
+func (this *lfuCache) Class() CacheClass {
+  return this.class
+}
+
+// Add maps key to value. Replacing a mapping counts as an access. The node
+// of key is taken out of its bucket while making room for the new weight, so
+// that it does not evict itself.
+func (this *lfuCache) Add(key, value interface{}) {
+  weight := weigh(this.class.weigher, key, value)
+  entry := this.table.FindEntry(key)
+  if weight > this.capacity {
+    if entry != nil {
+      this.evict(entry.Value.(*cacheNode))
+    }
+    this.StatsCounter().RecordEviction(EvictedBySize)
+    if this.whenEvicted != nil {
+      this.whenEvicted(KV(key, value))
+    }
+    return
+  }
+  var node *cacheNode
+  if entry == nil {
+    node = newCacheNode(key, value, 1)
+    this.table.AddEntry(key, node)
+  } else {
+    node = entry.Value.(*cacheNode)
+    this.unlink(node)
+    this.weight -= node.weight
+    node.value = value
+    node.queue++
+  }
+  for this.weight + weight > this.capacity {
+    for this.buckets[this.minFreq] == nil {
+      this.minFreq++
+    }
+    this.evict(this.buckets[this.minFreq].Back().Value.(*cacheNode))
+  }
+  node.weight = weight
+  this.weight += weight
+  this.link(node)
+  if node.queue < this.minFreq {
+    this.minFreq = node.queue
+  }
+}
+
+func (this *lfuCache) Remove(keys ...interface{}) {
+  for _, key := range keys {
+    if entry := this.table.FindEntry(key); entry != nil {
+      node := entry.Value.(*cacheNode)
+      this.unlink(node)
+      this.table.DeleteEntry(key)
+      this.weight -= node.weight
+    }
+  }
+}
+
+func (this *lfuCache) Clear() {
+  this.table.Clear()
+  this.buckets = make(map[int]*impl.DoubleLinkedList)
+  this.minFreq = 0
+  this.weight = 0
+}
+
+// evict removes node from this cache because the cache exceeds its capacity.
+func (this *lfuCache) evict(node *cacheNode) {
+  this.unlink(node)
+  this.table.DeleteEntry(node.key)
+  this.weight -= node.weight
+  this.StatsCounter().RecordEviction(EvictedBySize)
+  if this.whenEvicted != nil {
+    this.whenEvicted(node.entry())
+  }
+}
+
+// touch increments the access frequency of node and moves it to the
+// corresponding bucket.
+func (this *lfuCache) touch(node *cacheNode) {
+  freq := node.queue
+  this.unlink(node)
+  node.queue++
+  this.link(node)
+  if this.minFreq == freq && this.buckets[freq] == nil {
+    this.minFreq = node.queue
+  }
+}
+
+func (this *lfuCache) link(node *cacheNode) {
+  bucket := this.buckets[node.queue]
+  if bucket == nil {
+    bucket = impl.NewDoubleLinkedList()
+    this.buckets[node.queue] = bucket
+  }
+  bucket.InsertFront(node.elem)
+}
+
+// unlink removes node from its bucket. Empty buckets are dropped. minFreq
+// is not updated here; it may only be too small, which eviction tolerates.
+func (this *lfuCache) unlink(node *cacheNode) {
+  bucket := this.buckets[node.queue]
+  bucket.Remove(node.elem)
+  if bucket.Length() == 0 {
+    delete(this.buckets, node.queue)
+  }
+}
+
+type lfuCacheIterator struct {
+  hashEntryIter *impl.HashEntryIterator
+}
+
+func (this *lfuCacheIterator) HasNext() bool {
+  return this.hashEntryIter.HasNext()
+}
+
+func (this *lfuCacheIterator) Next() interface{} {
+  return this.hashEntryIter.Next().Value.(*cacheNode).entry()
+}
@@ -0,0 +1,147 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// LinkedHashMap is a hash map which iterates over its entries in the order in
+// which their keys were first included.
+var LinkedHashMap MutableMapClass = LinkedHashMapClass(UniversalHash, UniversalEquality, false)
+
+var ImmutableLinkedHashMap MapClass = ImmutableMap(LinkedHashMap)
+
+// LinkedHashMapClass returns a class of hash maps which iterate over their
+// entries in a well-defined order. If accessOrder is false, this is the order
+// in which keys were first included. If accessOrder is true, entries are
+// ordered from least recently to most recently accessed, where both Get and
+// Include count as an access, but HasKey does not. Since accesses reorder the
+// entries, iterators of such maps traverse a snapshot of the order.
+func LinkedHashMapClass(hash Hashfunction, equals Equality, accessOrder bool) MutableMapClass {
+  return &linkedHashMapClass{hash, equals, accessOrder}
+}
+
+type linkedHashMapClass struct {
+  hash Hashfunction
+  equals Equality
+  accessOrder bool
+}
+
+func (this *linkedHashMapClass) Embed(obj MutableMap) MutableMap {
+  res := new(linkedHashMap)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableMapDerived = EmbeddedMutableMap(obj)
+  res.table = impl.NewHashTable(17, 80, this.hash, this.equals)
+  res.order = impl.NewDoubleLinkedList()
+  res.accessOrder = this.accessOrder
+  return res
+}
+
+func (this *linkedHashMapClass) New(entries... MapEntry) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeEntry(entries...)
+  return res
+}
+
+func (this *linkedHashMapClass) From(coll Container) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+func (this *linkedHashMapClass) FromNative(mp map[interface{}] interface{}) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFromNative(mp)
+  return res
+}
+
+// linkedHashMap maps every key to an *Element of the order list whose value
+// is the MapEntry for this key.
+type linkedHashMap struct {
+  obj MutableMap
+  table *impl.HashTable
+  order *impl.DoubleLinkedList
+  accessOrder bool
+  MutableMapDerived
+}
+
+func (this *linkedHashMap) Size() int {
+  return this.table.Size()
+}
+
+func (this *linkedHashMap) Get(key interface{}) (value interface{}, exists bool) {
+  if entry := this.table.FindEntry(key); entry != nil {
+    elem := entry.Value.(*impl.Element)
+    this.accessed(elem)
+    return elem.Value.(MapEntry).Value(), true
+  }
+  return nil, false
+}
+
+func (this *linkedHashMap) HasKey(key interface{}) bool {
+  return this.table.FindEntry(key) != nil
+}
+
+func (this *linkedHashMap) accessed(elem *impl.Element) {
+  if this.accessOrder {
+    this.order.Remove(elem)
+    this.order.InsertBack(elem)
+  }
+}
+
+func (this *linkedHashMap) Elements() Iterator {
+  if !this.accessOrder {
+    return this.order.Iterator()
+  }
+  entries := make([]interface{}, 0, this.table.Size())
+  for iter := this.order.Iterator(); iter.HasNext(); {
+    entries = append(entries, iter.Next())
+  }
+  return Enum.New(entries...).Elements()
+}
+
+func (this *linkedHashMap) Class() MutableMapClass {
+  return LinkedHashMapClass(this.table.Hash(), this.table.Equality(), this.accessOrder)
+}
+
+func (this *linkedHashMap) Include(key, value interface{}) {
+  if entry := this.table.FindEntry(key); entry == nil {
+    elem := impl.NewElement(KV(key, value))
+    this.table.AddEntry(key, elem)
+    this.order.InsertBack(elem)
+  } else {
+    elem := entry.Value.(*impl.Element)
+    elem.Value = KV(elem.Value.(MapEntry).Key(), value)
+    this.accessed(elem)
+  }
+}
+
+func (this *linkedHashMap) Exclude(keys ...interface{}) {
+  for _, key := range keys {
+    if entry := this.table.FindEntry(key); entry != nil {
+      this.order.Remove(entry.Value.(*impl.Element))
+      this.table.DeleteEntry(key)
+    }
+  }
+}
+
+func (this *linkedHashMap) Clear() {
+  this.table.Clear()
+  this.order = impl.NewDoubleLinkedList()
+}
@@ -0,0 +1,61 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func checkKeyOrder(t *testing.T, m Map, expected ...interface{}) {
+  i := 0
+  for iter := m.Elements(); iter.HasNext(); i++ {
+    key := iter.Next().(MapEntry).Key()
+    if i >= len(expected) || key != expected[i] {
+      t.Fatalf("Unexpected key %v at position %d of map %v", key, i, m)
+    }
+  }
+  if i != len(expected) {
+    t.Fatalf("Expected %d keys; found %d", len(expected), i)
+  }
+}
+
+func TestLinkedHashMapClass(t *testing.T) {
+  m := LinkedHashMap.New(KV(3, "c"), KV(1, "a"), KV(2, "b"))
+  m.Include(1, "A")
+  m.Get(3)
+  checkKeyOrder(t, m, 3, 1, 2)
+  if m.GetValue(1) != "A" {
+    t.Errorf("Expected 1 to be mapped to A; was %v", m.GetValue(1))
+  }
+  m.Exclude(3)
+  m.Include(3, "C")
+  checkKeyOrder(t, m, 1, 2, 3)
+  m.Clear()
+  checkSize(t, m, 0, "m")
+}
+
+func TestAccessOrderedLinkedHashMap(t *testing.T) {
+  m := LinkedHashMapClass(UniversalHash, UniversalEquality, true).New(KV(3, "c"), KV(1, "a"), KV(2, "b"))
+  m.Get(3)
+  checkKeyOrder(t, m, 1, 2, 3)
+  m.Include(1, "A")
+  checkKeyOrder(t, m, 2, 3, 1)
+  m.Get(4)
+  checkKeyOrder(t, m, 2, 3, 1)
+  if !m.HasKey(2) {
+    t.Errorf("Expected key 2 in %v", m)
+  }
+  checkKeyOrder(t, m, 2, 3, 1)
+}
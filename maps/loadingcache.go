@@ -0,0 +1,391 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "errors"
+import "sync"
+import "time"
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// Loader computes the value of key for a LoadingCache.
+type Loader func (key interface{}) (interface{}, error)
+
+// BatchLoader computes the values of several keys at once for a LoadingCache.
+// It returns the values in the order of the given keys.
+type BatchLoader func (keys []interface{}) ([]interface{}, error)
+
+// ErrLoaderPanicked is returned to goroutines waiting for a load whose
+// loader panicked.
+var ErrLoaderPanicked = errors.New("LoadingCache: loader panicked")
+
+// ErrBatchSize is returned if a BatchLoader returns a different number of
+// values than it was given keys.
+var ErrBatchSize = errors.New("LoadingCache.GetAll: batch loader returned wrong number of values")
+
+// LoadingCache wraps a Cache and computes the values of missing keys with a
+// Loader. At most one load per key is in progress at any time: goroutines
+// requesting a key which is currently being loaded wait for the pending load
+// instead of starting another one. LoadingCache values are safe for
+// concurrent use; the wrapped cache must not be accessed directly while it
+// is used by a LoadingCache.
+type LoadingCache interface {
+  // Get returns the value of key, loading it if it is not cached. If the
+  // load fails, its error is returned.
+  Get(key interface{}) (interface{}, error)
+
+  // GetIfPresent returns the value of key if it is cached. It never loads
+  // values.
+  GetIfPresent(key interface{}) (value interface{}, exists bool)
+
+  // GetAll returns the values of the given keys. All missing keys are
+  // loaded with a single invocation of the batch loader, if there is one.
+  // If loading fails for some keys, the values of these keys are nil and
+  // the first error is returned.
+  GetAll(keys ...interface{}) ([]interface{}, error)
+
+  // Refresh reloads the value of key in the background and returns the
+  // currently cached value. Until the reload completes, the stale value
+  // keeps being returned. If reloading fails, the stale value is retained.
+  // A panic of the loader is recovered; goroutines waiting for the reload
+  // get ErrLoaderPanicked.
+  Refresh(key interface{}) (stale interface{}, exists bool)
+
+  // Put caches a mapping from key to value, overriding pending loads.
+  Put(key, value interface{})
+
+  // Invalidate removes the given keys from the cache and discards the
+  // results of pending loads for them.
+  Invalidate(keys ...interface{})
+
+  // Size returns the number of cached entries.
+  Size() int
+
+  // Cache returns the wrapped cache.
+  Cache() Cache
+
+  // Stats returns the statistics of the wrapped cache if it implements
+  // StatsRecorder. Loads are recorded in these statistics; every invocation
+  // of a batch loader counts as a single load.
+  Stats() CacheStats
+}
+
+// LoadingCacheClass defines factory methods for LoadingCache values.
+type LoadingCacheClass interface {
+  New(cache Cache, loader Loader) LoadingCache
+  NewWithBatchLoader(cache Cache, loader Loader, batchLoader BatchLoader) LoadingCache
+}
+
+// NewLoadingCacheClass returns a class of loading caches which use the given
+// hash function and equality to identify pending loads. These have to be
+// consistent with the wrapped caches. If cacheErrors is true, failed loads
+// are cached, so that the error is returned until the key is invalidated or
+// evicted. Otherwise, every request for the key retries loading it.
+func NewLoadingCacheClass(hash Hashfunction,
+                          equals Equality,
+                          cacheErrors bool) LoadingCacheClass {
+  return &loadingCacheClass{hash, equals, cacheErrors}
+}
+
+type loadingCacheClass struct {
+  hash Hashfunction
+  equals Equality
+  cacheErrors bool
+}
+
+func (this *loadingCacheClass) New(cache Cache, loader Loader) LoadingCache {
+  return this.NewWithBatchLoader(cache, loader, nil)
+}
+
+func (this *loadingCacheClass) NewWithBatchLoader(cache Cache,
+                                                  loader Loader,
+                                                  batchLoader BatchLoader) LoadingCache {
+  return &loadingCache{cache: cache,
+                       loader: loader,
+                       batchLoader: batchLoader,
+                       cacheErrors: this.cacheErrors,
+                       loads: impl.NewHashTable(17, 80, this.hash, this.equals)}
+}
+
+// loadFailure is stored in the wrapped cache for failed loads if errors
+// are cached.
+type loadFailure struct {
+  err error
+}
+
+// pendingLoad represents a load in progress. done is closed when the load
+// completes.
+type pendingLoad struct {
+  done chan bool
+  refresh bool
+  value interface{}
+  err error
+}
+
+type loadingCache struct {
+  mutex sync.Mutex
+  cache Cache
+  loader Loader
+  batchLoader BatchLoader
+  cacheErrors bool
+  loads *impl.HashTable
+}
+
+func (this *loadingCache) Cache() Cache {
+  return this.cache
+}
+
+func (this *loadingCache) Stats() CacheStats {
+  return this.statsCounter().Snapshot()
+}
+
+func (this *loadingCache) statsCounter() *StatsCounter {
+  if recorder, valid := this.cache.(StatsRecorder); valid {
+    return recorder.StatsCounter()
+  }
+  return nil
+}
+
+// recordLoad records a load which started at start and failed if err is
+// not nil.
+func (this *loadingCache) recordLoad(start time.Time, err error) {
+  if counter := this.statsCounter(); counter != nil {
+    if err == nil {
+      counter.RecordLoadSuccess(time.Since(start))
+    } else {
+      counter.RecordLoadFailure(time.Since(start))
+    }
+  }
+}
+
+func (this *loadingCache) Size() int {
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  return this.cache.Size()
+}
+
+func (this *loadingCache) Get(key interface{}) (interface{}, error) {
+  this.mutex.Lock()
+  if value, exists := this.cache.Get(key); exists {
+    this.mutex.Unlock()
+    return unwrapLoaded(value)
+  }
+  load, started := this.pending(key, false)
+  this.mutex.Unlock()
+  if started {
+    this.load(key, load)
+  }
+  <-load.done
+  return load.value, load.err
+}
+
+func (this *loadingCache) GetIfPresent(key interface{}) (value interface{}, exists bool) {
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  if value, exists = this.cache.Get(key); exists {
+    if _, failed := value.(*loadFailure); failed {
+      return nil, false
+    }
+  }
+  return value, exists
+}
+
+func (this *loadingCache) GetAll(keys ...interface{}) ([]interface{}, error) {
+  res := make([]interface{}, len(keys))
+  errs := make([]error, len(keys))
+  waiting := make(map[int]*pendingLoad)
+  var missingKeys []interface{}
+  var missingLoads []*pendingLoad
+  this.mutex.Lock()
+  for i, key := range keys {
+    if value, exists := this.cache.Get(key); exists {
+      res[i], errs[i] = unwrapLoaded(value)
+    } else {
+      load, started := this.pending(key, false)
+      if started {
+        missingKeys = append(missingKeys, key)
+        missingLoads = append(missingLoads, load)
+      }
+      waiting[i] = load
+    }
+  }
+  this.mutex.Unlock()
+  if this.batchLoader != nil && len(missingKeys) > 0 {
+    this.loadBatch(missingKeys, missingLoads)
+  } else {
+    this.loadEach(missingKeys, missingLoads)
+  }
+  for i, load := range waiting {
+    <-load.done
+    res[i], errs[i] = load.value, load.err
+  }
+  for _, err := range errs {
+    if err != nil {
+      return res, err
+    }
+  }
+  return res, nil
+}
+
+func (this *loadingCache) Refresh(key interface{}) (stale interface{}, exists bool) {
+  this.mutex.Lock()
+  if stale, exists = this.cache.Get(key); exists {
+    if _, failed := stale.(*loadFailure); failed {
+      stale, exists = nil, false
+    }
+  }
+  load, started := this.pending(key, true)
+  this.mutex.Unlock()
+  if started {
+    go this.reload(key, load)
+  }
+  return stale, exists
+}
+
+func (this *loadingCache) Put(key, value interface{}) {
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  this.loads.DeleteEntry(key)
+  this.cache.Add(key, value)
+}
+
+func (this *loadingCache) Invalidate(keys ...interface{}) {
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  for _, key := range keys {
+    this.loads.DeleteEntry(key)
+  }
+  this.cache.Remove(keys...)
+}
+
+// pending returns the pending load of key. If there is none, a new pending
+// load is registered and started is true; the caller is then responsible
+// for performing the load. pending must be called while holding the lock.
+func (this *loadingCache) pending(key interface{}, refresh bool) (load *pendingLoad, started bool) {
+  if entry := this.loads.FindEntry(key); entry != nil {
+    return entry.Value.(*pendingLoad), false
+  }
+  load = &pendingLoad{done: make(chan bool), refresh: refresh}
+  this.loads.AddEntry(key, load)
+  return load, true
+}
+
+// load invokes the loader for key and completes the pending load. If the
+// loader panics, waiting goroutines get ErrLoaderPanicked and the panic is
+// propagated.
+func (this *loadingCache) load(key interface{}, load *pendingLoad) {
+  start := time.Now()
+  completed := false
+  defer func () {
+    if !completed {
+      this.recordLoad(start, ErrLoaderPanicked)
+      this.complete(key, load, nil, ErrLoaderPanicked)
+    }
+  }()
+  value, err := this.loader(key)
+  completed = true
+  this.recordLoad(start, err)
+  this.complete(key, load, value, err)
+}
+
+// loadEach loads the given keys one after another. If the loader panics, the
+// pending loads of the remaining keys are abandoned before the panic is
+// propagated.
+func (this *loadingCache) loadEach(keys []interface{}, loads []*pendingLoad) {
+  next := 0
+  defer func () {
+    for ; next < len(keys); next++ {
+      this.abandon(keys[next], loads[next], ErrLoaderPanicked)
+    }
+  }()
+  for next < len(keys) {
+    key, load := keys[next], loads[next]
+    next++
+    this.load(key, load)
+  }
+}
+
+// reload performs a load started by Refresh in a background goroutine. A
+// panic of the loader is recovered, since there is nobody to propagate it to;
+// load reports it to waiting goroutines as ErrLoaderPanicked.
+func (this *loadingCache) reload(key interface{}, load *pendingLoad) {
+  defer func () {
+    recover()
+  }()
+  this.load(key, load)
+}
+
+func (this *loadingCache) loadBatch(keys []interface{}, loads []*pendingLoad) {
+  start := time.Now()
+  completed := false
+  defer func () {
+    if !completed {
+      this.recordLoad(start, ErrLoaderPanicked)
+      for i, key := range keys {
+        this.complete(key, loads[i], nil, ErrLoaderPanicked)
+      }
+    }
+  }()
+  values, err := this.batchLoader(keys)
+  completed = true
+  if err == nil && len(values) != len(keys) {
+    err = ErrBatchSize
+  }
+  this.recordLoad(start, err)
+  for i, key := range keys {
+    if err != nil {
+      this.complete(key, loads[i], nil, err)
+    } else {
+      this.complete(key, loads[i], values[i], nil)
+    }
+  }
+}
+
+// complete stores the result of a load in the cache, unless the load was
+// superseded by Put or Invalidate, and wakes up all waiting goroutines.
+func (this *loadingCache) complete(key interface{}, load *pendingLoad, value interface{}, err error) {
+  load.value, load.err = value, err
+  this.mutex.Lock()
+  if entry := this.loads.FindEntry(key); entry != nil && entry.Value == load {
+    this.loads.DeleteEntry(key)
+    if err == nil {
+      this.cache.Add(key, value)
+    } else if this.cacheErrors && !load.refresh {
+      this.cache.Add(key, &loadFailure{err})
+    }
+  }
+  this.mutex.Unlock()
+  close(load.done)
+}
+
+// abandon removes a pending load which was never performed without caching a
+// result, and wakes up all waiting goroutines with err.
+func (this *loadingCache) abandon(key interface{}, load *pendingLoad, err error) {
+  load.err = err
+  this.mutex.Lock()
+  if entry := this.loads.FindEntry(key); entry != nil && entry.Value == load {
+    this.loads.DeleteEntry(key)
+  }
+  this.mutex.Unlock()
+  close(load.done)
+}
+
+func unwrapLoaded(value interface{}) (interface{}, error) {
+  if failure, failed := value.(*loadFailure); failed {
+    return nil, failure.err
+  }
+  return value, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "errors"
+import "sync"
+import "sync/atomic"
+import "testing"
+import "time"
+import . "github.com/objecthub/containerkit"
+
+
+var loadingCaches = NewLoadingCacheClass(UniversalHash, UniversalEquality, false)
+
+func checkLoaded(t *testing.T, values []interface{}, expected ...interface{}) {
+  if len(values) != len(expected) {
+    t.Errorf("Expected values %v; got %v", expected, values)
+    return
+  }
+  for i, value := range values {
+    if value != expected[i] {
+      t.Errorf("Expected values %v; got %v", expected, values)
+      return
+    }
+  }
+}
+
+func TestLoadingCacheSingleFlight(t *testing.T) {
+  var calls int32
+  release := make(chan bool)
+  cache := loadingCaches.New(LruCache.New(16), func (key interface{}) (interface{}, error) {
+    atomic.AddInt32(&calls, 1)
+    <-release
+    return key.(int) * 10, nil
+  })
+  var group sync.WaitGroup
+  for i := 0; i < 20; i++ {
+    group.Add(1)
+    go func () {
+      defer group.Done()
+      if value, err := cache.Get(7); value != 70 || err != nil {
+        t.Errorf("Expected 70; got %v, %v", value, err)
+      }
+    }()
+  }
+  for {
+    if _, exists := cache.GetIfPresent(7); exists {
+      t.Fatalf("Value available before load completed")
+    }
+    if atomic.LoadInt32(&calls) > 0 {
+      break
+    }
+  }
+  close(release)
+  group.Wait()
+  if calls != 1 {
+    t.Errorf("Expected a single load; got %d", calls)
+  }
+  if value, exists := cache.GetIfPresent(7); value != 70 || !exists {
+    t.Errorf("Expected cached value 70; got %v, %v", value, exists)
+  }
+}
+
+func TestLoadingCacheErrors(t *testing.T) {
+  failure := errors.New("failure")
+  for _, cacheErrors := range []bool{false, true} {
+    calls := 0
+    class := NewLoadingCacheClass(UniversalHash, UniversalEquality, cacheErrors)
+    cache := class.New(LruCache.New(16), func (key interface{}) (interface{}, error) {
+      calls++
+      return nil, failure
+    })
+    for i := 0; i < 3; i++ {
+      if _, err := cache.Get("k"); err != failure {
+        t.Errorf("Expected failure; got %v", err)
+      }
+    }
+    if _, exists := cache.GetIfPresent("k"); exists {
+      t.Errorf("Failed load reported as present")
+    }
+    if expected := map[bool]int{false: 3, true: 1}[cacheErrors]; calls != expected {
+      t.Errorf("Expected %d loads with cacheErrors=%v; got %d", expected, cacheErrors, calls)
+    }
+    cache.Invalidate("k")
+    cache.Get("k")
+    if expected := map[bool]int{false: 4, true: 2}[cacheErrors]; calls != expected {
+      t.Errorf("Expected %d loads after invalidation; got %d", expected, calls)
+    }
+  }
+}
+
+func TestLoadingCacheGetAll(t *testing.T) {
+  var batches [][]interface{}
+  loader := func (key interface{}) (interface{}, error) {
+    return key.(int) * 10, nil
+  }
+  cache := loadingCaches.NewWithBatchLoader(LruCache.New(16), loader,
+      func (keys []interface{}) ([]interface{}, error) {
+        batches = append(batches, keys)
+        res := make([]interface{}, len(keys))
+        for i, key := range keys {
+          res[i] = key.(int) * 10
+        }
+        return res, nil
+      })
+  cache.Put(2, 200)
+  values, err := cache.GetAll(1, 2, 3, 1)
+  if err != nil {
+    t.Errorf("Unexpected error %v", err)
+  }
+  checkLoaded(t, values, 10, 200, 30, 10)
+  if len(batches) != 1 || len(batches[0]) != 2 {
+    t.Errorf("Expected one batch with keys 1 and 3; got %v", batches)
+  }
+  cache = loadingCaches.NewWithBatchLoader(LruCache.New(16), loader,
+      func (keys []interface{}) ([]interface{}, error) {
+        return nil, nil
+      })
+  if values, err = cache.GetAll(4, 5); err != ErrBatchSize || values[0] != nil {
+    t.Errorf("Expected ErrBatchSize; got %v, %v", values, err)
+  }
+  cache = loadingCaches.New(LruCache.New(16), loader)
+  values, _ = cache.GetAll(4, 5)
+  checkLoaded(t, values, 40, 50)
+}
+
+func TestLoadingCacheRefresh(t *testing.T) {
+  version := 0
+  release := make(chan bool)
+  cache := loadingCaches.New(LruCache.New(16), func (key interface{}) (interface{}, error) {
+    if version > 0 {
+      <-release
+    }
+    version++
+    return version, nil
+  })
+  cache.Get("k")
+  if stale, exists := cache.Refresh("k"); stale != 1 || !exists {
+    t.Errorf("Expected stale value 1; got %v, %v", stale, exists)
+  }
+  if value, _ := cache.Get("k"); value != 1 {
+    t.Errorf("Expected stale value while reloading; got %v", value)
+  }
+  release <- true
+  for {
+    if value, _ := cache.GetIfPresent("k"); value == 2 {
+      break
+    }
+  }
+}
+
+func TestLoadingCacheLoaderPanic(t *testing.T) {
+  failing := true
+  cache := loadingCaches.New(LruCache.New(16), func (key interface{}) (interface{}, error) {
+    if failing {
+      panic("loader failed")
+    }
+    return "v", nil
+  })
+  func () {
+    defer func () { recover() }()
+    cache.Get("k")
+    t.Errorf("Expected loader panic to propagate")
+  }()
+  failing = false
+  if value, err := cache.Get("k"); value != "v" || err != nil {
+    t.Errorf("Expected v; got %v, %v", value, err)
+  }
+}
+
+func TestLoadingCacheGetAllLoaderPanic(t *testing.T) {
+  cache := loadingCaches.New(LruCache.New(16), func (key interface{}) (interface{}, error) {
+    if key == 1 {
+      panic("loader failed")
+    }
+    return key.(int) * 10, nil
+  })
+  func () {
+    defer func () { recover() }()
+    cache.GetAll(1, 2)
+    t.Errorf("Expected loader panic to propagate")
+  }()
+  done := make(chan bool)
+  go func () {
+    if value, err := cache.Get(2); value != 20 || err != nil {
+      t.Errorf("Expected 20; got %v, %v", value, err)
+    }
+    close(done)
+  }()
+  select {
+    case <-done:
+    case <-time.After(5 * time.Second):
+      t.Fatalf("Get blocked on a load abandoned by GetAll")
+  }
+}
+
+func TestLoadingCacheRefreshLoaderPanic(t *testing.T) {
+  var calls int32
+  cache := loadingCaches.New(LruCache.New(16), func (key interface{}) (interface{}, error) {
+    if atomic.AddInt32(&calls, 1) == 1 {
+      panic("loader failed")
+    }
+    return "v", nil
+  })
+  if _, exists := cache.Refresh("k"); exists {
+    t.Errorf("Expected no stale value")
+  }
+  if value, err := cache.Get("k"); err != ErrLoaderPanicked && value != "v" {
+    t.Errorf("Expected ErrLoaderPanicked or v; got %v, %v", value, err)
+  }
+}
@@ -21,12 +21,21 @@ import "github.com/objecthub/containerkit/impl"
 var LruCache CacheClass = LruCacheClass(UniversalHash, UniversalEquality)
 
 func LruCacheClass(hash Hashfunction, equals Equality) CacheClass {
-  return &lruCacheClass{hash, equals}
+  return &lruCacheClass{hash, equals, nil}
+}
+
+// WeightedLruCacheClass returns a class of LRU caches whose capacity is a
+// maximum total weight. The weight of an entry is determined by weigher.
+func WeightedLruCacheClass(hash Hashfunction,
+                           equals Equality,
+                           weigher Weigher) WeightedCacheClass {
+  return &lruCacheClass{hash, equals, weigher}
 }
 
 type lruCacheClass struct {
   hash Hashfunction
   equals Equality
+  weigher Weigher
 }
 
 func (this *lruCacheClass) Embed(obj Cache, capacity int, we func (kv MapEntry)) Cache {
@@ -36,6 +45,7 @@ func (this *lruCacheClass) Embed(obj Cache, capacity int, we func (kv MapEntry))
   }
   res.obj = obj
   res.CacheDerived = EmbeddedCache(obj)
+  res.class = this
   res.capacity = capacity
   res.whenEvicted = we
   res.table = impl.NewHashTable(17, 80, this.hash, this.equals)
@@ -51,12 +61,27 @@ func (this *lruCacheClass) NewWithCallback(capacity int, we func (kv MapEntry))
   return this.Embed(nil, capacity, we)
 }
 
+func (this *lruCacheClass) NewWeighted(maxWeight int) WeightedCache {
+  return this.Embed(nil, maxWeight, nil).(*lruCache)
+}
+
+func (this *lruCacheClass) NewWeightedWithCallback(maxWeight int,
+                                                   we func (kv MapEntry)) WeightedCache {
+  return this.Embed(nil, maxWeight, we).(*lruCache)
+}
+
+// lruCache stores cacheNodes in the order of their last access. Without a
+// weigher, every entry has weight 1, so that the capacity limits the number
+// of entries.
 type lruCache struct {
   obj Cache
+  class *lruCacheClass
   capacity int
+  weight int
   whenEvicted func (kv MapEntry)
   table *impl.HashTable
   accessorder *impl.DoubleLinkedList
+  cacheStats
   CacheDerived
 }
 
@@ -64,52 +89,70 @@ func (this *lruCache) Size() int {
   return this.table.Size()
 }
 
+func (this *lruCache) Weight() int {
+  return this.weight
+}
+
+func (this *lruCache) MaxWeight() int {
+  return this.capacity
+}
+
 func (this *lruCache) Get(key interface{}) (value interface{}, exists bool) {
   if entry := this.table.FindEntry(key); entry != nil {
-    elem := entry.Value.(*impl.Element)
-    this.accessorder.Remove(elem)
-    this.accessorder.InsertFront(elem)
-    return elem.Value.(MapEntry).Value(), true
+    node := entry.Value.(*cacheNode)
+    this.accessorder.Remove(node.elem)
+    this.accessorder.InsertFront(node.elem)
+    this.StatsCounter().RecordHit()
+    return node.value, true
   }
+  this.StatsCounter().RecordMiss()
   return nil, false
 }
 
 func (this *lruCache) Elements() Iterator {
-  return &lruCacheIterator{this.table.Iterator()}
+  return newCacheQueueIterator(this.accessorder)
 }
 
 func (this *lruCache) Class() CacheClass {
-  return LruCacheClass(this.table.Hash(), this.table.Equality())
+  return this.class
 }
 
 func (this *lruCache) Add(key, value interface{}) {
-  kv := KV(key, value)
-  if entry := this.table.FindEntry(key); entry == nil {
-    elem := impl.NewElement(kv)
-    this.table.AddEntry(key, elem)
-    this.accessorder.InsertFront(elem)
-  } else {
-    elem := entry.Value.(*impl.Element)
-    elem.Value = kv
-    this.accessorder.Remove(elem)
-    this.accessorder.InsertFront(elem)
-  }
-  if this.table.Size() > this.capacity {
-    elem := this.accessorder.Back()
-    this.accessorder.Remove(elem)
-    this.table.DeleteEntry(elem.Value.(MapEntry).Key())
+  weight := weigh(this.class.weigher, key, value)
+  entry := this.table.FindEntry(key)
+  if weight > this.capacity {
+    if entry != nil {
+      this.evict(entry.Value.(*cacheNode))
+    }
+    this.StatsCounter().RecordEviction(EvictedBySize)
     if this.whenEvicted != nil {
-      this.whenEvicted(elem.Value.(MapEntry))
+      this.whenEvicted(KV(key, value))
     }
+    return
+  }
+  if entry == nil {
+    node := newCacheNode(key, value, 0)
+    node.weight = weight
+    this.table.AddEntry(key, node)
+    this.accessorder.InsertFront(node.elem)
+  } else {
+    node := entry.Value.(*cacheNode)
+    this.weight -= node.weight
+    node.value = value
+    node.weight = weight
+    this.accessorder.Remove(node.elem)
+    this.accessorder.InsertFront(node.elem)
+  }
+  this.weight += weight
+  for this.weight > this.capacity {
+    this.evict(this.accessorder.Back().Value.(*cacheNode))
   }
 }
 
 func (this *lruCache) Remove(keys ...interface{}) {
   for _, key := range keys {
     if entry := this.table.FindEntry(key); entry != nil {
-      elem := entry.Value.(*impl.Element)
-      this.accessorder.Remove(elem)
-      this.table.DeleteEntry(key)
+      this.unlink(entry.Value.(*cacheNode))
     }
   }
 }
@@ -117,17 +160,20 @@ func (this *lruCache) Remove(keys ...interface{}) {
 func (this *lruCache) Clear() {
   this.table.Clear()
   this.accessorder = impl.NewDoubleLinkedList()
+  this.weight = 0
 }
 
-type lruCacheIterator struct {
-  hashEntryIter *impl.HashEntryIterator
-}
-
-func (this *lruCacheIterator) HasNext() bool {
-  return this.hashEntryIter.HasNext()
+// evict removes node from this cache because the cache exceeds its capacity.
+func (this *lruCache) evict(node *cacheNode) {
+  this.unlink(node)
+  this.StatsCounter().RecordEviction(EvictedBySize)
+  if this.whenEvicted != nil {
+    this.whenEvicted(node.entry())
+  }
 }
 
-func (this *lruCacheIterator) Next() interface{} {
-  entry := this.hashEntryIter.Next()
-  return KV(entry.Key, entry.Value)
+func (this *lruCache) unlink(node *cacheNode) {
+  this.accessorder.Remove(node.elem)
+  this.table.DeleteEntry(node.key)
+  this.weight -= node.weight
 }
@@ -103,3 +103,48 @@ func TestLruCacheClass(t *testing.T) {
   }
 }
 
+func TestWeightedLruCacheClass(t *testing.T) {
+  var evicted []MapEntry
+  weigher := func (kv MapEntry) int {
+    return len(kv.Value().(string))
+  }
+  cache := WeightedLruCacheClass(UniversalHash, UniversalEquality, weigher).
+      NewWeightedWithCallback(10, func (kv MapEntry) { evicted = append(evicted, kv) })
+  recorder := cache.(StatsRecorder)
+  recorder.RecordStats(true)
+  cache.Add("a", "xxx")
+  cache.Add("b", "xxx")
+  cache.Add("c", "xx")
+  if cache.Weight() != 8 || cache.MaxWeight() != 10 {
+    t.Errorf("Expected weight 8 of 10; got %d of %d", cache.Weight(), cache.MaxWeight())
+  }
+  cache.Get("a")
+  cache.Add("d", "xxxxxxx")
+  checkCacheEntry(t, cache, "b", nil, 2)
+  checkCacheEntry(t, cache, "c", nil, 2)
+  checkCacheEntry(t, cache, "a", "xxx", 2)
+  if cache.Weight() != 10 || len(evicted) != 2 {
+    t.Errorf("Expected weight 10 after 2 evictions; got %d after %d", cache.Weight(), len(evicted))
+  }
+  cache.Add("d", "x")
+  checkCacheEntry(t, cache, "d", "x", 2)
+  if cache.Weight() != 4 {
+    t.Errorf("Expected weight 4 after replacing d; got %d", cache.Weight())
+  }
+  cache.Add("a", "xxxxxxxxxxx")
+  checkCacheEntry(t, cache, "a", nil, 1)
+  if cache.Weight() != 1 || len(evicted) != 4 ||
+     evicted[2].Value() != "xxx" || evicted[3].Value() != "xxxxxxxxxxx" {
+    t.Errorf("Expected eviction of previous and overweight entry; got weight %d and evictions %v",
+             cache.Weight(), evicted)
+  }
+  if stats := recorder.Stats(); stats.Evictions[EvictedBySize] != 4 {
+    t.Errorf("Expected 4 evictions in stats; got %d", stats.Evictions[EvictedBySize])
+  }
+  cache.Add("e", "")
+  cache.Remove("d")
+  checkCacheEntry(t, cache, "e", "", 1)
+  if cache.Weight() != 0 {
+    t.Errorf("Expected weight 0; got %d", cache.Weight())
+  }
+}
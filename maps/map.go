@@ -37,8 +37,21 @@ type MapDerived interface {
   RestrictTo(domain Set) DependentMap
   MapValues(f Mapping) DependentMap
   Override(base Map) DependentMap
+
+  // Diff returns the differences between this map and other, considering
+  // this map to be the old and other the new map. Values are compared with
+  // equals; if equals is nil, UniversalEquality is used.
+  Diff(other Map, equals Equality) *MapDiff
+
+  // MergeWith returns a live view of the union of this map and other. Keys
+  // mapped by both maps are mapped to resolve(key, a, b), where a is the
+  // value of this map and b is the value of other.
+  MergeWith(other Map, resolve Resolution) DependentMap
 }
 
+// Resolution combines the values a and b that two maps associate with key.
+type Resolution func (key, a, b interface{}) interface{}
+
 // MapClass defines the functionality of Map implementations,
 // ie. records that act as Map factories, providing an Embed, New,
 // and From method.
@@ -80,6 +93,31 @@ func (this *mapTrait) Override(base Map) DependentMap {
   return newExtendedMap(base, this.obj)
 }
 
+func (this *mapTrait) Diff(other Map, equals Equality) *MapDiff {
+  if equals == nil {
+    equals = UniversalEquality
+  }
+  res := new(MapDiff)
+  for iter := this.obj.Elements(); iter.HasNext(); {
+    entry := iter.Next().(MapEntry)
+    if value, exists := other.Get(entry.Key()); !exists {
+      res.Removed = append(res.Removed, entry)
+    } else if !equals(entry.Value(), value) {
+      res.Changed = append(res.Changed, MapChange{entry.Key(), entry.Value(), value})
+    }
+  }
+  for iter := other.Elements(); iter.HasNext(); {
+    if entry := iter.Next().(MapEntry); !this.obj.HasKey(entry.Key()) {
+      res.Added = append(res.Added, entry)
+    }
+  }
+  return res
+}
+
+func (this *mapTrait) MergeWith(other Map, resolve Resolution) DependentMap {
+  return newMergedMap(this.obj, other, resolve)
+}
+
 func (this *mapTrait) KeySet() DependentSet {
   res := new(keySet)
   res.SetDerived = EmbeddedDependentSet(res)
@@ -0,0 +1,65 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "github.com/objecthub/containerkit/util"
+
+
+// MapDiff describes the differences between an old and a new map, as
+// computed by Map.Diff. It can be replayed on a mutable map via Apply.
+type MapDiff struct {
+  // Added contains the entries of the new map whose keys are not mapped by
+  // the old map.
+  Added []MapEntry
+
+  // Removed contains the entries of the old map whose keys are not mapped by
+  // the new map.
+  Removed []MapEntry
+
+  // Changed contains the keys that are mapped to different values.
+  Changed []MapChange
+}
+
+// MapChange describes that Key was mapped to Old and is mapped to New.
+type MapChange struct {
+  Key interface{}
+  Old interface{}
+  New interface{}
+}
+
+// IsEmpty returns true if the old and the new map are equal.
+func (this *MapDiff) IsEmpty() bool {
+  return len(this.Added) == 0 && len(this.Removed) == 0 && len(this.Changed) == 0
+}
+
+// Size returns the number of keys in which the two maps differ.
+func (this *MapDiff) Size() int {
+  return len(this.Added) + len(this.Removed) + len(this.Changed)
+}
+
+func (this *MapDiff) String() string {
+  builder := util.NewStringBuilder()
+  for _, entry := range this.Added {
+    builder.AppendStr("+" + util.ToString(entry.Key()) + ": " + util.ToString(entry.Value()))
+  }
+  for _, entry := range this.Removed {
+    builder.AppendStr("-" + util.ToString(entry.Key()) + ": " + util.ToString(entry.Value()))
+  }
+  for _, change := range this.Changed {
+    builder.AppendStr("~" + util.ToString(change.Key) + ": " + util.ToString(change.Old) +
+                      " -> " + util.ToString(change.New))
+  }
+  return "{" + builder.Join(", ") + "}"
+}
@@ -0,0 +1,84 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "strings"
+import "testing"
+
+
+func TestMapDiff(t *testing.T) {
+  old := HashMap.New(KV("a", "1"), KV("b", "2"), KV("c", "x"), KV("d", "4"))
+  updated := HashMap.New(KV("a", "1"), KV("c", "X"), KV("d", "5"), KV("e", "6"))
+  diff := old.Diff(updated, nil)
+  if len(diff.Added) != 1 || diff.Added[0].Key() != "e" || diff.Added[0].Value() != "6" {
+    t.Errorf("Expected e to be added; got %s", diff)
+  }
+  if len(diff.Removed) != 1 || diff.Removed[0].Key() != "b" || diff.Removed[0].Value() != "2" {
+    t.Errorf("Expected b to be removed; got %s", diff)
+  }
+  if len(diff.Changed) != 2 || diff.Size() != 4 {
+    t.Errorf("Expected c and d to be changed; got %s", diff)
+  }
+  for _, change := range diff.Changed {
+    if change.Old != old.GetValue(change.Key) || change.New != updated.GetValue(change.Key) {
+      t.Errorf("Unexpected change %v", change)
+    }
+  }
+  ignoreCase := func (x, y interface{}) bool {
+    return strings.EqualFold(x.(string), y.(string))
+  }
+  if diff := old.Diff(updated, ignoreCase); len(diff.Changed) != 1 || diff.Changed[0].Key != "d" {
+    t.Errorf("Expected only d to be changed when ignoring case; got %s", diff)
+  }
+  if diff := old.Diff(old.Copy(), nil); !diff.IsEmpty() || diff.String() != "{}" {
+    t.Errorf("Expected no differences to a copy; got %s", diff)
+  }
+  old.Apply(diff)
+  if diff := old.Diff(updated, nil); !diff.IsEmpty() {
+    t.Errorf("Expected no differences after applying the diff; got %s", diff)
+  }
+}
+
+func TestMapMergeWith(t *testing.T) {
+  fst := HashMap.New(KV(1, 10), KV(2, 20))
+  snd := HashMap.New(KV(2, 2), KV(3, 30))
+  merged := fst.MergeWith(snd, func (key, a, b interface{}) interface{} {
+    return a.(int) + b.(int)
+  })
+  checkMergedMap := func (expected map[int]int) {
+    checkMapSize(t, merged, len(expected), "merged")
+    for key, value := range expected {
+      if merged.GetValue(key) != value {
+        t.Errorf("Expected merged map to map %d to %d; got %v", key, value, merged.GetValue(key))
+      }
+    }
+    for iter := merged.Elements(); iter.HasNext(); {
+      entry := iter.Next().(MapEntry)
+      if expected[entry.Key().(int)] != entry.Value() {
+        t.Errorf("Unexpected entry %v in merged map", entry)
+      }
+    }
+  }
+  checkMergedMap(map[int]int{1: 10, 2: 22, 3: 30})
+  if merged.HasKey(4) {
+    t.Errorf("Merged map unexpectedly has key 4")
+  }
+  snd.Include(1, 1)
+  fst.Exclude(2)
+  checkMergedMap(map[int]int{1: 11, 2: 2, 3: 30})
+  if s := merged.String(); !strings.HasPrefix(s, "<") {
+    t.Errorf("Expected merged map to be a dependent map; got %s", s)
+  }
+}
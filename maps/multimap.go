@@ -0,0 +1,452 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/sets"
+import . "github.com/objecthub/containerkit/sequences"
+import "github.com/objecthub/containerkit/util"
+
+
+// Multimap associates keys with collections of values. A key is contained in
+// a multimap as long as it is associated with at least one value. The size of
+// a multimap is the number of key/value pairs, not the number of keys.
+//
+// Depending on the kind of multimap, the values of a key are provided either
+// as a set (SetMultimap) or as a sequence (ListMultimap) via method Get.
+type Multimap interface {
+
+  // Size returns the number of key/value pairs of this multimap.
+  Size() int
+
+  // IsEmpty returns true if this multimap does not contain any key.
+  IsEmpty() bool
+
+  // HasKey returns true if key is associated with at least one value.
+  HasKey(key interface{}) bool
+
+  // HasEntry returns true if key is associated with value.
+  HasEntry(key, value interface{}) bool
+
+  // Count returns the number of values key is associated with.
+  Count(key interface{}) int
+
+  // Put associates key with value. It returns false if the multimap did not
+  // change, e.g. because a set multimap associates key with value already.
+  Put(key, value interface{}) bool
+
+  // PutAll associates key with all values contained in values.
+  PutAll(key interface{}, values Container)
+
+  // Remove removes one association of key with value. It returns false if
+  // there was no such association.
+  Remove(key, value interface{}) bool
+
+  // RemoveAll removes all values associated with key and returns the number
+  // of removed values.
+  RemoveAll(key interface{}) int
+
+  // Clear removes all keys and values.
+  Clear()
+
+  // KeySet returns a live view of the keys of this multimap.
+  KeySet() DependentSet
+
+  // Entries returns a live container of MapEntry values, one for every
+  // key/value pair of this multimap.
+  Entries() Container
+
+  // InverseInto associates every value of this multimap with its keys in
+  // target and returns target.
+  InverseInto(target Multimap) Multimap
+
+  String() string
+}
+
+// SetMultimap is a Multimap which associates a key with a set of values; a
+// key can be associated with the same value only once.
+type SetMultimap interface {
+  Multimap
+
+  // Get returns a live view of the values associated with key.
+  Get(key interface{}) DependentSet
+
+  // Inverse returns a new set multimap of class which associates every value
+  // of this multimap with its keys. Since values become keys, class defines
+  // how values are hashed or compared in the inverse.
+  Inverse(class SetMultimapClass) SetMultimap
+}
+
+// ListMultimap is a Multimap which associates a key with a sequence of values
+// in the order in which they were put. A key can be associated with the same
+// value multiple times. Values are compared with UniversalEquality.
+type ListMultimap interface {
+  Multimap
+
+  // Get returns a live view of the values associated with key.
+  Get(key interface{}) DependentSequence
+
+  // Inverse returns a new list multimap of class which associates every value
+  // of this multimap with its keys. Since values become keys, class defines
+  // how values are hashed or compared in the inverse.
+  Inverse(class ListMultimapClass) ListMultimap
+}
+
+type SetMultimapClass interface {
+  New(entries... MapEntry) SetMultimap
+  From(coll Container) SetMultimap
+}
+
+type ListMultimapClass interface {
+  New(entries... MapEntry) ListMultimap
+  From(coll Container) ListMultimap
+}
+
+// HashSetMultimap creates set multimaps which store their keys in a HashMap
+// and the values of every key in a HashSet.
+var HashSetMultimap SetMultimapClass = NewSetMultimapClass(HashMap, HashSet)
+
+// ArrayListMultimap creates list multimaps which store their keys in a HashMap
+// and the values of every key in an ArraySequence.
+var ArrayListMultimap ListMultimapClass = NewListMultimapClass(HashMap, ArraySequence)
+
+// NewSetMultimapClass returns a class of set multimaps which map keys with
+// maps created by keys to sets of values created by values.
+func NewSetMultimapClass(keys MutableMapClass, values MutableSetClass) SetMultimapClass {
+  return &setMultimapClass{keys, values}
+}
+
+// NewListMultimapClass returns a class of list multimaps which map keys with
+// maps created by keys to sequences of values created by values.
+func NewListMultimapClass(keys MutableMapClass, values MutableSequenceClass) ListMultimapClass {
+  return &listMultimapClass{keys, values}
+}
+
+type setMultimapClass struct {
+  keys MutableMapClass
+  values MutableSetClass
+}
+
+func (this *setMultimapClass) New(entries... MapEntry) SetMultimap {
+  res := &setMultimap{}
+  res.multimap = newMultimap(res, this.keys.New(), func () multimapBucket {
+    return &setBucket{this.values.New()}
+  })
+  for _, entry := range entries {
+    res.Put(entry.Key(), entry.Value())
+  }
+  return res
+}
+
+func (this *setMultimapClass) From(coll Container) SetMultimap {
+  res := this.New()
+  coll.ForEach(func (entry interface{}) {
+    res.Put(entry.(MapEntry).Key(), entry.(MapEntry).Value())
+  })
+  return res
+}
+
+type listMultimapClass struct {
+  keys MutableMapClass
+  values MutableSequenceClass
+}
+
+func (this *listMultimapClass) New(entries... MapEntry) ListMultimap {
+  res := &listMultimap{}
+  res.multimap = newMultimap(res, this.keys.New(), func () multimapBucket {
+    return &listBucket{this.values.New()}
+  })
+  for _, entry := range entries {
+    res.Put(entry.Key(), entry.Value())
+  }
+  return res
+}
+
+func (this *listMultimapClass) From(coll Container) ListMultimap {
+  res := this.New()
+  coll.ForEach(func (entry interface{}) {
+    res.Put(entry.(MapEntry).Key(), entry.(MapEntry).Value())
+  })
+  return res
+}
+
+// multimapBucket abstracts over the collection of values of a single key.
+type multimapBucket interface {
+  Size() int
+  Contains(value interface{}) bool
+  Elements() Iterator
+  add(value interface{}) bool
+  remove(value interface{}) bool
+}
+
+type setBucket struct {
+  values MutableSet
+}
+
+func (this *setBucket) Size() int {
+  return this.values.Size()
+}
+
+func (this *setBucket) Contains(value interface{}) bool {
+  return this.values.Contains(value)
+}
+
+func (this *setBucket) Elements() Iterator {
+  return this.values.Elements()
+}
+
+func (this *setBucket) add(value interface{}) bool {
+  if this.values.Contains(value) {
+    return false
+  }
+  this.values.Include(value)
+  return true
+}
+
+func (this *setBucket) remove(value interface{}) bool {
+  if !this.values.Contains(value) {
+    return false
+  }
+  this.values.Exclude(value)
+  return true
+}
+
+type listBucket struct {
+  values MutableSequence
+}
+
+func (this *listBucket) Size() int {
+  return this.values.Size()
+}
+
+func (this *listBucket) indexOf(value interface{}) int {
+  return this.values.NextIndex(0, func (elem interface{}) bool {
+    return UniversalEquality(elem, value)
+  })
+}
+
+func (this *listBucket) Contains(value interface{}) bool {
+  return this.indexOf(value) >= 0
+}
+
+func (this *listBucket) Elements() Iterator {
+  return this.values.Elements()
+}
+
+func (this *listBucket) add(value interface{}) bool {
+  this.values.Append(value)
+  return true
+}
+
+func (this *listBucket) remove(value interface{}) bool {
+  if i := this.indexOf(value); i >= 0 {
+    this.values.Delete(i, 1)
+    return true
+  }
+  return false
+}
+
+// multimap implements the functionality shared by all multimaps. Keys are
+// mapped to multimapBucket values; empty buckets are removed eagerly.
+type multimap struct {
+  obj Multimap
+  keys MutableMap
+  size int
+  newBucket func () multimapBucket
+}
+
+func newMultimap(obj Multimap, keys MutableMap, newBucket func () multimapBucket) multimap {
+  return multimap{obj, keys, 0, newBucket}
+}
+
+func (this *multimap) bucket(key interface{}) multimapBucket {
+  if bucket, exists := this.keys.Get(key); exists {
+    return bucket.(multimapBucket)
+  }
+  return nil
+}
+
+func (this *multimap) Size() int {
+  return this.size
+}
+
+func (this *multimap) IsEmpty() bool {
+  return this.size == 0
+}
+
+func (this *multimap) HasKey(key interface{}) bool {
+  return this.keys.HasKey(key)
+}
+
+func (this *multimap) HasEntry(key, value interface{}) bool {
+  bucket := this.bucket(key)
+  return bucket != nil && bucket.Contains(value)
+}
+
+func (this *multimap) Count(key interface{}) int {
+  if bucket := this.bucket(key); bucket != nil {
+    return bucket.Size()
+  }
+  return 0
+}
+
+func (this *multimap) Put(key, value interface{}) bool {
+  bucket := this.bucket(key)
+  if bucket == nil {
+    bucket = this.newBucket()
+    this.keys.Include(key, bucket)
+  }
+  if bucket.add(value) {
+    this.size++
+    return true
+  }
+  return false
+}
+
+func (this *multimap) PutAll(key interface{}, values Container) {
+  for iter := values.Elements(); iter.HasNext(); {
+    this.obj.Put(key, iter.Next())
+  }
+}
+
+func (this *multimap) Remove(key, value interface{}) bool {
+  if bucket := this.bucket(key); bucket != nil && bucket.remove(value) {
+    this.size--
+    if bucket.Size() == 0 {
+      this.keys.Exclude(key)
+    }
+    return true
+  }
+  return false
+}
+
+func (this *multimap) RemoveAll(key interface{}) int {
+  if bucket := this.bucket(key); bucket != nil {
+    n := bucket.Size()
+    this.keys.Exclude(key)
+    this.size -= n
+    return n
+  }
+  return 0
+}
+
+func (this *multimap) Clear() {
+  this.keys.Clear()
+  this.size = 0
+}
+
+func (this *multimap) KeySet() DependentSet {
+  return this.keys.KeySet()
+}
+
+func (this *multimap) Entries() Container {
+  return this.keys.FlatMap(func (entry interface{}) Iterator {
+    key := entry.(MapEntry).Key()
+    return NewMappedIterator(func (value interface{}) interface{} {
+      return KV(key, value)
+    }, entry.(MapEntry).Value().(multimapBucket).Elements())
+  })
+}
+
+func (this *multimap) InverseInto(target Multimap) Multimap {
+  for iter := this.Entries().Elements(); iter.HasNext(); {
+    entry := iter.Next().(MapEntry)
+    target.Put(entry.Value(), entry.Key())
+  }
+  return target
+}
+
+func (this *multimap) String() string {
+  builder := util.NewStringBuilder()
+  for iter := this.Entries().Elements(); iter.HasNext(); {
+    builder.Append(iter.Next())
+  }
+  return "{" + builder.Join(", ") + "}"
+}
+
+type setMultimap struct {
+  multimap
+}
+
+func (this *setMultimap) Get(key interface{}) DependentSet {
+  res := &multimapValueSet{multimap: &this.multimap, key: key}
+  res.SetDerived = EmbeddedDependentSet(res)
+  return res
+}
+
+func (this *setMultimap) Inverse(class SetMultimapClass) SetMultimap {
+  res := class.New()
+  this.InverseInto(res)
+  return res
+}
+
+type listMultimap struct {
+  multimap
+}
+
+func (this *listMultimap) Get(key interface{}) DependentSequence {
+  res := &multimapValueSequence{multimap: &this.multimap, key: key}
+  res.SequenceDerived = EmbeddedDependentSequence(res)
+  return res
+}
+
+func (this *listMultimap) Inverse(class ListMultimapClass) ListMultimap {
+  res := class.New()
+  this.InverseInto(res)
+  return res
+}
+
+// multimapValueSet is a live view of the values associated with a key of a
+// set multimap. It looks up the bucket of the key on every access, such that
+// it also reflects values put after the view was created.
+type multimapValueSet struct {
+  multimap *multimap
+  key interface{}
+  SetDerived
+}
+
+func (this *multimapValueSet) Size() int {
+  return this.multimap.Count(this.key)
+}
+
+func (this *multimapValueSet) Contains(value interface{}) bool {
+  return this.multimap.HasEntry(this.key, value)
+}
+
+func (this *multimapValueSet) Elements() Iterator {
+  if bucket := this.multimap.bucket(this.key); bucket != nil {
+    return bucket.Elements()
+  }
+  return Enum.Empty().Elements()
+}
+
+// multimapValueSequence is a live view of the values associated with a key of
+// a list multimap.
+type multimapValueSequence struct {
+  multimap *multimap
+  key interface{}
+  SequenceDerived
+}
+
+func (this *multimapValueSequence) Size() int {
+  return this.multimap.Count(this.key)
+}
+
+func (this *multimapValueSequence) At(index int) interface{} {
+  if bucket := this.multimap.bucket(this.key); bucket != nil {
+    return bucket.(*listBucket).values.At(index)
+  }
+  panic("multimapValueSequence.At: index out of bounds")
+}
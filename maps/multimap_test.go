@@ -0,0 +1,106 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "strings"
+import "testing"
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/sets"
+import . "github.com/objecthub/containerkit/sequences"
+
+
+func TestSetMultimapClass(t *testing.T) {
+  mm := HashSetMultimap.New(KV("a", 1), KV("a", 2), KV("b", 1))
+  view := mm.Get("c")
+  if mm.Put("a", 1) || !mm.Put("c", 3) || mm.Size() != 4 {
+    t.Errorf("Put does not respect set semantics")
+  }
+  if view.Size() != 1 || !view.Contains(3) {
+    t.Errorf("Expected live view of values of c; was %v", view)
+  }
+  mm.PutAll("c", HashSet.New(3, 4, 5))
+  if mm.Count("c") != 3 || mm.Size() != 6 || !mm.HasEntry("c", 5) {
+    t.Errorf("PutAll does not add values")
+  }
+  if !mm.Remove("b", 1) || mm.Remove("b", 1) || mm.HasKey("b") {
+    t.Errorf("Expected key to disappear after removing its last value")
+  }
+  if mm.RemoveAll("c") != 3 || view.Size() != 0 || mm.Size() != 2 {
+    t.Errorf("RemoveAll does not remove all values")
+  }
+  if !mm.KeySet().Contains("a") || mm.KeySet().Size() != 1 || CountElements(mm.Entries().Elements()) != 2 {
+    t.Errorf("Unexpected keys or entries of %v", mm)
+  }
+  inverse := mm.Inverse(NewSetMultimapClass(TreeMap, HashSet))
+  if !inverse.HasEntry(1, "a") || !inverse.HasEntry(2, "a") || inverse.Size() != 2 {
+    t.Errorf("Unexpected inverse %v", inverse)
+  }
+  mm.Clear()
+  if !mm.IsEmpty() || mm.HasKey("a") {
+    t.Errorf("Expected multimap to be empty after Clear")
+  }
+}
+
+func TestListMultimapClass(t *testing.T) {
+  mm := NewListMultimapClass(TreeMap, ListSequence).New(KV(2, "x"), KV(1, "y"), KV(2, "x"))
+  mm.Put(2, "z")
+  values := mm.Get(2)
+  if values.Size() != 3 || values.At(0) != "x" || values.At(2) != "z" || mm.Size() != 4 {
+    t.Errorf("Unexpected values %v of 2", values)
+  }
+  if !mm.Remove(2, "x") || values.Size() != 2 || values.At(0) != "x" {
+    t.Errorf("Remove does not remove a single value")
+  }
+  entries := ""
+  mm.Entries().ForEach(func (entry interface{}) {
+    entries += entry.(MapEntry).String()
+  })
+  if entries != "(1, y)(2, x)(2, z)" {
+    t.Errorf("Unexpected entries %s", entries)
+  }
+  if str := mm.String(); str != "{(1, y), (2, x), (2, z)}" {
+    t.Errorf("Unexpected string representation %s", str)
+  }
+  target := ArrayListMultimap.New(KV("y", 0))
+  mm.InverseInto(target)
+  if target.Count("y") != 2 || target.Count("x") != 1 {
+    t.Errorf("Unexpected inverse %v", target)
+  }
+  checkSequence(t, mm.Get(3), 0)
+}
+
+func TestMultimapInverseClass(t *testing.T) {
+  caseless := func (x, y interface{}) bool {
+    return strings.EqualFold(x.(string), y.(string))
+  }
+  hash := func (x interface{}) int {
+    return UniversalHash(strings.ToLower(x.(string)))
+  }
+  mm := HashSetMultimap.New(KV(1, "One"), KV(2, "ONE"), KV(2, "two"))
+  inverse := mm.Inverse(NewSetMultimapClass(HashMapClass(hash, caseless), HashSet))
+  if inverse.Count("one") != 2 || !inverse.HasEntry("one", 1) || inverse.Size() != 3 {
+    t.Errorf("Expected values to be compared ignoring case in inverse %v", inverse)
+  }
+  lm := ArrayListMultimap.New(KV(1, "One"), KV(2, "ONE"))
+  if lm.Inverse(NewListMultimapClass(HashMapClass(hash, caseless), ArraySequence)).Count("one") != 2 {
+    t.Errorf("Expected values to be compared ignoring case in inverse list multimap")
+  }
+}
+
+func checkSequence(t *testing.T, seq Sequence, size int) {
+  if seq.Size() != size || CountElements(seq.Elements()) != size {
+    t.Errorf("Expected sequence of size %d; was %v", size, seq)
+  }
+}
@@ -39,8 +39,39 @@ type MutableMapDerived interface {
   IncludeFrom(entries Container)
   IncludeFromNative(mp map[interface{}] interface{})
   ExcludeKeys(keys Container)
+
+  // GetOrInclude returns the value key is mapped to. If key is not mapped
+  // yet, it gets mapped to value, which is returned.
+  GetOrInclude(key, value interface{}) interface{}
+
+  // ComputeIfAbsent returns the value key is mapped to. If key is not mapped
+  // yet, it gets mapped to f(key), which is returned.
+  ComputeIfAbsent(key interface{}, f Mapping) interface{}
+
+  // ComputeIfPresent remaps key with f if key is mapped. It returns the
+  // resulting mapping of key.
+  ComputeIfPresent(key interface{}, f Remapping) (value interface{}, exists bool)
+
+  // Compute remaps key with f, whether key is mapped or not. It returns the
+  // resulting mapping of key.
+  Compute(key interface{}, f Remapping) (value interface{}, exists bool)
+
+  // Merge maps key to value if key is not mapped yet. Otherwise, key gets
+  // mapped to combine(old, value) where old is the current value of key.
+  // Merge returns the new value of key.
+  Merge(key, value interface{}, combine Binop) interface{}
+
+  // Apply replays diff on this map: removed keys get excluded, and added
+  // and changed keys get mapped to their new values.
+  Apply(diff *MapDiff)
 }
 
+// Remapping computes a new mapping for key from its current mapping. exists
+// is false if key is not mapped. A Remapping returns the new value and a flag
+// indicating whether key should be mapped at all; if keep is false, key gets
+// excluded.
+type Remapping func (key, value interface{}, exists bool) (newValue interface{}, keep bool)
+
 // A MutableMap is a Map that provides functionality for changing the state
 // of the map by either including or excluding mappings/map entries.
 type MutableMap interface {
@@ -102,3 +133,62 @@ func (this *mutableMap) ExcludeKeys(keys Container) {
     this.obj.Exclude(iter.Next())
   }
 }
+
+func (this *mutableMap) GetOrInclude(key, value interface{}) interface{} {
+  if old, exists := this.obj.Get(key); exists {
+    return old
+  }
+  this.obj.Include(key, value)
+  return value
+}
+
+func (this *mutableMap) ComputeIfAbsent(key interface{}, f Mapping) interface{} {
+  if old, exists := this.obj.Get(key); exists {
+    return old
+  }
+  value := f(key)
+  this.obj.Include(key, value)
+  return value
+}
+
+func (this *mutableMap) ComputeIfPresent(key interface{}, f Remapping) (value interface{}, exists bool) {
+  if old, exists := this.obj.Get(key); exists {
+    return this.remap(key, old, true, f)
+  }
+  return nil, false
+}
+
+func (this *mutableMap) Compute(key interface{}, f Remapping) (value interface{}, exists bool) {
+  old, exists := this.obj.Get(key)
+  return this.remap(key, old, exists, f)
+}
+
+func (this *mutableMap) remap(key, old interface{}, exists bool, f Remapping) (interface{}, bool) {
+  value, keep := f(key, old, exists)
+  if keep {
+    this.obj.Include(key, value)
+    return value, true
+  }
+  if exists {
+    this.obj.Exclude(key)
+  }
+  return nil, false
+}
+
+func (this *mutableMap) Merge(key, value interface{}, combine Binop) interface{} {
+  if old, exists := this.obj.Get(key); exists {
+    value = combine(old, value)
+  }
+  this.obj.Include(key, value)
+  return value
+}
+
+func (this *mutableMap) Apply(diff *MapDiff) {
+  for _, entry := range diff.Removed {
+    this.obj.Exclude(entry.Key())
+  }
+  this.obj.IncludeEntry(diff.Added...)
+  for _, change := range diff.Changed {
+    this.obj.Include(change.Key, change.New)
+  }
+}
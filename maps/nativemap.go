@@ -106,10 +106,14 @@ type nativeMapIterator struct {
 }
 
 func (this *nativeMapIterator) HasNext() bool {
-  return this.i < len(this.nmap)
+  return this.i < len(this.keys)
 }
 
 func (this *nativeMapIterator) Next() interface{} {
+  if !this.HasNext() {
+    panic("nativeMapIterator.Next: no next element")
+  }
+  key := this.keys[this.i]
   this.i++
-  return this.nmap[this.keys[this.i]]
+  return KV(key, this.nmap[key])
 }
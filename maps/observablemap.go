@@ -0,0 +1,256 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+
+
+// MutableMapObserver is notified about changes of observed maps. Including a
+// mapping for a key which is mapped already is reported as a Replace event.
+// Observers may implement MapBatchObserver and MapClearObserver to be
+// notified about IncludeFrom and Clear with a single event.
+type MutableMapObserver interface {
+  Include(subject MutableMap, key, value interface{})
+  Replace(subject MutableMap, key, oldValue, newValue interface{})
+  Exclude(subject MutableMap, key, value interface{})
+}
+
+// MapBatchObserver is an optional extension of MutableMapObserver.
+// IncludeFrom and IncludeFromNative are reported to observers implementing it
+// as a single IncludeBatch event listing all included entries; other
+// observers get an Include or Replace event for every entry.
+type MapBatchObserver interface {
+  IncludeBatch(subject MutableMap, entries []MapEntry)
+}
+
+// MapClearObserver is an optional extension of MutableMapObserver. Clearing
+// a map is reported to observers implementing it as a single Clear event;
+// other observers get an Exclude event for every entry.
+type MapClearObserver interface {
+  Clear(subject MutableMap)
+}
+
+// ObservedMap is implemented by maps created by ObservableMap classes. It
+// allows observers to be added and removed at any time.
+type ObservedMap interface {
+  MutableMap
+  AddObserver(observer MutableMapObserver)
+  RemoveObserver(observer MutableMapObserver)
+}
+
+// ObservableMap returns a class whose maps notify observers about changes.
+// The maps are initially observed by the MutableMapObserver elements of
+// observers, which may be nil.
+func ObservableMap(class MutableMapClass, observers Container) MutableMapClass {
+  return &observableMapClass{class, observers}
+}
+
+type observableMapClass struct {
+  class MutableMapClass
+  observers Container
+}
+
+func (this *observableMapClass) Embed(obj MutableMap) MutableMap {
+  res := new(observableMap)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  if this.observers != nil {
+    this.observers.ForEach(func (o interface{}) {
+      res.AddObserver(o.(MutableMapObserver))
+    })
+  }
+  res.MutableMap = this.class.Embed(obj)
+  res.derived = EmbeddedMutableMap(obj)
+  return res
+}
+
+func (this *observableMapClass) New(entries... MapEntry) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeEntry(entries...)
+  return res
+}
+
+func (this *observableMapClass) From(coll Container) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+func (this *observableMapClass) FromNative(mp map[interface{}] interface{}) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFromNative(mp)
+  return res
+}
+
+type observableMap struct {
+  obj MutableMap
+  observers Observers
+  depth int
+  batch []observedChange
+  derived MutableMapDerived
+  MutableMap
+}
+
+// observedChange records a mapping included as part of a batch.
+type observedChange struct {
+  key interface{}
+  oldValue interface{}
+  newValue interface{}
+  existed bool
+}
+
+func (this *observableMap) AddObserver(observer MutableMapObserver) {
+  this.observers.Add(observer)
+}
+
+func (this *observableMap) RemoveObserver(observer MutableMapObserver) {
+  this.observers.Remove(observer)
+}
+
+func (this *observableMap) Include(key, value interface{}) {
+  old, existed := this.MutableMap.Get(key)
+  this.MutableMap.Include(key, value)
+  if this.depth > 0 {
+    this.batch = append(this.batch, observedChange{key, old, value, existed})
+    return
+  }
+  for _, o := range this.observers {
+    if existed {
+      o.(MutableMapObserver).Replace(this.obj, key, old, value)
+    } else {
+      o.(MutableMapObserver).Include(this.obj, key, value)
+    }
+  }
+}
+
+func (this *observableMap) Exclude(keys ...interface{}) {
+  for _, key := range keys {
+    if old, existed := this.MutableMap.Get(key); existed {
+      this.MutableMap.Exclude(key)
+      if this.depth == 0 {
+        for _, o := range this.observers {
+          o.(MutableMapObserver).Exclude(this.obj, key, old)
+        }
+      }
+    }
+  }
+}
+
+// The following operations are derived from Get, Include and Exclude of
+// this map, even if the observed map implements them natively, such that all
+// changes are reported to the observers.
+
+func (this *observableMap) IncludeEntry(entries ...MapEntry) {
+  this.derived.IncludeEntry(entries...)
+}
+
+func (this *observableMap) IncludeFrom(entries Container) {
+  this.depth++
+  this.derived.IncludeFrom(entries)
+  this.endBatch()
+}
+
+func (this *observableMap) IncludeFromNative(mp map[interface{}] interface{}) {
+  this.depth++
+  this.derived.IncludeFromNative(mp)
+  this.endBatch()
+}
+
+func (this *observableMap) ExcludeKeys(keys Container) {
+  this.derived.ExcludeKeys(keys)
+}
+
+func (this *observableMap) GetOrInclude(key, value interface{}) interface{} {
+  return this.derived.GetOrInclude(key, value)
+}
+
+func (this *observableMap) ComputeIfAbsent(key interface{}, f Mapping) interface{} {
+  return this.derived.ComputeIfAbsent(key, f)
+}
+
+func (this *observableMap) ComputeIfPresent(key interface{}, f Remapping) (value interface{}, exists bool) {
+  return this.derived.ComputeIfPresent(key, f)
+}
+
+func (this *observableMap) Compute(key interface{}, f Remapping) (value interface{}, exists bool) {
+  return this.derived.Compute(key, f)
+}
+
+func (this *observableMap) Merge(key, value interface{}, combine Binop) interface{} {
+  return this.derived.Merge(key, value, combine)
+}
+
+func (this *observableMap) Apply(diff *MapDiff) {
+  this.derived.Apply(diff)
+}
+
+func (this *observableMap) endBatch() {
+  this.depth--
+  if this.depth > 0 {
+    return
+  }
+  batch := this.batch
+  this.batch = nil
+  var entries []MapEntry
+  for _, o := range this.observers {
+    if bo, ok := o.(MapBatchObserver); ok {
+      if entries == nil {
+        entries = make([]MapEntry, len(batch))
+        for i, change := range batch {
+          entries[i] = KV(change.key, change.newValue)
+        }
+      }
+      bo.IncludeBatch(this.obj, entries)
+      continue
+    }
+    for _, change := range batch {
+      if change.existed {
+        o.(MutableMapObserver).Replace(this.obj, change.key, change.oldValue, change.newValue)
+      } else {
+        o.(MutableMapObserver).Include(this.obj, change.key, change.newValue)
+      }
+    }
+  }
+}
+
+func (this *observableMap) Clear() {
+  var entries []MapEntry
+  if this.depth == 0 {
+    for _, o := range this.observers {
+      if _, ok := o.(MapClearObserver); !ok {
+        for iter := this.MutableMap.Elements(); iter.HasNext(); {
+          entries = append(entries, iter.Next().(MapEntry))
+        }
+        break
+      }
+    }
+  }
+  this.depth++
+  this.MutableMap.Clear()
+  this.depth--
+  if this.depth == 0 {
+    for _, o := range this.observers {
+      if co, ok := o.(MapClearObserver); ok {
+        co.Clear(this.obj)
+      } else {
+        for _, entry := range entries {
+          o.(MutableMapObserver).Exclude(this.obj, entry.Key(), entry.Value())
+        }
+      }
+    }
+  }
+}
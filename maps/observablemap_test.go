@@ -0,0 +1,94 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "fmt"
+import "strings"
+import "testing"
+
+
+type mapRecorder struct {
+  events []string
+}
+
+func (this *mapRecorder) Include(subject MutableMap, key, value interface{}) {
+  this.events = append(this.events, fmt.Sprintf("+%v:%v", key, value))
+}
+
+func (this *mapRecorder) Replace(subject MutableMap, key, oldValue, newValue interface{}) {
+  this.events = append(this.events, fmt.Sprintf("%v:%v=>%v", key, oldValue, newValue))
+}
+
+func (this *mapRecorder) Exclude(subject MutableMap, key, value interface{}) {
+  this.events = append(this.events, fmt.Sprintf("-%v:%v", key, value))
+}
+
+// batchMapRecorder also records batch and clear events.
+type batchMapRecorder struct {
+  mapRecorder
+}
+
+func (this *batchMapRecorder) IncludeBatch(subject MutableMap, entries []MapEntry) {
+  this.events = append(this.events, fmt.Sprintf("batch%d", len(entries)))
+}
+
+func (this *batchMapRecorder) Clear(subject MutableMap) {
+  this.events = append(this.events, "clear")
+}
+
+func TestObservableMapClass(t *testing.T) {
+  recorder := new(batchMapRecorder)
+  m := ObservableMap(HashMap, nil).New(KV(1, "a")).(ObservedMap)
+  m.AddObserver(recorder)
+  m.Include(1, "b")
+  m.Include(2, "c")
+  m.IncludeFrom(HashMap.New(KV(3, "d"), KV(4, "e")))
+  m.Exclude(2, 5)
+  checkSize(t, m, 3, "m")
+  m.Clear()
+  m.RemoveObserver(recorder)
+  m.Include(6, "f")
+  expected := "1:a=>b +2:c batch2 -2:c clear"
+  if events := strings.Join(recorder.events, " "); events != expected {
+    t.Errorf("Unexpected events %s; expected %s", events, expected)
+  }
+}
+
+func TestObservableMapFallbackEvents(t *testing.T) {
+  recorder := new(mapRecorder)
+  m := ObservableMap(TreeMap, nil).New(KV(1, "a")).(ObservedMap)
+  m.AddObserver(recorder)
+  m.IncludeFrom(TreeMap.New(KV(1, "b"), KV(2, "c")))
+  m.Clear()
+  expected := "1:a=>b +2:c -1:b -2:c"
+  if events := strings.Join(recorder.events, " "); events != expected {
+    t.Errorf("Unexpected events %s; expected %s", events, expected)
+  }
+}
+
+func TestObservableMapComputeEvents(t *testing.T) {
+  for _, class := range []MutableMapClass{HashMap, SynchronizedMap(HashMap), ConcurrentHashMap} {
+    recorder := new(mapRecorder)
+    m := ObservableMap(class, nil).New(KV(1, 10)).(ObservedMap)
+    m.AddObserver(recorder)
+    m.Merge(1, 5, func (x, y interface{}) interface{} { return x.(int) + y.(int) })
+    m.ComputeIfAbsent(2, func (key interface{}) interface{} { return 20 })
+    m.GetOrInclude(3, 30)
+    expected := "1:10=>15 +2:20 +3:30"
+    if events := strings.Join(recorder.events, " "); events != expected {
+      t.Errorf("Unexpected events %s; expected %s", events, expected)
+    }
+  }
+}
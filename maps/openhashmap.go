@@ -0,0 +1,111 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// OpenHashMap is a class of hash maps which are backed by hash tables with
+// open addressing.
+var OpenHashMap MutableMapClass = HashMapClassWithTable(UniversalHash, UniversalEquality,
+    impl.HashTableConfig{OpenAddressing: true, Capacity: 16, LoadFactor: 80})
+
+var ImmutableOpenHashMap MapClass = ImmutableMap(OpenHashMap)
+
+type openHashMapClass struct {
+  hash Hashfunction
+  equals Equality
+  table impl.HashTableConfig
+}
+
+func (this *openHashMapClass) Embed(obj MutableMap) MutableMap {
+  res := new(openHashMap)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.class = this
+  res.table = this.table.NewOpenTable(this.hash, this.equals)
+  res.MutableMapDerived = embeddedHashTableMap(obj, res, res.table)
+  return res
+}
+
+func (this *openHashMapClass) New(entries... MapEntry) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeEntry(entries...)
+  return res
+}
+
+func (this *openHashMapClass) From(coll Container) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+func (this *openHashMapClass) FromNative(mp map[interface{}] interface{}) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFromNative(mp)
+  return res
+}
+
+type openHashMap struct {
+  obj MutableMap
+  class *openHashMapClass
+  table *impl.OpenHashTable
+  MutableMapDerived
+}
+
+func (this *openHashMap) Size() int {
+  return this.table.Size()
+}
+
+func (this *openHashMap) Get(key interface{}) (value interface{}, exists bool) {
+  return this.table.Get(key)
+}
+
+func (this *openHashMap) Elements() Iterator {
+  return &openHashMapIterator{this.table.Iterator()}
+}
+
+func (this *openHashMap) Class() MutableMapClass {
+  return this.class
+}
+
+func (this *openHashMap) Include(key, value interface{}) {
+  this.table.Put(key, value)
+}
+
+func (this *openHashMap) Exclude(keys ...interface{}) {
+  for _, key := range keys {
+    this.table.Delete(key)
+  }
+}
+
+func (this *openHashMap) Clear() {
+  this.table.Clear()
+}
+
+type openHashMapIterator struct {
+  iter *impl.OpenHashIterator
+}
+
+func (this *openHashMapIterator) HasNext() bool {
+  return this.iter.HasNext()
+}
+
+func (this *openHashMapIterator) Next() interface{} {
+  return KV(this.iter.Next())
+}
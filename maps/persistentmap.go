@@ -0,0 +1,158 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// PersistentMap is an immutable Map which can be used to efficiently derive
+// modified versions of itself. The derived maps share most of their
+// representation with the original map.
+type PersistentMap interface {
+  Map
+
+  // With returns a new map which maps key to value and otherwise contains
+  // all mappings of this map.
+  With(key, value interface{}) PersistentMap
+
+  // Without returns a new map which contains all mappings of this map
+  // except for the mappings of the given keys.
+  Without(keys ...interface{}) PersistentMap
+}
+
+// PersistentMapClass defines the functionality of PersistentMap
+// implementations. In addition to the MapClass methods, it provides
+// factory methods returning PersistentMap values.
+type PersistentMapClass interface {
+  MapClass
+  Empty() PersistentMap
+  NewPersistent(entries... MapEntry) PersistentMap
+  FromPersistent(coll Container) PersistentMap
+}
+
+var PersistentHashMap PersistentMapClass =
+    PersistentHashMapClass(UniversalHash, UniversalEquality)
+
+func PersistentHashMapClass(hash Hashfunction, equals Equality) PersistentMapClass {
+  return &persistentHashMapClass{hash, equals}
+}
+
+type persistentHashMapClass struct {
+  hash Hashfunction
+  equals Equality
+}
+
+func (this *persistentHashMapClass) Embed(obj Map) Map {
+  return newPersistentHashMap(obj, impl.NewHamt(this.hash, this.equals))
+}
+
+func (this *persistentHashMapClass) New(entries... MapEntry) Map {
+  return this.NewPersistent(entries...)
+}
+
+func (this *persistentHashMapClass) From(coll Container) Map {
+  return this.FromPersistent(coll)
+}
+
+func (this *persistentHashMapClass) Empty() PersistentMap {
+  return newPersistentHashMap(nil, impl.NewHamt(this.hash, this.equals))
+}
+
+func (this *persistentHashMapClass) NewPersistent(entries... MapEntry) PersistentMap {
+  trie := impl.NewHamt(this.hash, this.equals)
+  for _, entry := range entries {
+    trie = trie.With(entry.Key(), entry.Value())
+  }
+  return newPersistentHashMap(nil, trie)
+}
+
+func (this *persistentHashMapClass) FromPersistent(coll Container) PersistentMap {
+  trie := impl.NewHamt(this.hash, this.equals)
+  for iter := coll.Elements(); iter.HasNext(); {
+    if entry, valid := iter.Next().(MapEntry); valid {
+      trie = trie.With(entry.Key(), entry.Value())
+    } else {
+      panic("persistentHashMapClass.From: not a MapEntry value")
+    }
+  }
+  return newPersistentHashMap(nil, trie)
+}
+
+func newPersistentHashMap(obj Map, trie *impl.Hamt) *persistentHashMap {
+  res := new(persistentHashMap)
+  if obj == nil {
+    obj = res
+  }
+  res.MapDerived = EmbeddedMap(obj)
+  res.trie = trie
+  return res
+}
+
+type persistentHashMap struct {
+  trie *impl.Hamt
+  MapDerived
+}
+
+func (this *persistentHashMap) Size() int {
+  return this.trie.Size()
+}
+
+func (this *persistentHashMap) Get(key interface{}) (value interface{}, exists bool) {
+  if entry := this.trie.FindEntry(key); entry != nil {
+    return entry.Value, true
+  }
+  return nil, false
+}
+
+func (this *persistentHashMap) Elements() Iterator {
+  return &persistentHashMapIterator{this.trie.Iterator()}
+}
+
+func (this *persistentHashMap) With(key, value interface{}) PersistentMap {
+  return newPersistentHashMap(nil, this.trie.With(key, value))
+}
+
+func (this *persistentHashMap) Without(keys ...interface{}) PersistentMap {
+  trie := this.trie
+  for _, key := range keys {
+    trie = trie.Without(key)
+  }
+  if trie == this.trie {
+    return this
+  }
+  return newPersistentHashMap(nil, trie)
+}
+
+func (this *persistentHashMap) ReadOnly() DependentMap {
+  return this
+}
+
+func (this *persistentHashMap) Freeze() FiniteContainer {
+  return this
+}
+
+type persistentHashMapIterator struct {
+  entryIter *impl.HamtIterator
+}
+
+func (this *persistentHashMapIterator) HasNext() bool {
+  return this.entryIter.HasNext()
+}
+
+func (this *persistentHashMapIterator) Next() interface{} {
+  entry := this.entryIter.Next()
+  return KV(entry.Key, entry.Value)
+}
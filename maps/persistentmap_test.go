@@ -0,0 +1,42 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "testing"
+
+
+func checkMapSize(t *testing.T, m Map, size int, name string) {
+  if m.Size() != size {
+    t.Errorf("Expected size of map %s to be %d; was %d", name, size, m.Size())
+  }
+}
+
+func TestPersistentHashMapClass(t *testing.T) {
+  m1 := PersistentHashMap.NewPersistent(KV("one", 1), KV("two", 2))
+  m2 := m1.With("three", 3).With("one", 10)
+  m3 := m2.Without("two", "four")
+  checkMapSize(t, m1, 2, "m1")
+  checkMapSize(t, m2, 3, "m2")
+  checkMapSize(t, m3, 2, "m3")
+  if m1.GetValue("one") != 1 || m2.GetValue("one") != 10 || m3.HasKey("two") {
+    t.Errorf("Persistent maps are not independent of each other")
+  }
+  if m3.Without("four") != m3 {
+    t.Errorf("Expected unchanged persistent map to be returned")
+  }
+  m4 := PersistentHashMap.FromPersistent(HashMap.New(KV(1, "a"), KV(2, "b")))
+  checkMapSize(t, m4.With(3, "c"), 3, "m4")
+  checkMapSize(t, PersistentHashMap.Empty(), 0, "empty")
+}
@@ -0,0 +1,197 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "strings"
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// PrefixMap is a MutableMap with string keys which supports queries for keys
+// sharing a prefix. Its iterator returns the entries in ascending order of
+// their keys.
+type PrefixMap interface {
+  MutableMap
+
+  // WithPrefix returns a live view of all mappings whose keys start with
+  // prefix.
+  WithPrefix(prefix string) DependentMap
+
+  // KeysWithPrefix returns a live view of all keys starting with prefix, in
+  // ascending order.
+  KeysWithPrefix(prefix string) DependentContainer
+
+  // LongestPrefixOf returns the mapping for the longest key which is a prefix
+  // of key.
+  LongestPrefixOf(key string) (prefix string, value interface{}, exists bool)
+}
+
+// PrefixMapClass defines the functionality of PrefixMap implementations. In
+// addition to the MutableMapClass methods, which return MutableMap values,
+// it provides factory methods returning PrefixMap values.
+type PrefixMapClass interface {
+  MutableMapClass
+  NewPrefix(entries... MapEntry) PrefixMap
+  FromPrefix(coll Container) PrefixMap
+}
+
+// RadixTreeMap creates prefix maps which are implemented by a compressed
+// radix tree. Including a key which is not a string panics.
+var RadixTreeMap PrefixMapClass = &radixTreeMapClass{}
+
+var ImmutableRadixTreeMap MapClass = ImmutableMap(RadixTreeMap)
+
+type radixTreeMapClass struct {}
+
+func (this *radixTreeMapClass) Embed(obj MutableMap) MutableMap {
+  res := new(radixTreeMap)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableMapDerived = EmbeddedMutableMap(obj)
+  res.tree = impl.NewRadixTree()
+  return res
+}
+
+func (this *radixTreeMapClass) New(entries... MapEntry) MutableMap {
+  return this.NewPrefix(entries...)
+}
+
+func (this *radixTreeMapClass) From(coll Container) MutableMap {
+  return this.FromPrefix(coll)
+}
+
+func (this *radixTreeMapClass) FromNative(mp map[interface{}] interface{}) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFromNative(mp)
+  return res
+}
+
+func (this *radixTreeMapClass) NewPrefix(entries... MapEntry) PrefixMap {
+  res := this.Embed(nil).(*radixTreeMap)
+  res.IncludeEntry(entries...)
+  return res
+}
+
+func (this *radixTreeMapClass) FromPrefix(coll Container) PrefixMap {
+  res := this.Embed(nil).(*radixTreeMap)
+  res.IncludeFrom(coll)
+  return res
+}
+
+type radixTreeMap struct {
+  obj MutableMap
+  tree *impl.RadixTree
+  MutableMapDerived
+}
+
+func (this *radixTreeMap) Size() int {
+  return this.tree.Size()
+}
+
+func (this *radixTreeMap) Get(key interface{}) (value interface{}, exists bool) {
+  if str, valid := key.(string); valid {
+    if node := this.tree.Find(str); node != nil {
+      return node.Value, true
+    }
+  }
+  return nil, false
+}
+
+func (this *radixTreeMap) Elements() Iterator {
+  return &radixTreeMapIterator{this.tree.Iterator()}
+}
+
+func (this *radixTreeMap) Class() MutableMapClass {
+  return RadixTreeMap
+}
+
+func (this *radixTreeMap) Include(key, value interface{}) {
+  if str, valid := key.(string); valid {
+    this.tree.Insert(str, value)
+  } else {
+    panic("radixTreeMap.Include: key not a string")
+  }
+}
+
+func (this *radixTreeMap) Exclude(keys ...interface{}) {
+  for _, key := range keys {
+    if str, valid := key.(string); valid {
+      this.tree.Delete(str)
+    }
+  }
+}
+
+func (this *radixTreeMap) Clear() {
+  this.tree.Clear()
+}
+
+func (this *radixTreeMap) WithPrefix(prefix string) DependentMap {
+  res := new(radixPrefixMap)
+  res.MapDerived = EmbeddedDependentMap(res)
+  res.tree = this.tree
+  res.prefix = prefix
+  return res
+}
+
+func (this *radixTreeMap) KeysWithPrefix(prefix string) DependentContainer {
+  return this.WithPrefix(prefix).Keys()
+}
+
+func (this *radixTreeMap) LongestPrefixOf(key string) (prefix string, value interface{}, exists bool) {
+  if node := this.tree.LongestPrefix(key); node != nil {
+    return node.Key, node.Value, true
+  }
+  return "", nil, false
+}
+
+// Prefix views
+
+type radixPrefixMap struct {
+  MapDerived
+  tree *impl.RadixTree
+  prefix string
+}
+
+func (this *radixPrefixMap) Size() int {
+  return CountElements(this.Elements())
+}
+
+func (this *radixPrefixMap) Get(key interface{}) (value interface{}, exists bool) {
+  if str, valid := key.(string); valid && strings.HasPrefix(str, this.prefix) {
+    if node := this.tree.Find(str); node != nil {
+      return node.Value, true
+    }
+  }
+  return nil, false
+}
+
+func (this *radixPrefixMap) Elements() Iterator {
+  return &radixTreeMapIterator{this.tree.PrefixIterator(this.prefix)}
+}
+
+type radixTreeMapIterator struct {
+  nodeIter *impl.RadixNodeIterator
+}
+
+func (this *radixTreeMapIterator) HasNext() bool {
+  return this.nodeIter.HasNext()
+}
+
+func (this *radixTreeMapIterator) Next() interface{} {
+  node := this.nodeIter.Next()
+  return KV(node.Key, node.Value)
+}
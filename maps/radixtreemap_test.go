@@ -0,0 +1,84 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func checkStrings(t *testing.T, coll Container, expected ...string) {
+  iter := coll.Elements()
+  for i, str := range expected {
+    if !iter.HasNext() {
+      t.Errorf("Expected %d elements; got %d", len(expected), i)
+      return
+    }
+    if elem := iter.Next(); elem != str {
+      t.Errorf("Expected %q at position %d; got %v", str, i, elem)
+    }
+  }
+  if iter.HasNext() {
+    t.Errorf("Expected %d elements; got more", len(expected))
+  }
+}
+
+func TestRadixTreeMapClass(t *testing.T) {
+  m := RadixTreeMap.NewPrefix(KV("team", 1), KV("test", 2), KV("toast", 3), KV("te", 4))
+  checkSize(t, m, 4, "m")
+  checkStrings(t, m.Keys(), "te", "team", "test", "toast")
+  m.Include("tea", 5)
+  m.Exclude("te", "missing", 42)
+  checkStrings(t, m.Keys(), "tea", "team", "test", "toast")
+  if m.GetValue("tea") != 5 || m.HasKey("te") || m.HasKey(1) {
+    t.Errorf("Unexpected mappings in %s", m)
+  }
+  expectPanic(t, "Include of an int key", func () { m.Include(1, 1) })
+  if m.Class() != RadixTreeMap {
+    t.Errorf("Unexpected class of radix tree map")
+  }
+  im := ImmutableRadixTreeMap.New(KV("b", 1), KV("a", 2))
+  checkStrings(t, im.Keys(), "a", "b")
+}
+
+func TestRadixTreeMapPrefixes(t *testing.T) {
+  routes := RadixTreeMap.NewPrefix(KV("/", "root"),
+                                   KV("/api", "api"),
+                                   KV("/api/users", "users"),
+                                   KV("/static", "static"))
+  view := routes.WithPrefix("/api")
+  keys := routes.KeysWithPrefix("/api/")
+  checkMapSize(t, view, 2, "view")
+  checkStrings(t, keys, "/api/users")
+  routes.Include("/api/groups", "groups")
+  routes.Include("/apiary", "bees")
+  checkMapSize(t, view, 4, "view")
+  checkStrings(t, view.Keys(), "/api", "/api/groups", "/api/users", "/apiary")
+  checkStrings(t, keys, "/api/groups", "/api/users")
+  if view.GetValue("/api/users") != "users" || view.HasKey("/static") {
+    t.Errorf("Unexpected mappings in prefix view %s", view)
+  }
+  for key, expected := range map[string]string{"/api/users/42": "/api/users",
+                                               "/api/items": "/api",
+                                               "/index.html": "/"} {
+    if prefix, _, exists := routes.LongestPrefixOf(key); !exists || prefix != expected {
+      t.Errorf("Expected longest prefix of %s to be %s; got %s", key, expected, prefix)
+    }
+  }
+  if _, _, exists := routes.LongestPrefixOf("api"); exists {
+    t.Errorf("Expected no prefix of api")
+  }
+  routes.Clear()
+  checkMapSize(t, view, 0, "view")
+}
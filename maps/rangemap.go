@@ -0,0 +1,181 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "sort"
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/sets"
+
+
+// RangeMap maps disjoint intervals of a domain ordered by a Comparison to
+// values. Looking up a value is done by point: the value of a point is the
+// value of the interval containing it. Mapping an interval overrides the
+// mappings of all its values; intervals which partially overlap get trimmed
+// or split. Adjacent intervals are never coalesced, even if they are mapped
+// to the same value.
+type RangeMap interface {
+
+  // Comparison returns the comparison function defining the order of the
+  // domain.
+  Comparison() Comparison
+
+  // Size returns the number of intervals mapped by this range map.
+  Size() int
+
+  // IsEmpty returns true if no interval is mapped.
+  IsEmpty() bool
+
+  // Get returns the value of the interval containing point.
+  Get(point interface{}) (value interface{}, exists bool)
+
+  // GetEntry returns the interval containing point together with its value.
+  GetEntry(point interface{}) (rng Interval, value interface{}, exists bool)
+
+  // Include maps all values of rng to value.
+  Include(rng Interval, value interface{})
+
+  // Exclude removes the mappings of all values of rng.
+  Exclude(rng Interval)
+
+  // Entries returns a container of MapEntry values, mapping the disjoint
+  // intervals of this range map in ascending order to their values. The
+  // container is a live view of this range map.
+  Entries() Container
+
+  // Clear removes all mappings.
+  Clear()
+
+  String() string
+}
+
+// NewRangeMap returns a new empty range map over the domain ordered by comp.
+func NewRangeMap(comp Comparison) RangeMap {
+  return &rangeMap{comp, nil}
+}
+
+type rangeMap struct {
+  comp Comparison
+  ranges []rangeEntry
+}
+
+type rangeEntry struct {
+  rng Interval
+  value interface{}
+}
+
+func (this *rangeMap) Comparison() Comparison {
+  return this.comp
+}
+
+func (this *rangeMap) Size() int {
+  return len(this.ranges)
+}
+
+func (this *rangeMap) IsEmpty() bool {
+  return len(this.ranges) == 0
+}
+
+func (this *rangeMap) Get(point interface{}) (value interface{}, exists bool) {
+  _, value, exists = this.GetEntry(point)
+  return value, exists
+}
+
+func (this *rangeMap) GetEntry(point interface{}) (rng Interval, value interface{}, exists bool) {
+  i := sort.Search(len(this.ranges), func (i int) bool {
+    return !this.ranges[i].rng.Precedes(this.comp, ClosedInterval(point, point))
+  })
+  if i < len(this.ranges) && this.ranges[i].rng.Contains(this.comp, point) {
+    return this.ranges[i].rng, this.ranges[i].value, true
+  }
+  return rng, nil, false
+}
+
+func (this *rangeMap) Include(rng Interval, value interface{}) {
+  if !rng.IsEmpty(this.comp) {
+    this.replace(rng, &rangeEntry{rng, value})
+  }
+}
+
+func (this *rangeMap) Exclude(rng Interval) {
+  if !rng.IsEmpty(this.comp) {
+    this.replace(rng, nil)
+  }
+}
+
+// replace removes the mappings of all values of rng and inserts entry, if it
+// is not nil, at the position of rng.
+func (this *rangeMap) replace(rng Interval, entry *rangeEntry) {
+  i := sort.Search(len(this.ranges), func (i int) bool {
+    return !this.ranges[i].rng.Precedes(this.comp, rng)
+  })
+  res := make([]rangeEntry, 0, len(this.ranges) + 2)
+  res = append(res, this.ranges[:i]...)
+  var right []rangeEntry
+  for ; i < len(this.ranges) && this.ranges[i].rng.Overlaps(this.comp, rng); i++ {
+    left, rest := this.ranges[i].rng.Difference(this.comp, rng)
+    if !left.IsEmpty(this.comp) {
+      res = append(res, rangeEntry{left, this.ranges[i].value})
+    }
+    if !rest.IsEmpty(this.comp) {
+      right = append(right, rangeEntry{rest, this.ranges[i].value})
+    }
+  }
+  if entry != nil {
+    res = append(res, *entry)
+  }
+  res = append(res, right...)
+  this.ranges = append(res, this.ranges[i:]...)
+}
+
+func (this *rangeMap) Entries() Container {
+  res := &rangeMapEntries{this, nil}
+  res.Container = EmbeddedContainer(res)
+  return res
+}
+
+func (this *rangeMap) Clear() {
+  this.ranges = nil
+}
+
+func (this *rangeMap) String() string {
+  return "[" + this.Entries().String() + "]"
+}
+
+type rangeMapEntries struct {
+  rm *rangeMap
+  Container
+}
+
+func (this *rangeMapEntries) Elements() Iterator {
+  return &rangeMapIterator{this.rm.ranges, 0}
+}
+
+type rangeMapIterator struct {
+  ranges []rangeEntry
+  next int
+}
+
+func (this *rangeMapIterator) HasNext() bool {
+  return this.next < len(this.ranges)
+}
+
+func (this *rangeMapIterator) Next() interface{} {
+  if this.next >= len(this.ranges) {
+    panic("rangeMapIterator.Next: no next element")
+  }
+  this.next++
+  entry := this.ranges[this.next - 1]
+  return KV(entry.rng, entry.value)
+}
@@ -0,0 +1,49 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/sets"
+
+
+func TestRangeMap(t *testing.T) {
+  slots := NewRangeMap(UniversalComparison)
+  slots.Include(HalfOpenInterval(9, 12), "work")
+  slots.Include(HalfOpenInterval(12, 13), "lunch")
+  slots.Include(HalfOpenInterval(13, 18), "work")
+  slots.Include(ClosedInterval(10, 11), "meeting")
+  if slots.Size() != 5 {
+    t.Errorf("Expected 5 ranges; was %d: %v", slots.Size(), slots)
+  }
+  expected := map[interface{}]interface{}{8: nil, 9: "work", 10: "meeting", 11: "meeting",
+                                          12: "lunch", 13: "work", 17: "work", 18: nil}
+  for point, value := range expected {
+    if actual, exists := slots.Get(point); actual != value || exists != (value != nil) {
+      t.Errorf("Expected %v to be mapped to %v; was %v", point, value, actual)
+    }
+  }
+  if rng, _, _ := slots.GetEntry(11); rng.String() != "[10, 11]" {
+    t.Errorf("Unexpected range %v containing 11", rng)
+  }
+  slots.Exclude(HalfOpenInterval(11, 14))
+  if slots.String() != "[([9, 10), work), ([10, 11), meeting), ([14, 18), work)]" {
+    t.Errorf("Unexpected range map %v", slots)
+  }
+  slots.Clear()
+  if !slots.IsEmpty() || !slots.Entries().IsEmpty() {
+    t.Errorf("Expected range map to be empty after Clear")
+  }
+}
@@ -0,0 +1,95 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+
+
+// SortedMap is a Map whose keys are ordered by a Comparison function. Its
+// iterator returns the entries in ascending order of their keys. In addition
+// to the Map functionality, SortedMap provides methods for navigating the map
+// and for creating views of ranges of the map. Navigation methods return nil
+// if there is no matching entry.
+type SortedMap interface {
+  Map
+
+  // Comparison returns the function that defines the order of the keys.
+  Comparison() Comparison
+
+  // FirstEntry returns the entry with the smallest key.
+  FirstEntry() MapEntry
+
+  // LastEntry returns the entry with the largest key.
+  LastEntry() MapEntry
+
+  // FloorEntry returns the entry with the largest key less than or equal to key.
+  FloorEntry(key interface{}) MapEntry
+
+  // CeilingEntry returns the entry with the smallest key greater than or equal
+  // to key.
+  CeilingEntry(key interface{}) MapEntry
+
+  // LowerEntry returns the entry with the largest key strictly less than key.
+  LowerEntry(key interface{}) MapEntry
+
+  // HigherEntry returns the entry with the smallest key strictly greater than key.
+  HigherEntry(key interface{}) MapEntry
+
+  // SubMap returns a live view of all entries whose keys range from 'from'
+  // (inclusive) to 'to' (exclusive).
+  SubMap(from, to interface{}) DependentSortedMap
+
+  // HeadMap returns a live view of all entries whose keys are strictly less
+  // than 'to'.
+  HeadMap(to interface{}) DependentSortedMap
+
+  // TailMap returns a live view of all entries whose keys are greater than or
+  // equal to 'from'.
+  TailMap(from interface{}) DependentSortedMap
+
+  // DescendingMap returns a live view of this map in which the order of the
+  // keys is reversed.
+  DescendingMap() DependentSortedMap
+}
+
+// DependentSortedMap is a SortedMap which is a view of another SortedMap.
+type DependentSortedMap interface {
+  SortedMap
+}
+
+// MutableSortedMap is a SortedMap that can be changed by including and
+// excluding entries.
+type MutableSortedMap interface {
+  MutableMap
+  SortedMap
+
+  // PollFirstEntry removes and returns the entry with the smallest key. Like
+  // PollFirst of sorted sets, it returns nil if the map is empty.
+  PollFirstEntry() MapEntry
+
+  // PollLastEntry removes and returns the entry with the largest key. Like
+  // PollLast of sorted sets, it returns nil if the map is empty.
+  PollLastEntry() MapEntry
+}
+
+// SortedMapClass defines the functionality of MutableSortedMap implementations.
+// In addition to the MutableMapClass methods, which return MutableMap values,
+// it provides factory methods returning MutableSortedMap values.
+type SortedMapClass interface {
+  MutableMapClass
+  Comparison() Comparison
+  NewSorted(entries... MapEntry) MutableSortedMap
+  FromSorted(coll Container) MutableSortedMap
+}
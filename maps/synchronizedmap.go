@@ -19,6 +19,12 @@ import . "github.com/objecthub/containerkit/sets"
 import "sync"
 
 
+// SynchronizedMap returns a class of maps which guard maps of class with a
+// read/write lock. Derived operations, including the compute operations of
+// MutableMap, are executed atomically while the lock is held. This does not
+// hold for a synchronized map which is embedded by another map: its derived
+// operations dispatch to the embedding map, which calls back into the
+// primitives of the synchronized map, so only the primitives are atomic.
 func SynchronizedMap(class MutableMapClass) MutableMapClass {
   return &synchronizedMapClass{class}
 }
@@ -36,6 +42,18 @@ func (this *synchronizedMapClass) Embed(obj MutableMap) MutableMap {
   res.class = this
   res.unsync = this.class.Embed(obj)
   res.unsynchronizedMap = res.unsync
+  if obj == res {
+    locked := &lockedMap{MutableMapBase: res.unsync, class: this}
+    locked.MutableMapDerived = EmbeddedMutableMap(locked)
+    res.derived = locked
+    res.derivedMutex = &res.mutex
+  } else {
+    // Derived methods dispatch to obj, which acquires the lock for every
+    // primitive, so they must not hold the lock themselves. They are not
+    // atomic; the separate mutex only keeps the locking code uniform.
+    res.derived = res.unsync
+    res.derivedMutex = new(sync.RWMutex)
+  }
   return res
 }
 
@@ -81,6 +99,7 @@ type unsynchronizedMap interface {
   RestrictTo(domain Set) DependentMap
   MapValues(f Mapping) DependentMap
   Override(base Map) DependentMap
+  MergeWith(other Map, resolve Resolution) DependentMap
 }
 
 type synchronizedMap struct {
@@ -88,9 +107,26 @@ type synchronizedMap struct {
   class MutableMapClass
   mutex sync.RWMutex
   unsync MutableMap
+  derived MutableMap
+  derivedMutex *sync.RWMutex
   unsynchronizedMap
 }
 
+// lockedMap implements the derived methods of a synchronized map which is
+// not embedded by another map. They are executed atomically while the lock
+// is held, dispatching to the primitives of the wrapped map directly, such
+// that they do not call back into the synchronized map. Maps created by
+// derived methods like Copy belong to the class of the synchronized map.
+type lockedMap struct {
+  MutableMapBase
+  MutableMapDerived
+  class MutableMapClass
+}
+
+func (this *lockedMap) Class() MutableMapClass {
+  return this.class
+}
+
 func (this *synchronizedMap) Size() int {
   this.mutex.RLock()
   defer this.mutex.RUnlock()
@@ -104,15 +140,15 @@ func (this *synchronizedMap) Get(key interface{}) (value interface{}, exists boo
 }
 
 func (this *synchronizedMap) HasKey(key interface{}) bool {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.HasKey(key)
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.HasKey(key)
 }
 
 func (this *synchronizedMap) GetValue(key interface{}) interface{} {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.GetValue(key)
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.GetValue(key)
 }
 
 func (this *synchronizedMap) Include(key, value interface{}) {
@@ -128,39 +164,81 @@ func (this *synchronizedMap) Exclude(key... interface{}) {
 }
 
 func (this *synchronizedMap) IncludeEntry(entries... MapEntry) {
-  this.mutex.Lock()
-  defer this.mutex.Unlock()
-  this.unsync.IncludeEntry(entries...)
+  this.derivedMutex.Lock()
+  defer this.derivedMutex.Unlock()
+  this.derived.IncludeEntry(entries...)
 }
 
 func (this *synchronizedMap) IncludeFrom(entries Container) {
-  this.mutex.Lock()
-  defer this.mutex.Unlock()
-  this.unsync.IncludeFrom(entries)
+  this.derivedMutex.Lock()
+  defer this.derivedMutex.Unlock()
+  this.derived.IncludeFrom(entries)
 }
 
 func (this *synchronizedMap) IncludeFromNative(mp map[interface{}] interface{}) {
-  this.mutex.Lock()
-  defer this.mutex.Unlock()
-  this.unsync.IncludeFromNative(mp)
+  this.derivedMutex.Lock()
+  defer this.derivedMutex.Unlock()
+  this.derived.IncludeFromNative(mp)
 }
 
 func (this *synchronizedMap) ExcludeKeys(keys Container) {
-  this.mutex.Lock()
-  defer this.mutex.Unlock()
-  this.unsync.ExcludeKeys(keys)
+  this.derivedMutex.Lock()
+  defer this.derivedMutex.Unlock()
+  this.derived.ExcludeKeys(keys)
+}
+
+func (this *synchronizedMap) GetOrInclude(key, value interface{}) interface{} {
+  this.derivedMutex.Lock()
+  defer this.derivedMutex.Unlock()
+  return this.derived.GetOrInclude(key, value)
+}
+
+func (this *synchronizedMap) ComputeIfAbsent(key interface{}, f Mapping) interface{} {
+  this.derivedMutex.Lock()
+  defer this.derivedMutex.Unlock()
+  return this.derived.ComputeIfAbsent(key, f)
+}
+
+func (this *synchronizedMap) ComputeIfPresent(key interface{}, f Remapping) (value interface{}, exists bool) {
+  this.derivedMutex.Lock()
+  defer this.derivedMutex.Unlock()
+  return this.derived.ComputeIfPresent(key, f)
+}
+
+func (this *synchronizedMap) Compute(key interface{}, f Remapping) (value interface{}, exists bool) {
+  this.derivedMutex.Lock()
+  defer this.derivedMutex.Unlock()
+  return this.derived.Compute(key, f)
+}
+
+func (this *synchronizedMap) Merge(key, value interface{}, combine Binop) interface{} {
+  this.derivedMutex.Lock()
+  defer this.derivedMutex.Unlock()
+  return this.derived.Merge(key, value, combine)
+}
+
+func (this *synchronizedMap) Apply(diff *MapDiff) {
+  this.derivedMutex.Lock()
+  defer this.derivedMutex.Unlock()
+  this.derived.Apply(diff)
+}
+
+func (this *synchronizedMap) Diff(other Map, equals Equality) *MapDiff {
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.Diff(other, equals)
 }
 
 func (this *synchronizedMap) ProjectValues(proj Mapping) MutableMap {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.ProjectValues(proj)
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.ProjectValues(proj)
 }
 
 func (this *synchronizedMap) FilterKeys(pred Predicate) MutableMap {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.FilterKeys(pred)
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.FilterKeys(pred)
 }
 
 func (this *synchronizedMap) Class() MutableMapClass {
@@ -168,57 +246,57 @@ func (this *synchronizedMap) Class() MutableMapClass {
 }
 
 func (this *synchronizedMap) Copy() MutableMap {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.Copy()
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.Copy()
 }
 
 func (this *synchronizedMap) IsEmpty() bool {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.IsEmpty()
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.IsEmpty()
 }
 
 func (this *synchronizedMap) Exists(pred Predicate) bool {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.Exists(pred)
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.Exists(pred)
 }
 
 func (this *synchronizedMap) ForAll(pred Predicate) bool {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.ForAll(pred)
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.ForAll(pred)
 }
 
 func (this *synchronizedMap) ForEach(proc Procedure) {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  this.unsync.ForEach(proc)
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  this.derived.ForEach(proc)
 }
 
 func (this *synchronizedMap) FoldLeft(f Binop, z interface{}) interface{} {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.FoldLeft(f, z)
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.FoldLeft(f, z)
 }
 
 func (this *synchronizedMap) FoldRight(f Binop, z interface{}) interface{} {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.FoldRight(f, z)
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.FoldRight(f, z)
 }
 
 func (this *synchronizedMap) Force() FiniteContainer {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.Force()
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.Force()
 }
 
 func (this *synchronizedMap) Freeze() FiniteContainer {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.Freeze()
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.Freeze()
 }
 
 func (this *synchronizedMap) Clear() {
@@ -228,7 +306,7 @@ func (this *synchronizedMap) Clear() {
 }
 
 func (this *synchronizedMap) String() string {
-  this.mutex.RLock()
-  defer this.mutex.RUnlock()
-  return this.unsync.String()
+  this.derivedMutex.RLock()
+  defer this.derivedMutex.RUnlock()
+  return this.derived.String()
 }
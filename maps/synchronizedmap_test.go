@@ -0,0 +1,84 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "sync"
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func TestSynchronizedMapAtomicUpdates(t *testing.T) {
+  m := SynchronizedMap(HashMap).New()
+  sum := func (x, y interface{}) interface{} { return x.(int) + y.(int) }
+  var group sync.WaitGroup
+  for i := 0; i < 8; i++ {
+    group.Add(1)
+    go func () {
+      defer group.Done()
+      for j := 0; j < 1000; j++ {
+        m.Merge(j % 10, 1, sum)
+        m.ComputeIfAbsent(-1, func (key interface{}) interface{} { return 0 })
+        m.Compute(-1, func (key, value interface{}, exists bool) (interface{}, bool) {
+          return value.(int) + 1, true
+        })
+      }
+    }()
+  }
+  group.Wait()
+  checkSize(t, m, 11, "m")
+  for i := 0; i < 10; i++ {
+    if value := m.GetValue(i); value != 800 {
+      t.Errorf("Expected counter %d to be 800; was %v", i, value)
+    }
+  }
+  if value := m.GetValue(-1); value != 8000 {
+    t.Errorf("Expected counter -1 to be 8000; was %v", value)
+  }
+  m.IncludeEntry(KV(20, 20))
+  m.IncludeFrom(Enum.New(KV(21, 21)))
+  checkSize(t, m, 13, "m")
+}
+
+func TestSynchronizedMapDerivedClass(t *testing.T) {
+  m := SynchronizedMap(HashMap).New(KV(1, 10), KV(2, 20))
+  derived := map[string]MutableMap{
+    "Copy": m.Copy(),
+    "ProjectValues": m.ProjectValues(func (x interface{}) interface{} { return x }),
+    "FilterKeys": m.FilterKeys(func (x interface{}) bool { return true }),
+  }
+  for name, res := range derived {
+    if _, synchronized := res.(*synchronizedMap); !synchronized {
+      t.Errorf("Expected %s of synchronized map to be synchronized; was %T", name, res)
+    }
+    checkSize(t, res, 2, name)
+  }
+}
+
+func TestEmbeddedSynchronizedMapCompute(t *testing.T) {
+  class := ObservableMap(SynchronizedMap(HashMap), nil)
+  m := class.New(KV(1, 10))
+  sum := func (x, y interface{}) interface{} { return x.(int) + y.(int) }
+  m.Merge(1, 5, sum)
+  m.Merge(2, 7, sum)
+  if value := m.ComputeIfAbsent(3, func (key interface{}) interface{} { return 30 }); value != 30 {
+    t.Errorf("Expected ComputeIfAbsent to return 30; was %v", value)
+  }
+  m.Compute(3, func (key, value interface{}, exists bool) (interface{}, bool) {
+    return nil, false
+  })
+  if m.GetValue(1) != 15 || m.GetValue(2) != 7 || m.HasKey(3) {
+    t.Errorf("Unexpected result %v of compute operations on embedded synchronized map", m)
+  }
+}
@@ -0,0 +1,271 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// TinyLfuCache is a cache class implementing the W-TinyLFU policy by Einziger,
+// Friedman and Manes. New entries enter a small LRU window. Entries leaving
+// the window are only admitted to the main cache if they were accessed more
+// often than the entry the main cache would evict instead. Access frequencies
+// are estimated approximately by a count-min sketch, which also remembers
+// the keys of entries that are not cached anymore. The main cache is a
+// segmented LRU cache with a probation and a protected segment.
+var TinyLfuCache CacheClass = TinyLfuCacheClass(UniversalHash, UniversalEquality)
+
+func TinyLfuCacheClass(hash Hashfunction, equals Equality) CacheClass {
+  return &tinyLfuCacheClass{hash, equals}
+}
+
+type tinyLfuCacheClass struct {
+  hash Hashfunction
+  equals Equality
+}
+
+func (this *tinyLfuCacheClass) Embed(obj Cache, capacity int, we func (kv MapEntry)) Cache {
+  res := new(tinyLfuCache)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.CacheDerived = EmbeddedCache(obj)
+  res.class = this
+  res.capacity = capacity
+  res.windowCapacity = max(1, capacity / 100)
+  res.protectedCapacity = (capacity - res.windowCapacity) * 4 / 5
+  res.whenEvicted = we
+  res.table = impl.NewHashTable(17, 80, this.hash, this.equals)
+  for i := range res.queues {
+    res.queues[i] = impl.NewDoubleLinkedList()
+  }
+  res.sketch = newFrequencySketch(capacity)
+  return res
+}
+
+func (this *tinyLfuCacheClass) New(capacity int) Cache {
+  return this.Embed(nil, capacity, nil)
+}
+
+func (this *tinyLfuCacheClass) NewWithCallback(capacity int, we func (kv MapEntry)) Cache {
+  return this.Embed(nil, capacity, we)
+}
+
+// Queues of a W-TinyLFU cache
+const (
+  tinyLfuWindow = iota
+  tinyLfuProbation
+  tinyLfuProtected
+)
+
+type tinyLfuCache struct {
+  obj Cache
+  class *tinyLfuCacheClass
+  capacity int
+  windowCapacity int
+  protectedCapacity int
+  whenEvicted func (kv MapEntry)
+  table *impl.HashTable
+  queues [3]*impl.DoubleLinkedList
+  sketch *frequencySketch
+  cacheStats
+  CacheDerived
+}
+
+func (this *tinyLfuCache) Size() int {
+  return this.table.Size()
+}
+
+func (this *tinyLfuCache) Get(key interface{}) (value interface{}, exists bool) {
+  this.sketch.Increment(this.table.Hash()(key))
+  if entry := this.table.FindEntry(key); entry != nil {
+    node := entry.Value.(*cacheNode)
+    this.touch(node)
+    this.StatsCounter().RecordHit()
+    return node.value, true
+  }
+  this.StatsCounter().RecordMiss()
+  return nil, false
+}
+
+func (this *tinyLfuCache) Elements() Iterator {
+  return newCacheQueueIterator(this.queues[tinyLfuWindow],
+                               this.queues[tinyLfuProbation],
+                               this.queues[tinyLfuProtected])
+}
+
+func (this *tinyLfuCache) Class() CacheClass {
+  return this.class
+}
+
+func (this *tinyLfuCache) Add(key, value interface{}) {
+  if this.capacity <= 0 {
+    this.StatsCounter().RecordEviction(EvictedBySize)
+    if this.whenEvicted != nil {
+      this.whenEvicted(KV(key, value))
+    }
+    return
+  }
+  this.sketch.Increment(this.table.Hash()(key))
+  if entry := this.table.FindEntry(key); entry != nil {
+    node := entry.Value.(*cacheNode)
+    node.value = value
+    this.touch(node)
+    return
+  }
+  node := newCacheNode(key, value, tinyLfuWindow)
+  this.table.AddEntry(key, node)
+  this.queues[tinyLfuWindow].InsertFront(node.elem)
+  if this.queues[tinyLfuWindow].Length() > this.windowCapacity {
+    this.admit(this.queues[tinyLfuWindow].Back().Value.(*cacheNode))
+  }
+}
+
+func (this *tinyLfuCache) Remove(keys ...interface{}) {
+  for _, key := range keys {
+    if entry := this.table.FindEntry(key); entry != nil {
+      node := entry.Value.(*cacheNode)
+      this.queues[node.queue].Remove(node.elem)
+      this.table.DeleteEntry(key)
+    }
+  }
+}
+
+func (this *tinyLfuCache) Clear() {
+  this.table.Clear()
+  for i := range this.queues {
+    this.queues[i] = impl.NewDoubleLinkedList()
+  }
+  this.sketch.Clear()
+}
+
+func (this *tinyLfuCache) move(node *cacheNode, queue int) {
+  this.queues[node.queue].Remove(node.elem)
+  node.queue = queue
+  this.queues[queue].InsertFront(node.elem)
+}
+
+// touch records an access of node. Entries on probation get protected; if
+// the protected segment overflows, its least recently used entry is put back
+// on probation.
+func (this *tinyLfuCache) touch(node *cacheNode) {
+  if node.queue == tinyLfuWindow {
+    this.move(node, tinyLfuWindow)
+    return
+  }
+  this.move(node, tinyLfuProtected)
+  if protected := this.queues[tinyLfuProtected]; protected.Length() > this.protectedCapacity {
+    this.move(protected.Back().Value.(*cacheNode), tinyLfuProbation)
+  }
+}
+
+// admit moves candidate from the window to the main cache if there is room.
+// Otherwise either candidate or the entry the main cache would evict is
+// evicted, depending on which was accessed less frequently.
+func (this *tinyLfuCache) admit(candidate *cacheNode) {
+  main := this.capacity - this.windowCapacity
+  if this.queues[tinyLfuProbation].Length() + this.queues[tinyLfuProtected].Length() < main {
+    this.move(candidate, tinyLfuProbation)
+    return
+  }
+  victim := candidate
+  if main > 0 {
+    queue := this.queues[tinyLfuProbation]
+    if queue.Length() == 0 {
+      queue = this.queues[tinyLfuProtected]
+    }
+    victim = queue.Back().Value.(*cacheNode)
+    hash := this.table.Hash()
+    if this.sketch.Frequency(hash(candidate.key)) > this.sketch.Frequency(hash(victim.key)) {
+      this.move(candidate, tinyLfuProbation)
+    } else {
+      victim = candidate
+    }
+  }
+  this.queues[victim.queue].Remove(victim.elem)
+  this.table.DeleteEntry(victim.key)
+  this.StatsCounter().RecordEviction(EvictedBySize)
+  if this.whenEvicted != nil {
+    this.whenEvicted(victim.entry())
+  }
+}
+
+// frequencySketch is a count-min sketch estimating the access frequency of
+// keys with four rows of saturating 4-bit counters. Once the number of
+// increments reaches a sample size proportional to the capacity of the
+// cache, all counters are halved so that old accesses fade out.
+type frequencySketch struct {
+  counters []uint8
+  mask uint64
+  additions int
+  sampleSize int
+}
+
+const frequencySketchDepth = 4
+
+func newFrequencySketch(capacity int) *frequencySketch {
+  width := 16
+  for width < capacity {
+    width <<= 1
+  }
+  return &frequencySketch{make([]uint8, frequencySketchDepth * width),
+                          uint64(width - 1),
+                          0,
+                          10 * width}
+}
+
+func (this *frequencySketch) index(hash int, row int) int {
+  h := uint64(hash) + uint64(row) * 0x9e3779b97f4a7c15
+  h ^= h >> 33
+  h *= 0xff51afd7ed558ccd
+  h ^= h >> 33
+  return row * int(this.mask + 1) + int(h & this.mask)
+}
+
+// Increment counts an access of a key with the given hash.
+func (this *frequencySketch) Increment(hash int) {
+  added := false
+  for row := 0; row < frequencySketchDepth; row++ {
+    if i := this.index(hash, row); this.counters[i] < 15 {
+      this.counters[i]++
+      added = true
+    }
+  }
+  if added {
+    this.additions++
+    if this.additions >= this.sampleSize {
+      for i := range this.counters {
+        this.counters[i] >>= 1
+      }
+      this.additions /= 2
+    }
+  }
+}
+
+// Frequency returns the estimated number of accesses of a key with the
+// given hash.
+func (this *frequencySketch) Frequency(hash int) int {
+  res := 15
+  for row := 0; row < frequencySketchDepth; row++ {
+    res = min(res, int(this.counters[this.index(hash, row)]))
+  }
+  return res
+}
+
+func (this *frequencySketch) Clear() {
+  clear(this.counters)
+  this.additions = 0
+}
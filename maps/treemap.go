@@ -0,0 +1,243 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+var TreeMap SortedMapClass = TreeMapClass(UniversalComparison)
+
+var ImmutableTreeMap MapClass = ImmutableMap(TreeMap)
+
+func TreeMapClass(comp Comparison) SortedMapClass {
+  return &treeMapClass{comp}
+}
+
+type treeMapClass struct {
+  comp Comparison
+}
+
+func (this *treeMapClass) Comparison() Comparison {
+  return this.comp
+}
+
+func (this *treeMapClass) Embed(obj MutableMap) MutableMap {
+  res := new(treeMap)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableMapDerived = EmbeddedMutableMap(obj)
+  res.treeMapRange = treeMapRange{
+      impl.NewTreeRange(impl.NewAvlTree(this.comp), impl.TreeBound{}, impl.TreeBound{}), false}
+  return res
+}
+
+func (this *treeMapClass) New(entries... MapEntry) MutableMap {
+  return this.NewSorted(entries...)
+}
+
+func (this *treeMapClass) From(coll Container) MutableMap {
+  return this.FromSorted(coll)
+}
+
+func (this *treeMapClass) FromNative(mp map[interface{}] interface{}) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFromNative(mp)
+  return res
+}
+
+func (this *treeMapClass) NewSorted(entries... MapEntry) MutableSortedMap {
+  res := this.Embed(nil).(*treeMap)
+  res.IncludeEntry(entries...)
+  return res
+}
+
+func (this *treeMapClass) FromSorted(coll Container) MutableSortedMap {
+  res := this.Embed(nil).(*treeMap)
+  res.IncludeFrom(coll)
+  return res
+}
+
+type treeMap struct {
+  obj MutableMap
+  treeMapRange
+  MutableMapDerived
+}
+
+func (this *treeMap) Class() MutableMapClass {
+  return TreeMapClass(this.Tree().Comparison())
+}
+
+func (this *treeMap) Include(key, value interface{}) {
+  if node, inserted := this.Tree().InsertNode(key, value); !inserted {
+    node.Value = value
+  }
+}
+
+func (this *treeMap) Exclude(keys ...interface{}) {
+  for _, key := range keys {
+    this.Tree().DeleteKey(key)
+  }
+}
+
+func (this *treeMap) Clear() {
+  this.Tree().Clear()
+}
+
+func (this *treeMap) PollFirstEntry() MapEntry {
+  node := this.Tree().FirstNode()
+  if node == nil {
+    return nil
+  }
+  this.Tree().DeleteNode(node)
+  return KV(node.Key, node.Value)
+}
+
+func (this *treeMap) PollLastEntry() MapEntry {
+  node := this.Tree().LastNode()
+  if node == nil {
+    return nil
+  }
+  this.Tree().DeleteNode(node)
+  return KV(node.Key, node.Value)
+}
+
+// Range views
+
+func newTreeSubMap(rng impl.TreeRange, descending bool) DependentSortedMap {
+  res := new(treeSubMap)
+  res.MapDerived = EmbeddedDependentMap(res)
+  res.treeMapRange = treeMapRange{rng, descending}
+  return res
+}
+
+type treeSubMap struct {
+  MapDerived
+  treeMapRange
+}
+
+// treeMapRange implements the read-only functionality of sorted maps on top
+// of a TreeRange. If descending is true, the order of the range is reversed.
+type treeMapRange struct {
+  impl.TreeRange
+  descending bool
+}
+
+func (this *treeMapRange) Get(key interface{}) (value interface{}, exists bool) {
+  if node := this.FindNode(key); node != nil {
+    return node.Value, true
+  }
+  return nil, false
+}
+
+func (this *treeMapRange) Elements() Iterator {
+  return &treeMapIterator{this.Nodes(!this.descending)}
+}
+
+func (this *treeMapRange) Comparison() Comparison {
+  if this.descending {
+    return InvertComparison(this.Tree().Comparison())
+  }
+  return this.Tree().Comparison()
+}
+
+func (this *treeMapRange) FirstEntry() MapEntry {
+  if this.descending {
+    return nodeEntry(this.LastNode())
+  }
+  return nodeEntry(this.FirstNode())
+}
+
+func (this *treeMapRange) LastEntry() MapEntry {
+  if this.descending {
+    return nodeEntry(this.FirstNode())
+  }
+  return nodeEntry(this.LastNode())
+}
+
+func (this *treeMapRange) FloorEntry(key interface{}) MapEntry {
+  if this.descending {
+    return nodeEntry(this.CeilingNode(key, true))
+  }
+  return nodeEntry(this.FloorNode(key, true))
+}
+
+func (this *treeMapRange) CeilingEntry(key interface{}) MapEntry {
+  if this.descending {
+    return nodeEntry(this.FloorNode(key, true))
+  }
+  return nodeEntry(this.CeilingNode(key, true))
+}
+
+func (this *treeMapRange) LowerEntry(key interface{}) MapEntry {
+  if this.descending {
+    return nodeEntry(this.CeilingNode(key, false))
+  }
+  return nodeEntry(this.FloorNode(key, false))
+}
+
+func (this *treeMapRange) HigherEntry(key interface{}) MapEntry {
+  if this.descending {
+    return nodeEntry(this.FloorNode(key, false))
+  }
+  return nodeEntry(this.CeilingNode(key, false))
+}
+
+// restrict returns a view of this range restricted to the keys between from
+// and to in the order of this range.
+func (this *treeMapRange) restrict(from, to impl.TreeBound) DependentSortedMap {
+  if this.descending {
+    return newTreeSubMap(this.Restrict(to, from), true)
+  }
+  return newTreeSubMap(this.Restrict(from, to), false)
+}
+
+func (this *treeMapRange) SubMap(from, to interface{}) DependentSortedMap {
+  return this.restrict(impl.InclusiveBound(from), impl.ExclusiveBound(to))
+}
+
+func (this *treeMapRange) HeadMap(to interface{}) DependentSortedMap {
+  return this.restrict(impl.TreeBound{}, impl.ExclusiveBound(to))
+}
+
+func (this *treeMapRange) TailMap(from interface{}) DependentSortedMap {
+  return this.restrict(impl.InclusiveBound(from), impl.TreeBound{})
+}
+
+func (this *treeMapRange) DescendingMap() DependentSortedMap {
+  return newTreeSubMap(this.TreeRange, !this.descending)
+}
+
+func nodeEntry(node *impl.TreeNode) MapEntry {
+  if node == nil {
+    return nil
+  }
+  return KV(node.Key, node.Value)
+}
+
+type treeMapIterator struct {
+  nodeIter *impl.TreeNodeIterator
+}
+
+func (this *treeMapIterator) HasNext() bool {
+  return this.nodeIter.HasNext()
+}
+
+func (this *treeMapIterator) Next() interface{} {
+  node := this.nodeIter.Next()
+  return KV(node.Key, node.Value)
+}
@@ -0,0 +1,77 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "testing"
+
+
+func checkEntry(t *testing.T, entry MapEntry, key interface{}, name string) {
+  if key == nil && entry != nil {
+    t.Errorf("Expected no entry for %s; was %v", name, entry)
+  } else if key != nil && (entry == nil || entry.Key() != key) {
+    t.Errorf("Expected entry with key %v for %s; was %v", key, name, entry)
+  }
+}
+
+func TestTreeMapClass(t *testing.T) {
+  m := TreeMap.NewSorted(KV(50, "e"), KV(10, "a"), KV(30, "c"), KV(20, "b"), KV(40, "d"))
+  checkKeyOrder(t, m, 10, 20, 30, 40, 50)
+  m.Include(30, "C")
+  if m.GetValue(30) != "C" || m.Size() != 5 {
+    t.Errorf("Expected 30 to be remapped to C")
+  }
+  checkEntry(t, m.FirstEntry(), 10, "FirstEntry")
+  checkEntry(t, m.LastEntry(), 50, "LastEntry")
+  checkEntry(t, m.FloorEntry(35), 30, "FloorEntry(35)")
+  checkEntry(t, m.FloorEntry(5), nil, "FloorEntry(5)")
+  checkEntry(t, m.CeilingEntry(30), 30, "CeilingEntry(30)")
+  checkEntry(t, m.LowerEntry(30), 20, "LowerEntry(30)")
+  checkEntry(t, m.HigherEntry(50), nil, "HigherEntry(50)")
+  checkEntry(t, m.PollFirstEntry(), 10, "PollFirstEntry")
+  checkEntry(t, m.PollLastEntry(), 50, "PollLastEntry")
+  checkKeyOrder(t, m, 20, 30, 40)
+  m.Clear()
+  checkEntry(t, m.PollFirstEntry(), nil, "PollFirstEntry")
+}
+
+func TestTreeMapViews(t *testing.T) {
+  m := TreeMap.NewSorted()
+  for i := 0; i < 10; i++ {
+    m.Include(i * 10, i)
+  }
+  sub := m.SubMap(20, 60)
+  checkKeyOrder(t, sub, 20, 30, 40, 50)
+  checkKeyOrder(t, m.HeadMap(30), 0, 10, 20)
+  checkKeyOrder(t, m.TailMap(75), 80, 90)
+  if sub.HasKey(60) || sub.GetValue(40) != 4 {
+    t.Errorf("SubMap does not respect its bounds")
+  }
+  m.Include(25, 25)
+  m.Exclude(40)
+  checkKeyOrder(t, sub, 20, 25, 30, 50)
+  checkEntry(t, sub.HigherEntry(50), nil, "sub.HigherEntry(50)")
+  desc := m.DescendingMap()
+  checkEntry(t, desc.FirstEntry(), 90, "desc.FirstEntry")
+  checkEntry(t, desc.FloorEntry(45), 50, "desc.FloorEntry(45)")
+  checkEntry(t, desc.HigherEntry(30), 25, "desc.HigherEntry(30)")
+  checkKeyOrder(t, desc.SubMap(50, 20), 50, 30, 25)
+  checkKeyOrder(t, desc.HeadMap(70), 90, 80)
+  checkKeyOrder(t, desc.TailMap(10), 10, 0)
+  checkKeyOrder(t, desc.DescendingMap().HeadMap(20), 0, 10)
+  if desc.Comparison()(1, 2) <= 0 {
+    t.Errorf("Expected descending map to invert comparison")
+  }
+  checkMapSize(t, sub, 4, "sub")
+}
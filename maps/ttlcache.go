@@ -0,0 +1,458 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "sync"
+import "time"
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// ExpiringCache is a Cache whose entries expire after a time-to-live (TTL).
+// Expired entries are never returned. They are removed lazily when they are
+// encountered, when the cache is accessed via Size, Elements or Add, by an
+// explicit CleanUp, or periodically by a background goroutine. Every expired
+// entry that gets removed is passed to the eviction callback of the cache.
+// Like other caches, an ExpiringCache evicts the least recently used entry
+// when it exceeds its capacity.
+//
+// Unlike other caches, expiring caches are safe for concurrent use, since
+// background cleanup modifies them concurrently. The eviction callback is
+// invoked without holding the lock of the cache.
+type ExpiringCache interface {
+  Cache
+
+  // AddWithTTL adds a mapping from key to value which expires after ttl. A
+  // non-positive ttl makes the entry never expire.
+  AddWithTTL(key, value interface{}, ttl time.Duration)
+
+  // TTL returns the default time-to-live of entries added via Add.
+  TTL() time.Duration
+
+  // Mode returns whether entries expire after they were written or after
+  // they were accessed last.
+  Mode() ExpiryMode
+
+  // CleanUp removes all expired entries and returns their number.
+  CleanUp() int
+
+  // StartCleanup starts a goroutine which calls CleanUp periodically. The
+  // goroutine runs until StopCleanup is called.
+  StartCleanup(interval time.Duration)
+
+  // StopCleanup stops the background cleanup goroutine, if there is one.
+  StopCleanup()
+}
+
+// ExpiryMode defines what resets the time-to-live of an entry.
+type ExpiryMode int
+
+const (
+  // ExpireAfterWrite makes entries expire a fixed time after they were added.
+  ExpireAfterWrite ExpiryMode = iota
+
+  // ExpireAfterAccess makes entries expire a fixed time after they were added
+  // or looked up last.
+  ExpireAfterAccess
+)
+
+// ExpiringCacheClass defines the functionality of ExpiringCache
+// implementations. In addition to the CacheClass methods, which return Cache
+// values, it provides factory methods returning ExpiringCache values.
+type ExpiringCacheClass interface {
+  CacheClass
+  NewExpiring(capacity int) ExpiringCache
+  NewExpiringWithCallback(capacity int, whenEvicted func (kv MapEntry)) ExpiringCache
+}
+
+// WeightedExpiringCacheClass defines the functionality of ExpiringCache
+// implementations whose capacity is a maximum total weight. The caches it
+// creates implement both ExpiringCache and WeightedCache.
+type WeightedExpiringCacheClass interface {
+  ExpiringCacheClass
+  WeightedCacheClass
+}
+
+// TtlCacheClass returns a class of expiring caches whose entries expire by
+// default after ttl according to mode. Time is measured with clock.
+func TtlCacheClass(hash Hashfunction,
+                   equals Equality,
+                   ttl time.Duration,
+                   mode ExpiryMode,
+                   clock Clock) ExpiringCacheClass {
+  return &ttlCacheClass{hash, equals, ttl, mode, clock, nil}
+}
+
+// WeightedTtlCacheClass returns a class of expiring caches like TtlCacheClass
+// whose capacity is a maximum total weight. The weight of an entry is
+// determined by weigher.
+func WeightedTtlCacheClass(hash Hashfunction,
+                           equals Equality,
+                           ttl time.Duration,
+                           mode ExpiryMode,
+                           clock Clock,
+                           weigher Weigher) WeightedExpiringCacheClass {
+  return &ttlCacheClass{hash, equals, ttl, mode, clock, weigher}
+}
+
+type ttlCacheClass struct {
+  hash Hashfunction
+  equals Equality
+  ttl time.Duration
+  mode ExpiryMode
+  clock Clock
+  weigher Weigher
+}
+
+func (this *ttlCacheClass) Embed(obj Cache, capacity int, we func (kv MapEntry)) Cache {
+  res := new(ttlCache)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.CacheDerived = EmbeddedCache(obj)
+  res.class = this
+  res.capacity = capacity
+  res.whenEvicted = we
+  res.table = impl.NewHashTable(17, 80, this.hash, this.equals)
+  res.accessorder = impl.NewDoubleLinkedList()
+  res.expiries = newExpiryHeap()
+  return res
+}
+
+func (this *ttlCacheClass) New(capacity int) Cache {
+  return this.Embed(nil, capacity, nil)
+}
+
+func (this *ttlCacheClass) NewWithCallback(capacity int, we func (kv MapEntry)) Cache {
+  return this.Embed(nil, capacity, we)
+}
+
+func (this *ttlCacheClass) NewExpiring(capacity int) ExpiringCache {
+  return this.Embed(nil, capacity, nil).(*ttlCache)
+}
+
+func (this *ttlCacheClass) NewExpiringWithCallback(capacity int,
+                                                   we func (kv MapEntry)) ExpiringCache {
+  return this.Embed(nil, capacity, we).(*ttlCache)
+}
+
+func (this *ttlCacheClass) NewWeighted(maxWeight int) WeightedCache {
+  return this.Embed(nil, maxWeight, nil).(*ttlCache)
+}
+
+func (this *ttlCacheClass) NewWeightedWithCallback(maxWeight int,
+                                                   we func (kv MapEntry)) WeightedCache {
+  return this.Embed(nil, maxWeight, we).(*ttlCache)
+}
+
+// ttlEntry is the value of the hash table of a ttlCache. It is also the value
+// of its element in the access order list. A zero expires value denotes an
+// entry that never expires.
+type ttlEntry struct {
+  key interface{}
+  value interface{}
+  ttl time.Duration
+  expires time.Time
+  weight int
+  elem *impl.Element
+  removed bool
+}
+
+func (this *ttlEntry) expired(now time.Time) bool {
+  return !this.expires.IsZero() && !this.expires.After(now)
+}
+
+func (this *ttlEntry) touch(now time.Time) {
+  if this.ttl > 0 {
+    this.expires = now.Add(this.ttl)
+  } else {
+    this.expires = time.Time{}
+  }
+}
+
+// expiryRecord is an element of the expiry heap. Records are never updated;
+// whenever the expiry time of an entry changes, a new record is added. Records
+// whose expiry time does not match their entry are stale and get skipped.
+type expiryRecord struct {
+  expires time.Time
+  entry *ttlEntry
+}
+
+func (this *expiryRecord) stale() bool {
+  return this.entry.removed || !this.expires.Equal(this.entry.expires)
+}
+
+func newExpiryHeap() *impl.Heap {
+  return impl.NewHeap(func (x, y interface{}) int {
+    return y.(*expiryRecord).expires.Compare(x.(*expiryRecord).expires)
+  })
+}
+
+// ttlCache evicts the least recently used entries when the total weight of
+// its entries exceeds its capacity. Without a weigher, every entry has
+// weight 1, so that the capacity limits the number of entries.
+type ttlCache struct {
+  obj Cache
+  class *ttlCacheClass
+  capacity int
+  weight int
+  whenEvicted func (kv MapEntry)
+  mutex sync.Mutex
+  table *impl.HashTable
+  accessorder *impl.DoubleLinkedList
+  expiries *impl.Heap
+  stop chan bool
+  cacheStats
+  CacheDerived
+}
+
+func (this *ttlCache) TTL() time.Duration {
+  return this.class.ttl
+}
+
+func (this *ttlCache) Mode() ExpiryMode {
+  return this.class.mode
+}
+
+func (this *ttlCache) Class() CacheClass {
+  return this.class
+}
+
+// notify passes evicted entries to the eviction callback. It must be called
+// without holding the lock.
+func (this *ttlCache) notify(evicted []MapEntry) {
+  if this.whenEvicted != nil {
+    for _, kv := range evicted {
+      this.whenEvicted(kv)
+    }
+  }
+}
+
+func (this *ttlCache) remove(entry *ttlEntry) {
+  entry.removed = true
+  this.accessorder.Remove(entry.elem)
+  this.table.DeleteEntry(entry.key)
+  this.weight -= entry.weight
+}
+
+// expire removes all entries that expired at time now and appends them to
+// evicted.
+func (this *ttlCache) expire(now time.Time, evicted []MapEntry) []MapEntry {
+  for this.expiries.Length() > 0 {
+    record := this.expiries.First().(*expiryRecord)
+    if record.expires.After(now) {
+      break
+    }
+    this.expiries.Next()
+    if !record.stale() {
+      this.remove(record.entry)
+      this.StatsCounter().RecordEviction(EvictedByExpiry)
+      evicted = append(evicted, KV(record.entry.key, record.entry.value))
+    }
+  }
+  return evicted
+}
+
+// schedule records the expiry time of entry. It rebuilds the expiry heap if
+// stale records make up the majority of it.
+func (this *ttlCache) schedule(entry *ttlEntry) {
+  if entry.expires.IsZero() {
+    return
+  }
+  if this.expiries.Length() > 2 * this.table.Size() + 32 {
+    this.expiries = newExpiryHeap()
+    for iter := this.table.Iterator(); iter.HasNext(); {
+      if other := iter.Next().Value.(*ttlEntry); !other.expires.IsZero() && other != entry {
+        this.expiries.Add(&expiryRecord{other.expires, other})
+      }
+    }
+  }
+  this.expiries.Add(&expiryRecord{entry.expires, entry})
+}
+
+func (this *ttlCache) Size() int {
+  this.mutex.Lock()
+  evicted := this.expire(this.class.clock.Now(), nil)
+  res := this.table.Size()
+  this.mutex.Unlock()
+  this.notify(evicted)
+  return res
+}
+
+func (this *ttlCache) Weight() int {
+  this.mutex.Lock()
+  evicted := this.expire(this.class.clock.Now(), nil)
+  res := this.weight
+  this.mutex.Unlock()
+  this.notify(evicted)
+  return res
+}
+
+func (this *ttlCache) MaxWeight() int {
+  return this.capacity
+}
+
+func (this *ttlCache) Get(key interface{}) (value interface{}, exists bool) {
+  var evicted []MapEntry
+  this.mutex.Lock()
+  if hashEntry := this.table.FindEntry(key); hashEntry != nil {
+    entry := hashEntry.Value.(*ttlEntry)
+    now := this.class.clock.Now()
+    if entry.expired(now) {
+      this.remove(entry)
+      this.StatsCounter().RecordEviction(EvictedByExpiry)
+      evicted = append(evicted, KV(entry.key, entry.value))
+    } else {
+      if this.class.mode == ExpireAfterAccess {
+        entry.touch(now)
+        this.schedule(entry)
+      }
+      this.accessorder.Remove(entry.elem)
+      this.accessorder.InsertFront(entry.elem)
+      value, exists = entry.value, true
+    }
+  }
+  if exists {
+    this.StatsCounter().RecordHit()
+  } else {
+    this.StatsCounter().RecordMiss()
+  }
+  this.mutex.Unlock()
+  this.notify(evicted)
+  return value, exists
+}
+
+func (this *ttlCache) Elements() Iterator {
+  this.mutex.Lock()
+  evicted := this.expire(this.class.clock.Now(), nil)
+  entries := make([]interface{}, 0, this.table.Size())
+  for iter := this.accessorder.Iterator(); iter.HasNext(); {
+    entry := iter.Next().(*ttlEntry)
+    entries = append(entries, KV(entry.key, entry.value))
+  }
+  this.mutex.Unlock()
+  this.notify(evicted)
+  return Enum.New(entries...).Elements()
+}
+
+func (this *ttlCache) Add(key, value interface{}) {
+  this.AddWithTTL(key, value, this.class.ttl)
+}
+
+func (this *ttlCache) AddWithTTL(key, value interface{}, ttl time.Duration) {
+  this.mutex.Lock()
+  now := this.class.clock.Now()
+  evicted := this.expire(now, nil)
+  weight := weigh(this.class.weigher, key, value)
+  hashEntry := this.table.FindEntry(key)
+  if weight > this.capacity {
+    if hashEntry != nil {
+      old := hashEntry.Value.(*ttlEntry)
+      this.remove(old)
+      this.StatsCounter().RecordEviction(EvictedBySize)
+      evicted = append(evicted, KV(old.key, old.value))
+    }
+    this.StatsCounter().RecordEviction(EvictedBySize)
+    evicted = append(evicted, KV(key, value))
+    this.mutex.Unlock()
+    this.notify(evicted)
+    return
+  }
+  var entry *ttlEntry
+  if hashEntry == nil {
+    entry = &ttlEntry{key: key}
+    entry.elem = impl.NewElement(entry)
+    this.table.AddEntry(key, entry)
+  } else {
+    entry = hashEntry.Value.(*ttlEntry)
+    this.accessorder.Remove(entry.elem)
+    this.weight -= entry.weight
+  }
+  this.accessorder.InsertFront(entry.elem)
+  entry.value = value
+  entry.ttl = ttl
+  entry.weight = weight
+  this.weight += weight
+  entry.touch(now)
+  this.schedule(entry)
+  for this.weight > this.capacity {
+    lru := this.accessorder.Back().Value.(*ttlEntry)
+    this.remove(lru)
+    this.StatsCounter().RecordEviction(EvictedBySize)
+    evicted = append(evicted, KV(lru.key, lru.value))
+  }
+  this.mutex.Unlock()
+  this.notify(evicted)
+}
+
+func (this *ttlCache) Remove(keys ...interface{}) {
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  for _, key := range keys {
+    if hashEntry := this.table.FindEntry(key); hashEntry != nil {
+      this.remove(hashEntry.Value.(*ttlEntry))
+    }
+  }
+}
+
+func (this *ttlCache) Clear() {
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  for iter := this.table.Iterator(); iter.HasNext(); {
+    iter.Next().Value.(*ttlEntry).removed = true
+  }
+  this.table.Clear()
+  this.accessorder = impl.NewDoubleLinkedList()
+  this.expiries = newExpiryHeap()
+  this.weight = 0
+}
+
+func (this *ttlCache) CleanUp() int {
+  this.mutex.Lock()
+  evicted := this.expire(this.class.clock.Now(), nil)
+  this.mutex.Unlock()
+  this.notify(evicted)
+  return len(evicted)
+}
+
+func (this *ttlCache) StartCleanup(interval time.Duration) {
+  this.StopCleanup()
+  stop := make(chan bool)
+  this.mutex.Lock()
+  this.stop = stop
+  this.mutex.Unlock()
+  go func () {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+      select {
+        case <-ticker.C:
+          this.CleanUp()
+        case <-stop:
+          return
+      }
+    }
+  }()
+}
+
+func (this *ttlCache) StopCleanup() {
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  if this.stop != nil {
+    close(this.stop)
+    this.stop = nil
+  }
+}
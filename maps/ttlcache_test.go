@@ -0,0 +1,161 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "testing"
+import "time"
+import . "github.com/objecthub/containerkit"
+
+
+func newTestTtlCache(mode ExpiryMode, capacity int) (ExpiringCache, *ManualClock, *[]MapEntry) {
+  clock := NewManualClock(time.Unix(1000, 0))
+  evicted := new([]MapEntry)
+  class := TtlCacheClass(UniversalHash, UniversalEquality, time.Minute, mode, clock)
+  cache := class.NewExpiringWithCallback(capacity, func (kv MapEntry) {
+    *evicted = append(*evicted, kv)
+  })
+  return cache, clock, evicted
+}
+
+func TestTtlCacheExpireAfterWrite(t *testing.T) {
+  cache, clock, evicted := newTestTtlCache(ExpireAfterWrite, 8)
+  cache.Add("k1", "v1")
+  clock.Advance(30 * time.Second)
+  cache.Add("k2", "v2")
+  checkCacheEntry(t, cache, "k1", "v1", 2)
+  clock.Advance(30 * time.Second)
+  checkCacheEntry(t, cache, "k1", nil, 1)
+  checkCacheEntry(t, cache, "k2", "v2", 1)
+  if len(*evicted) != 1 || (*evicted)[0].Key() != "k1" || (*evicted)[0].Value() != "v1" {
+    t.Errorf("Expected eviction of k1; got %v", *evicted)
+  }
+  clock.Advance(30 * time.Second)
+  checkCacheEntry(t, cache, "k2", nil, 0)
+  if len(*evicted) != 2 {
+    t.Errorf("Expected 2 evictions; got %d", len(*evicted))
+  }
+}
+
+func TestTtlCacheExpireAfterAccess(t *testing.T) {
+  cache, clock, evicted := newTestTtlCache(ExpireAfterAccess, 8)
+  cache.Add("k1", "v1")
+  cache.Add("k2", "v2")
+  for i := 0; i < 5; i++ {
+    clock.Advance(40 * time.Second)
+    if _, exists := cache.Get("k1"); !exists {
+      t.Errorf("Expected k1 to be kept alive by access %d", i)
+    }
+  }
+  checkCacheEntry(t, cache, "k2", nil, 1)
+  clock.Advance(time.Minute)
+  if n := cache.CleanUp(); n != 1 {
+    t.Errorf("Expected CleanUp to remove 1 entry; removed %d", n)
+  }
+  checkCacheEntry(t, cache, "k1", nil, 0)
+  if len(*evicted) != 2 {
+    t.Errorf("Expected 2 evictions; got %d", len(*evicted))
+  }
+}
+
+func TestTtlCachePerEntryTTL(t *testing.T) {
+  cache, clock, _ := newTestTtlCache(ExpireAfterWrite, 8)
+  cache.AddWithTTL("short", 1, 10 * time.Second)
+  cache.AddWithTTL("forever", 2, 0)
+  cache.Add("default", 3)
+  clock.Advance(10 * time.Second)
+  checkCacheEntry(t, cache, "short", nil, 2)
+  clock.Advance(time.Hour)
+  checkCacheEntry(t, cache, "default", nil, 1)
+  checkCacheEntry(t, cache, "forever", 2, 1)
+  cache.AddWithTTL("forever", 4, time.Second)
+  clock.Advance(time.Second)
+  checkCacheEntry(t, cache, "forever", nil, 0)
+}
+
+func TestTtlCacheCapacity(t *testing.T) {
+  cache, clock, evicted := newTestTtlCache(ExpireAfterWrite, 3)
+  cache.Add(1, "a")
+  cache.Add(2, "b")
+  cache.Add(3, "c")
+  cache.Get(1)
+  cache.Add(4, "d")
+  checkCacheEntry(t, cache, 2, nil, 3)
+  if len(*evicted) != 1 || (*evicted)[0].Key() != 2 {
+    t.Errorf("Expected eviction of least recently used key 2; got %v", *evicted)
+  }
+  for i := 0; i < 100; i++ {
+    cache.Add(5, i)
+  }
+  clock.Advance(time.Minute)
+  if n := cache.CleanUp(); n != 3 {
+    t.Errorf("Expected CleanUp to remove 3 entries; removed %d", n)
+  }
+  if !cache.IsEmpty() {
+    t.Errorf("Expected empty cache; got %s", cache)
+  }
+}
+
+func TestWeightedTtlCache(t *testing.T) {
+  var evicted []MapEntry
+  clock := NewManualClock(time.Unix(1000, 0))
+  weigher := func (kv MapEntry) int {
+    return len(kv.Value().(string))
+  }
+  class := WeightedTtlCacheClass(UniversalHash, UniversalEquality, time.Minute,
+                                 ExpireAfterWrite, clock, weigher)
+  cache := class.NewWeightedWithCallback(10, func (kv MapEntry) {
+    evicted = append(evicted, kv)
+  })
+  cache.Add("a", "xxx")
+  cache.Add("b", "xxx")
+  cache.Add("c", "xx")
+  cache.Get("a")
+  cache.Add("d", "xxxxxxx")
+  checkCacheEntry(t, cache, "a", "xxx", 2)
+  if cache.Weight() != 10 || len(evicted) != 2 || evicted[0].Key() != "b" || evicted[1].Key() != "c" {
+    t.Errorf("Expected weight 10 after evicting b and c; got %d after %v", cache.Weight(), evicted)
+  }
+  clock.Advance(time.Minute)
+  if cache.Weight() != 0 || len(evicted) != 4 {
+    t.Errorf("Expected weight 0 after expiry; got %d after %v", cache.Weight(), evicted)
+  }
+  cache.Add("e", "x")
+  cache.Add("e", "xxxxxxxxxxx")
+  checkCacheEntry(t, cache, "e", nil, 0)
+  if cache.Weight() != 0 || len(evicted) != 6 ||
+     evicted[4].Value() != "x" || evicted[5].Value() != "xxxxxxxxxxx" {
+    t.Errorf("Expected eviction of previous and overweight entry; got weight %d and evictions %v",
+             cache.Weight(), evicted)
+  }
+}
+
+func TestTtlCacheBackgroundCleanup(t *testing.T) {
+  clock := NewManualClock(time.Unix(1000, 0))
+  evicted := make(chan MapEntry, 1)
+  class := TtlCacheClass(UniversalHash, UniversalEquality, time.Second, ExpireAfterWrite, clock)
+  cache := class.NewExpiringWithCallback(8, func (kv MapEntry) { evicted <- kv })
+  cache.Add("k1", "v1")
+  cache.StartCleanup(time.Millisecond)
+  defer cache.StopCleanup()
+  clock.Advance(time.Second)
+  select {
+    case kv := <-evicted:
+      if kv.Key() != "k1" {
+        t.Errorf("Expected eviction of k1; got %v", kv)
+      }
+    case <-time.After(5 * time.Second):
+      t.Errorf("Background cleanup did not evict expired entry")
+  }
+}
@@ -0,0 +1,193 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/impl"
+
+
+// TwoQueueCache is a cache class implementing the full 2Q policy by Johnson
+// and Shasha. New entries are put into a FIFO queue holding a quarter of the
+// capacity. Entries evicted from this queue are remembered as ghost keys for
+// a while; if a ghost key is added again, its entry gets promoted to the main
+// LRU queue. Entries which are only used once, e.g. during scans, thus never
+// displace the entries of the main queue.
+var TwoQueueCache CacheClass = TwoQueueCacheClass(UniversalHash, UniversalEquality)
+
+func TwoQueueCacheClass(hash Hashfunction, equals Equality) CacheClass {
+  return &twoQueueCacheClass{hash, equals}
+}
+
+type twoQueueCacheClass struct {
+  hash Hashfunction
+  equals Equality
+}
+
+func (this *twoQueueCacheClass) Embed(obj Cache, capacity int, we func (kv MapEntry)) Cache {
+  res := new(twoQueueCache)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.CacheDerived = EmbeddedCache(obj)
+  res.class = this
+  res.capacity = capacity
+  res.inCapacity = max(1, capacity / 4)
+  res.outCapacity = max(1, capacity / 2)
+  res.whenEvicted = we
+  res.table = impl.NewHashTable(17, 80, this.hash, this.equals)
+  for i := range res.queues {
+    res.queues[i] = impl.NewDoubleLinkedList()
+  }
+  return res
+}
+
+func (this *twoQueueCacheClass) New(capacity int) Cache {
+  return this.Embed(nil, capacity, nil)
+}
+
+func (this *twoQueueCacheClass) NewWithCallback(capacity int, we func (kv MapEntry)) Cache {
+  return this.Embed(nil, capacity, we)
+}
+
+// Queues of a 2Q cache (A1in, A1out and Am in the paper)
+const (
+  twoQueueIn = iota
+  twoQueueOut
+  twoQueueMain
+)
+
+type twoQueueCache struct {
+  obj Cache
+  class *twoQueueCacheClass
+  capacity int
+  inCapacity int
+  outCapacity int
+  whenEvicted func (kv MapEntry)
+  table *impl.HashTable
+  queues [3]*impl.DoubleLinkedList
+  cacheStats
+  CacheDerived
+}
+
+func (this *twoQueueCache) Size() int {
+  return this.queues[twoQueueIn].Length() + this.queues[twoQueueMain].Length()
+}
+
+func (this *twoQueueCache) Get(key interface{}) (value interface{}, exists bool) {
+  if entry := this.table.FindEntry(key); entry != nil {
+    switch node := entry.Value.(*cacheNode); node.queue {
+      case twoQueueMain:
+        this.move(node, twoQueueMain)
+        this.StatsCounter().RecordHit()
+        return node.value, true
+      case twoQueueIn:
+        this.StatsCounter().RecordHit()
+        return node.value, true
+    }
+  }
+  this.StatsCounter().RecordMiss()
+  return nil, false
+}
+
+func (this *twoQueueCache) Elements() Iterator {
+  return newCacheQueueIterator(this.queues[twoQueueIn], this.queues[twoQueueMain])
+}
+
+func (this *twoQueueCache) Class() CacheClass {
+  return this.class
+}
+
+func (this *twoQueueCache) Add(key, value interface{}) {
+  if this.capacity <= 0 {
+    this.StatsCounter().RecordEviction(EvictedBySize)
+    if this.whenEvicted != nil {
+      this.whenEvicted(KV(key, value))
+    }
+    return
+  }
+  if entry := this.table.FindEntry(key); entry != nil {
+    node := entry.Value.(*cacheNode)
+    switch node.queue {
+      case twoQueueMain:
+        this.move(node, twoQueueMain)
+      case twoQueueOut:
+        this.queues[twoQueueOut].Remove(node.elem)
+        node.queue = twoQueueMain
+        this.reclaim()
+        this.queues[twoQueueMain].InsertFront(node.elem)
+    }
+    node.value = value
+    return
+  }
+  this.reclaim()
+  node := newCacheNode(key, value, twoQueueIn)
+  this.table.AddEntry(key, node)
+  this.queues[twoQueueIn].InsertFront(node.elem)
+}
+
+func (this *twoQueueCache) Remove(keys ...interface{}) {
+  for _, key := range keys {
+    if entry := this.table.FindEntry(key); entry != nil {
+      node := entry.Value.(*cacheNode)
+      this.queues[node.queue].Remove(node.elem)
+      this.table.DeleteEntry(key)
+    }
+  }
+}
+
+func (this *twoQueueCache) Clear() {
+  this.table.Clear()
+  for i := range this.queues {
+    this.queues[i] = impl.NewDoubleLinkedList()
+  }
+}
+
+func (this *twoQueueCache) move(node *cacheNode, queue int) {
+  this.queues[node.queue].Remove(node.elem)
+  node.queue = queue
+  this.queues[queue].InsertFront(node.elem)
+}
+
+// reclaim makes room for a new entry if the cache is full. If the FIFO queue
+// exceeds its share of the capacity, its oldest entry is evicted and its key
+// is remembered as a ghost key. Otherwise the least recently used entry of
+// the main queue is evicted.
+func (this *twoQueueCache) reclaim() {
+  if this.Size() < this.capacity {
+    return
+  }
+  in := this.queues[twoQueueIn]
+  var node *cacheNode
+  if in.Length() > this.inCapacity || this.queues[twoQueueMain].Length() == 0 {
+    node = in.Back().Value.(*cacheNode)
+    this.move(node, twoQueueOut)
+    if out := this.queues[twoQueueOut]; out.Length() > this.outCapacity {
+      ghost := out.Back().Value.(*cacheNode)
+      out.Remove(ghost.elem)
+      this.table.DeleteEntry(ghost.key)
+    }
+  } else {
+    node = this.queues[twoQueueMain].Back().Value.(*cacheNode)
+    this.queues[twoQueueMain].Remove(node.elem)
+    this.table.DeleteEntry(node.key)
+  }
+  evicted := node.entry()
+  node.value = nil
+  this.StatsCounter().RecordEviction(EvictedBySize)
+  if this.whenEvicted != nil {
+    this.whenEvicted(evicted)
+  }
+}
@@ -0,0 +1,198 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.24
+
+package maps
+
+import "runtime"
+import "sync"
+import "weak"
+import . "github.com/objecthub/containerkit"
+
+
+// WeakMapClass returns a class of maps whose keys are pointers of type *K
+// which are referenced weakly: a map does not prevent its keys from being
+// garbage collected, and entries disappear once their key was collected.
+// Keys are compared by identity. Values are referenced strongly; a value
+// referring to its own key keeps the entry alive.
+//
+// Entries of collected keys are removed the next time the map is accessed
+// after the garbage collector reclaimed the key. Until then, they are counted
+// by Size, but they are never returned by Get or Elements.
+//
+// Weak maps rely on package weak and are only available with Go 1.24 or later.
+func WeakMapClass[K any]() MutableMapClass {
+  return &weakMapClass[K]{}
+}
+
+type weakMapClass[K any] struct {}
+
+func (this *weakMapClass[K]) Embed(obj MutableMap) MutableMap {
+  res := new(weakMap[K])
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableMapDerived = EmbeddedMutableMap(obj)
+  res.entries = make(map[weak.Pointer[K]]*weakEntry)
+  return res
+}
+
+func (this *weakMapClass[K]) New(entries... MapEntry) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeEntry(entries...)
+  return res
+}
+
+func (this *weakMapClass[K]) From(coll Container) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+func (this *weakMapClass[K]) FromNative(mp map[interface{}] interface{}) MutableMap {
+  res := this.Embed(nil)
+  res.IncludeFromNative(mp)
+  return res
+}
+
+// weakMap stores its entries in a native map indexed by weak pointers. Weak
+// pointers created from the same pointer are equal, even after the object
+// they refer to was reclaimed. A cleanup function registered for every key
+// queues the weak pointer of collected keys in collected; the queue is
+// processed by the goroutines accessing the map.
+type weakMap[K any] struct {
+  obj MutableMap
+  entries map[weak.Pointer[K]]*weakEntry
+  mutex sync.Mutex
+  collected []weak.Pointer[K]
+  MutableMapDerived
+}
+
+type weakEntry struct {
+  value interface{}
+  cleanup runtime.Cleanup
+}
+
+func (this *weakMap[K]) purge() {
+  this.mutex.Lock()
+  collected := this.collected
+  this.collected = nil
+  this.mutex.Unlock()
+  for _, wp := range collected {
+    delete(this.entries, wp)
+  }
+}
+
+func (this *weakMap[K]) collect(wp weak.Pointer[K]) {
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  this.collected = append(this.collected, wp)
+}
+
+func (this *weakMap[K]) Size() int {
+  this.purge()
+  return len(this.entries)
+}
+
+func (this *weakMap[K]) Get(key interface{}) (value interface{}, exists bool) {
+  this.purge()
+  if ptr, valid := key.(*K); valid && ptr != nil {
+    if entry, exists := this.entries[weak.Make(ptr)]; exists {
+      return entry.value, true
+    }
+  }
+  return nil, false
+}
+
+func (this *weakMap[K]) Elements() Iterator {
+  this.purge()
+  keys := make([]weak.Pointer[K], 0, len(this.entries))
+  for wp := range this.entries {
+    keys = append(keys, wp)
+  }
+  return &weakMapIterator[K]{this, keys, nil, nil}
+}
+
+func (this *weakMap[K]) Class() MutableMapClass {
+  return WeakMapClass[K]()
+}
+
+func (this *weakMap[K]) Include(key, value interface{}) {
+  this.purge()
+  ptr, valid := key.(*K)
+  if !valid || ptr == nil {
+    panic("weakMap.Include: key is not a pointer of the key type")
+  }
+  wp := weak.Make(ptr)
+  if entry, exists := this.entries[wp]; exists {
+    entry.value = value
+  } else {
+    this.entries[wp] = &weakEntry{value, runtime.AddCleanup(ptr, this.collect, wp)}
+  }
+}
+
+func (this *weakMap[K]) Exclude(keys ...interface{}) {
+  this.purge()
+  for _, key := range keys {
+    if ptr, valid := key.(*K); valid && ptr != nil {
+      wp := weak.Make(ptr)
+      if entry, exists := this.entries[wp]; exists {
+        entry.cleanup.Stop()
+        delete(this.entries, wp)
+      }
+    }
+  }
+}
+
+func (this *weakMap[K]) Clear() {
+  for _, entry := range this.entries {
+    entry.cleanup.Stop()
+  }
+  this.entries = make(map[weak.Pointer[K]]*weakEntry)
+  this.mutex.Lock()
+  defer this.mutex.Unlock()
+  this.collected = nil
+}
+
+// weakMapIterator iterates over a snapshot of weak pointers, skipping the
+// ones whose key was collected or excluded in the meantime.
+type weakMapIterator[K any] struct {
+  mp *weakMap[K]
+  keys []weak.Pointer[K]
+  next *K
+  value interface{}
+}
+
+func (this *weakMapIterator[K]) HasNext() bool {
+  for this.next == nil && len(this.keys) > 0 {
+    if ptr := this.keys[0].Value(); ptr != nil {
+      if entry, exists := this.mp.entries[this.keys[0]]; exists {
+        this.next, this.value = ptr, entry.value
+      }
+    }
+    this.keys = this.keys[1:]
+  }
+  return this.next != nil
+}
+
+func (this *weakMapIterator[K]) Next() interface{} {
+  if !this.HasNext() {
+    panic("weakMapIterator.Next: no next element")
+  }
+  res := KV(this.next, this.value)
+  this.next, this.value = nil, nil
+  return res
+}
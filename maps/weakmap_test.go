@@ -0,0 +1,86 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.24
+
+package maps
+
+import "runtime"
+import "testing"
+import "time"
+import "weak"
+
+
+type weakKey struct {
+  id int
+  name string
+}
+
+func TestWeakMapClass(t *testing.T) {
+  m := WeakMapClass[weakKey]().New()
+  keys := make([]*weakKey, 10)
+  for i := range keys {
+    keys[i] = &weakKey{i, "key"}
+    m.Include(keys[i], i)
+  }
+  checkSize(t, m, 10, "m")
+  if m.GetValue(keys[3]) != 3 || m.HasKey(&weakKey{3, "key"}) || m.HasKey(3) {
+    t.Errorf("Expected keys to be compared by identity")
+  }
+  m.Exclude(keys[9])
+  // Simulate the cleanup functions of collected keys.
+  for i := 5; i < 9; i++ {
+    m.(*weakMap[weakKey]).collect(weak.Make(keys[i]))
+  }
+  checkSize(t, m, 5, "m")
+  if m.HasKey(keys[5]) || !m.HasKey(keys[4]) {
+    t.Errorf("Expected entries of collected keys to be purged")
+  }
+  count := 0
+  for iter := m.Elements(); iter.HasNext(); count++ {
+    entry := iter.Next().(MapEntry)
+    if entry.Key().(*weakKey).id != entry.Value() {
+      t.Errorf("Unexpected entry %v", entry)
+    }
+  }
+  if count != 5 {
+    t.Errorf("Expected 5 entries; found %d", count)
+  }
+  m.Clear()
+  checkSize(t, m, 0, "m")
+  runtime.KeepAlive(keys)
+}
+
+// TestWeakMapGarbageCollection depends on the garbage collector reclaiming
+// keys in time; it is skipped in short mode.
+func TestWeakMapGarbageCollection(t *testing.T) {
+  if testing.Short() {
+    t.Skip("depends on the garbage collector")
+  }
+  m := WeakMapClass[weakKey]().New()
+  keys := make([]*weakKey, 10)
+  for i := range keys {
+    keys[i] = &weakKey{i, "key"}
+    m.Include(keys[i], i)
+  }
+  for i := 5; i < len(keys); i++ {
+    keys[i] = nil
+  }
+  for i := 0; i < 100 && m.Size() > 5; i++ {
+    runtime.GC()
+    time.Sleep(time.Millisecond)
+  }
+  checkSize(t, m, 5, "m")
+  runtime.KeepAlive(keys)
+}
@@ -0,0 +1,40 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerkit
+
+
+// Observers is a list of observers shared by the observable container
+// classes. Adding and removing observers creates a new backing array, such
+// that adding or removing an observer during a notification does not affect
+// the notification in progress.
+type Observers []interface{}
+
+// Add appends observer to this list.
+func (this *Observers) Add(observer interface{}) {
+  res := make([]interface{}, len(*this), len(*this) + 1)
+  copy(res, *this)
+  *this = append(res, observer)
+}
+
+// Remove removes the first occurrence of observer from this list.
+func (this *Observers) Remove(observer interface{}) {
+  for i, o := range *this {
+    if o == observer {
+      res := make([]interface{}, 0, len(*this) - 1)
+      *this = append(append(res, (*this)[:i]...), (*this)[i + 1:]...)
+      return
+    }
+  }
+}
@@ -0,0 +1,182 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sequences
+
+import . "github.com/objecthub/containerkit"
+
+
+// MutableSequenceObserver is notified about changes of observed sequences.
+// Insert reports that n elements were inserted at index; the elements are
+// available via the subject. Inserting multiple elements, e.g. via Append or
+// AppendFrom, is reported as a single Insert event. Delete reports the
+// elements that were removed starting at index. Overwriting an element is
+// reported as a Replace event. Observers may implement SequenceClearObserver
+// to be notified about Clear with a dedicated event.
+type MutableSequenceObserver interface {
+  Insert(subject MutableSequence, index int, n int)
+  Delete(subject MutableSequence, index int, elements []interface{})
+  Replace(subject MutableSequence, index int, oldValue, newValue interface{})
+}
+
+// SequenceClearObserver is an optional extension of MutableSequenceObserver.
+// Clearing a sequence is reported to observers implementing it as a Clear
+// event; other observers get a Delete event listing all elements.
+type SequenceClearObserver interface {
+  Clear(subject MutableSequence)
+}
+
+// ObservedSequence is implemented by sequences created by ObservableSequence
+// classes. It allows observers to be added and removed at any time.
+type ObservedSequence interface {
+  MutableSequence
+  AddObserver(observer MutableSequenceObserver)
+  RemoveObserver(observer MutableSequenceObserver)
+}
+
+// ObservableSequence returns a class whose sequences notify observers about
+// changes. The sequences are initially observed by the MutableSequenceObserver
+// elements of observers, which may be nil.
+func ObservableSequence(class MutableSequenceClass, observers Container) MutableSequenceClass {
+  return &observableSequenceClass{class, observers}
+}
+
+type observableSequenceClass struct {
+  class MutableSequenceClass
+  observers Container
+}
+
+func (this *observableSequenceClass) Embed(obj MutableSequence) MutableSequence {
+  res := new(observableSequence)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  if this.observers != nil {
+    this.observers.ForEach(func (o interface{}) {
+      res.AddObserver(o.(MutableSequenceObserver))
+    })
+  }
+  res.MutableSequence = this.class.Embed(obj)
+  return res
+}
+
+func (this *observableSequenceClass) New(elements ...interface{}) MutableSequence {
+  res := this.Embed(nil)
+  res.Append(elements...)
+  return res
+}
+
+func (this *observableSequenceClass) From(coll Container) MutableSequence {
+  res := this.Embed(nil)
+  res.AppendFrom(coll)
+  return res
+}
+
+type observableSequence struct {
+  obj MutableSequence
+  observers Observers
+  depth int
+  MutableSequence
+}
+
+func (this *observableSequence) AddObserver(observer MutableSequenceObserver) {
+  this.observers.Add(observer)
+}
+
+func (this *observableSequence) RemoveObserver(observer MutableSequenceObserver) {
+  this.observers.Remove(observer)
+}
+
+func (this *observableSequence) Set(index int, element interface{}) {
+  if this.depth > 0 {
+    this.MutableSequence.Set(index, element)
+    return
+  }
+  old := this.MutableSequence.At(index)
+  this.MutableSequence.Set(index, element)
+  for _, o := range this.observers {
+    o.(MutableSequenceObserver).Replace(this.obj, index, old, element)
+  }
+}
+
+func (this *observableSequence) Allocate(index int, n int, element interface{}) {
+  this.MutableSequence.Allocate(index, n, element)
+  this.inserted(index, n)
+}
+
+func (this *observableSequence) Delete(index int, n int) {
+  if this.depth > 0 || len(this.observers) == 0 {
+    this.MutableSequence.Delete(index, n)
+    return
+  }
+  elements := make([]interface{}, n)
+  for i := range elements {
+    elements[i] = this.MutableSequence.At(index + i)
+  }
+  this.MutableSequence.Delete(index, n)
+  for _, o := range this.observers {
+    o.(MutableSequenceObserver).Delete(this.obj, index, elements)
+  }
+}
+
+func (this *observableSequence) Insert(index int, elements ...interface{}) {
+  this.depth++
+  this.MutableSequence.Insert(index, elements...)
+  this.depth--
+  this.inserted(index, len(elements))
+}
+
+func (this *observableSequence) InsertFrom(index int, elems Container) {
+  size := this.MutableSequence.Size()
+  this.depth++
+  this.MutableSequence.InsertFrom(index, elems)
+  this.depth--
+  this.inserted(index, this.MutableSequence.Size() - size)
+}
+
+func (this *observableSequence) inserted(index int, n int) {
+  if this.depth == 0 && n > 0 {
+    for _, o := range this.observers {
+      o.(MutableSequenceObserver).Insert(this.obj, index, n)
+    }
+  }
+}
+
+func (this *observableSequence) Clear() {
+  var elements []interface{}
+  if this.depth == 0 {
+    for _, o := range this.observers {
+      if _, ok := o.(SequenceClearObserver); !ok {
+        elements = make([]interface{}, this.MutableSequence.Size())
+        for i := range elements {
+          elements[i] = this.MutableSequence.At(i)
+        }
+        break
+      }
+    }
+  }
+  this.depth++
+  this.MutableSequence.Clear()
+  this.depth--
+  if this.depth == 0 {
+    for _, o := range this.observers {
+      if co, ok := o.(SequenceClearObserver); ok {
+        co.Clear(this.obj)
+      } else if len(elements) > 0 {
+        o.(MutableSequenceObserver).Delete(this.obj, 0, elements)
+      }
+    }
+  }
+}
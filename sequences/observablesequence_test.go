@@ -0,0 +1,75 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sequences
+
+import "fmt"
+import "strings"
+import "testing"
+
+
+type sequenceRecorder struct {
+  events []string
+}
+
+func (this *sequenceRecorder) Insert(subject MutableSequence, index int, n int) {
+  this.events = append(this.events, fmt.Sprintf("+%d:%d", index, n))
+}
+
+func (this *sequenceRecorder) Delete(subject MutableSequence, index int, elements []interface{}) {
+  this.events = append(this.events, fmt.Sprintf("-%d:%v", index, elements))
+}
+
+func (this *sequenceRecorder) Replace(subject MutableSequence,
+                                      index int,
+                                      oldValue, newValue interface{}) {
+  this.events = append(this.events, fmt.Sprintf("%d:%v=>%v", index, oldValue, newValue))
+}
+
+// clearSequenceRecorder also records clear events.
+type clearSequenceRecorder struct {
+  sequenceRecorder
+}
+
+func (this *clearSequenceRecorder) Clear(subject MutableSequence) {
+  this.events = append(this.events, "clear")
+}
+
+func TestObservableSequenceClass(t *testing.T) {
+  recorder := new(clearSequenceRecorder)
+  seq := ObservableSequence(ArraySequence, nil).New(1, 2).(ObservedSequence)
+  seq.AddObserver(recorder)
+  seq.Append(3, 4)
+  seq.AppendFrom(ArraySequence.New(5, 6, 7))
+  seq.Set(0, 10)
+  seq.Delete(1, 2)
+  checkSize(t, seq, 5, "seq")
+  seq.Clear()
+  expected := "+2:2 +4:3 0:1=>10 -1:[2 3] clear"
+  if events := strings.Join(recorder.events, " "); events != expected {
+    t.Errorf("Unexpected events %s; expected %s", events, expected)
+  }
+}
+
+func TestObservableSequenceFallbackEvents(t *testing.T) {
+  recorder := new(sequenceRecorder)
+  seq := ObservableSequence(ArraySequence, nil).New(1, 2).(ObservedSequence)
+  seq.AddObserver(recorder)
+  seq.Clear()
+  seq.Clear()
+  expected := "-0:[1 2]"
+  if events := strings.Join(recorder.events, " "); events != expected {
+    t.Errorf("Unexpected events %s; expected %s", events, expected)
+  }
+}
@@ -0,0 +1,377 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "math/bits"
+import . "github.com/objecthub/containerkit"
+
+
+// MutableBitSet is a MutableSet of non-negative ints which is represented
+// by a sequence of bits. Bit sets are compact for dense sets of small
+// numbers. Set operations between two bit sets are performed on whole
+// words at a time.
+type MutableBitSet interface {
+  MutableSet
+
+  // Cardinality returns the number of elements of this bit set. It is
+  // equivalent to Size.
+  Cardinality() int
+
+  // NextSetBit returns the smallest element greater than or equal to from,
+  // or -1 if there is no such element.
+  NextSetBit(from int) int
+
+  // NextClearBit returns the smallest non-negative int greater than or equal
+  // to from which is not an element of this bit set.
+  NextClearBit(from int) int
+}
+
+// BitSetClass defines the functionality of MutableBitSet implementations. In
+// addition to the MutableSetClass methods, it provides factory methods
+// returning MutableBitSet values.
+type BitSetClass interface {
+  MutableSetClass
+  NewBits(elements ...int) MutableBitSet
+  FromBits(coll Container) MutableBitSet
+}
+
+var BitSet BitSetClass = &bitSetClass{}
+
+type bitSetClass struct {}
+
+func (this *bitSetClass) Embed(obj MutableSet) MutableSet {
+  res := new(bitSet)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableSetDerived = EmbeddedMutableSet(obj)
+  return res
+}
+
+func (this *bitSetClass) New(elements ...interface{}) MutableSet {
+  res := this.Embed(nil)
+  res.Include(elements...)
+  return res
+}
+
+func (this *bitSetClass) From(coll Container) MutableSet {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+func (this *bitSetClass) NewBits(elements ...int) MutableBitSet {
+  res := this.Embed(nil).(*bitSet)
+  for _, elem := range elements {
+    res.set(elem)
+  }
+  return res
+}
+
+func (this *bitSetClass) FromBits(coll Container) MutableBitSet {
+  res := this.Embed(nil).(*bitSet)
+  res.IncludeFrom(coll)
+  return res
+}
+
+// wordSet is implemented by sets which can provide their elements as a
+// sequence of bits. Bit set operations use it for word-parallel fast paths.
+type wordSet interface {
+  Set
+  bitWords() []uint64
+}
+
+type bitSet struct {
+  obj MutableSet
+  words []uint64
+  MutableSetDerived
+}
+
+func (this *bitSet) bitWords() []uint64 {
+  return this.words
+}
+
+func (this *bitSet) set(n int) {
+  if n < 0 {
+    panic("bitSet.Include: negative element")
+  }
+  w := n / 64
+  if w >= len(this.words) {
+    words := make([]uint64, w + 1, 2 * (w + 1))
+    copy(words, this.words)
+    this.words = words
+  }
+  this.words[w] |= 1 << uint(n % 64)
+}
+
+func (this *bitSet) trim() {
+  n := len(this.words)
+  for n > 0 && this.words[n - 1] == 0 {
+    n--
+  }
+  this.words = this.words[:n]
+}
+
+func (this *bitSet) Size() int {
+  return cardinality(this.words)
+}
+
+func (this *bitSet) Cardinality() int {
+  return cardinality(this.words)
+}
+
+func (this *bitSet) Contains(elem interface{}) bool {
+  if n, valid := elem.(int); valid {
+    return testBit(this.words, n)
+  }
+  return false
+}
+
+func (this *bitSet) Elements() Iterator {
+  return newBitIterator(this.words)
+}
+
+func (this *bitSet) Class() MutableSetClass {
+  return BitSet
+}
+
+func (this *bitSet) Include(elements ...interface{}) {
+  for _, elem := range elements {
+    if n, valid := elem.(int); valid {
+      this.set(n)
+    } else {
+      panic("bitSet.Include: element not an int")
+    }
+  }
+}
+
+func (this *bitSet) Exclude(elements ...interface{}) {
+  for _, elem := range elements {
+    if n, valid := elem.(int); valid && n >= 0 && n / 64 < len(this.words) {
+      this.words[n / 64] &^= 1 << uint(n % 64)
+    }
+  }
+  this.trim()
+}
+
+func (this *bitSet) Clear() {
+  this.words = nil
+}
+
+// IncludeFrom, ExcludeFrom and IntersectWith combine the words of two bit
+// sets directly. Embedded bit sets take the element-wise path instead, so
+// that the embedding set observes every change.
+func (this *bitSet) IncludeFrom(coll Container) {
+  if other, valid := coll.(wordSet); valid && this.obj == this {
+    words := other.bitWords()
+    if len(words) > len(this.words) {
+      extended := make([]uint64, len(words))
+      copy(extended, this.words)
+      this.words = extended
+    }
+    for i, w := range words {
+      this.words[i] |= w
+    }
+  } else {
+    this.MutableSetDerived.IncludeFrom(coll)
+  }
+}
+
+func (this *bitSet) ExcludeFrom(coll Container) {
+  if other, valid := coll.(wordSet); valid && this.obj == this {
+    words := other.bitWords()
+    for i := 0; i < len(this.words) && i < len(words); i++ {
+      this.words[i] &^= words[i]
+    }
+    this.trim()
+  } else {
+    this.MutableSetDerived.ExcludeFrom(coll)
+  }
+}
+
+func (this *bitSet) IntersectWith(coll Container) {
+  if other, valid := coll.(wordSet); valid && this.obj == this {
+    words := other.bitWords()
+    if len(words) < len(this.words) {
+      this.words = this.words[:len(words)]
+    }
+    for i := range this.words {
+      this.words[i] &= words[i]
+    }
+    this.trim()
+  } else {
+    this.MutableSetDerived.IntersectWith(coll)
+  }
+}
+
+func (this *bitSet) NextSetBit(from int) int {
+  return nextSetBit(this.words, from)
+}
+
+func (this *bitSet) NextClearBit(from int) int {
+  if from < 0 {
+    from = 0
+  }
+  for w := from / 64; w < len(this.words); w++ {
+    word := ^this.words[w]
+    if w == from / 64 {
+      word &= ^uint64(0) << uint(from % 64)
+    }
+    if word != 0 {
+      return w * 64 + bits.TrailingZeros64(word)
+    }
+  }
+  if from > len(this.words) * 64 {
+    return from
+  }
+  return len(this.words) * 64
+}
+
+func (this *bitSet) Union(set Set) DependentSet {
+  if other, valid := set.(wordSet); valid {
+    return newBitSetCombination(this, other, func (x, y uint64) uint64 { return x | y })
+  }
+  return this.MutableSetDerived.Union(set)
+}
+
+func (this *bitSet) Intersection(set Set) DependentSet {
+  if other, valid := set.(wordSet); valid {
+    return newBitSetCombination(this, other, func (x, y uint64) uint64 { return x & y })
+  }
+  return this.MutableSetDerived.Intersection(set)
+}
+
+func (this *bitSet) Difference(set Set) DependentSet {
+  if other, valid := set.(wordSet); valid {
+    return newBitSetCombination(this, other, func (x, y uint64) uint64 { return x &^ y })
+  }
+  return this.MutableSetDerived.Difference(set)
+}
+
+func (this *bitSet) SymmetricDifference(set Set) DependentSet {
+  if other, valid := set.(wordSet); valid {
+    return newBitSetCombination(this, other, func (x, y uint64) uint64 { return x ^ y })
+  }
+  return this.MutableSetDerived.SymmetricDifference(set)
+}
+
+// Word-parallel combination of two bit sets
+
+func newBitSetCombination(fst wordSet,
+                          snd wordSet,
+                          op func (x, y uint64) uint64) DependentSet {
+  res := new(bitSetCombination)
+  res.SetDerived = EmbeddedDependentSet(res)
+  res.fst = fst
+  res.snd = snd
+  res.op = op
+  return res
+}
+
+type bitSetCombination struct {
+  SetDerived
+  fst wordSet
+  snd wordSet
+  op func (x, y uint64) uint64
+}
+
+func (this *bitSetCombination) bitWords() []uint64 {
+  fst, snd := this.fst.bitWords(), this.snd.bitWords()
+  n := len(fst)
+  if len(snd) > n {
+    n = len(snd)
+  }
+  res := make([]uint64, n)
+  for i := range res {
+    res[i] = this.op(wordAt(fst, i), wordAt(snd, i))
+  }
+  return res
+}
+
+func (this *bitSetCombination) Size() int {
+  return cardinality(this.bitWords())
+}
+
+func (this *bitSetCombination) Contains(elem interface{}) bool {
+  if n, valid := elem.(int); valid && n >= 0 {
+    word := this.op(wordAt(this.fst.bitWords(), n / 64), wordAt(this.snd.bitWords(), n / 64))
+    return word & (1 << uint(n % 64)) != 0
+  }
+  return false
+}
+
+func (this *bitSetCombination) Elements() Iterator {
+  return newBitIterator(this.bitWords())
+}
+
+// Utility functions operating on words
+
+func wordAt(words []uint64, i int) uint64 {
+  if i < len(words) {
+    return words[i]
+  }
+  return 0
+}
+
+func testBit(words []uint64, n int) bool {
+  return n >= 0 && wordAt(words, n / 64) & (1 << uint(n % 64)) != 0
+}
+
+func cardinality(words []uint64) int {
+  res := 0
+  for _, w := range words {
+    res += bits.OnesCount64(w)
+  }
+  return res
+}
+
+func nextSetBit(words []uint64, from int) int {
+  if from < 0 {
+    from = 0
+  }
+  for w := from / 64; w < len(words); w++ {
+    word := words[w]
+    if w == from / 64 {
+      word &= ^uint64(0) << uint(from % 64)
+    }
+    if word != 0 {
+      return w * 64 + bits.TrailingZeros64(word)
+    }
+  }
+  return -1
+}
+
+func newBitIterator(words []uint64) Iterator {
+  return &bitIterator{words, nextSetBit(words, 0)}
+}
+
+type bitIterator struct {
+  words []uint64
+  next int
+}
+
+func (this *bitIterator) HasNext() bool {
+  return this.next >= 0
+}
+
+func (this *bitIterator) Next() interface{} {
+  if this.next < 0 {
+    panic("bitIterator.Next: no next element")
+  }
+  res := this.next
+  this.next = nextSetBit(this.words, res + 1)
+  return res
+}
@@ -0,0 +1,77 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "strings"
+import "testing"
+
+
+func TestBitSetClass(t *testing.T) {
+  s1 := BitSet.NewBits(3, 130, 64, 3, 0)
+  checkElements(t, s1, []interface{}{0, 3, 64, 130}, "s1")
+  if s1.Cardinality() != 4 || !s1.Contains(130) || s1.Contains(131) || s1.Contains("x") {
+    t.Errorf("Unexpected cardinality or membership of s1")
+  }
+  if s1.NextSetBit(4) != 64 || s1.NextSetBit(131) != -1 || s1.NextSetBit(-5) != 0 {
+    t.Errorf("Unexpected result of NextSetBit")
+  }
+  if s1.NextClearBit(0) != 1 || s1.NextClearBit(64) != 65 || s1.NextClearBit(500) != 500 {
+    t.Errorf("Unexpected result of NextClearBit")
+  }
+  s1.Exclude(130, 1000)
+  checkElements(t, s1, []interface{}{0, 3, 64}, "s1")
+}
+
+func TestBitSetOperations(t *testing.T) {
+  s1 := BitSet.NewBits(1, 2, 3, 100)
+  s2 := BitSet.NewBits(2, 3, 4, 200)
+  union := s1.Union(s2)
+  intersection := s1.Intersection(s2)
+  difference := s1.Difference(s2)
+  symmetric := s1.SymmetricDifference(s2)
+  checkElements(t, union, []interface{}{1, 2, 3, 4, 100, 200}, "union")
+  checkElements(t, intersection, []interface{}{2, 3}, "intersection")
+  checkElements(t, difference, []interface{}{1, 100}, "difference")
+  checkElements(t, symmetric, []interface{}{1, 4, 100, 200}, "symmetric difference")
+  s2.Include(1)
+  if !intersection.Contains(1) || difference.Contains(1) || symmetric.Contains(1) {
+    t.Errorf("Combined bit sets do not reflect changes of the underlying sets")
+  }
+  s3 := BitSet.NewBits(5)
+  s3.IncludeFrom(union)
+  checkElements(t, s3, []interface{}{1, 2, 3, 4, 5, 100, 200}, "s3")
+  s3.IntersectWith(BitSet.NewBits(1, 5, 200, 300))
+  checkElements(t, s3, []interface{}{1, 5, 200}, "s3")
+  s3.ExcludeFrom(BitSet.NewBits(200))
+  checkElements(t, s3, []interface{}{1, 5}, "s3")
+  s3.IncludeFrom(HashSet.New(7, 8))
+  checkElements(t, s3, []interface{}{1, 5, 7, 8}, "s3")
+  checkElements(t, s3.Union(HashSet.New(9)), []interface{}{1, 5, 7, 8, 9}, "generic union")
+}
+
+func TestObservableBitSet(t *testing.T) {
+  classes := map[string]MutableSetClass{"BitSet": BitSet, "HashSet": HashSet}
+  for name, class := range classes {
+    recorder := new(setRecorder)
+    set := ObservableSet(class, ListSet.New(recorder)).New(1, 2)
+    set.IncludeFrom(BitSet.New(3, 4))
+    set.ExcludeFrom(BitSet.New(1))
+    set.IntersectWith(BitSet.New(2, 3))
+    if events := strings.Join(recorder.events, " "); events != "+1 +2 +3 +4 -1 -4" {
+      t.Errorf("Unexpected events %s of observable %s", events, name)
+    }
+    checkSize(t, set, 2, "set")
+  }
+}
@@ -0,0 +1,383 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "math"
+import "math/bits"
+import "strconv"
+import . "github.com/objecthub/containerkit"
+
+
+// BloomFilter is a probabilistic Collection. Contains never returns false for
+// an element which was included, but it may return true for an element which
+// was never included. The probability of such false positives depends on the
+// number of bits and hash functions the filter uses, and on how many elements
+// were included. Bloom filters do not store their elements and thus cannot
+// enumerate them.
+type BloomFilter interface {
+  Collection
+
+  // Include adds the given elements to the filter.
+  Include(elements ...interface{})
+
+  // IncludeFrom adds all elements of coll to the filter.
+  IncludeFrom(coll Container)
+
+  // Clear resets the filter such that it does not contain any elements.
+  Clear()
+
+  // Bits returns the number of bits (or counters) of this filter.
+  Bits() int
+
+  // Hashes returns the number of hash functions applied to each element.
+  Hashes() int
+
+  // ApproximateSize estimates the number of distinct elements which were
+  // included in this filter.
+  ApproximateSize() int
+
+  // FalsePositiveRate estimates the probability that Contains returns true
+  // for an element which was never included, given the current fill level.
+  FalsePositiveRate() float64
+
+  // IsCompatible returns true if this filter and other have the same number
+  // of bits and hash functions. Filters need to use the same Hashfunction
+  // as well for their union to be meaningful; this cannot be checked.
+  IsCompatible(other BloomFilter) bool
+
+  // Union returns a new filter of the same kind as this filter containing
+  // the elements of this filter and other. Union panics if the filters are
+  // not compatible.
+  Union(other BloomFilter) BloomFilter
+
+  // Copy returns a new filter of the same kind with the same content.
+  Copy() BloomFilter
+
+  String() string
+}
+
+// CountingBloomFilter is a BloomFilter which maintains a small counter instead
+// of a single bit per position, and therefore supports the removal of
+// elements. Excluding elements which were never included may introduce false
+// negatives. Counters saturate at 255; saturated counters are never
+// decremented.
+type CountingBloomFilter interface {
+  BloomFilter
+
+  // Exclude removes the given elements from the filter.
+  Exclude(elements ...interface{})
+}
+
+// NewBloomFilter returns a new bloom filter which is sized such that, after
+// including expectedSize distinct elements, its false positive rate is
+// approximately falsePositiveRate. Element positions are derived from hash,
+// e.g. UniversalHash.
+func NewBloomFilter(expectedSize int,
+                    falsePositiveRate float64,
+                    hash Hashfunction) BloomFilter {
+  m, k := bloomParameters(expectedSize, falsePositiveRate)
+  return newBloomFilter(m, k, hash)
+}
+
+// NewCountingBloomFilter returns a new counting bloom filter which is sized
+// like a filter returned by NewBloomFilter.
+func NewCountingBloomFilter(expectedSize int,
+                            falsePositiveRate float64,
+                            hash Hashfunction) CountingBloomFilter {
+  m, k := bloomParameters(expectedSize, falsePositiveRate)
+  return newCountingBloomFilter(m, k, hash)
+}
+
+// bloomParameters computes the optimal number of bits m and hash functions k
+// for n elements and false positive rate p.
+func bloomParameters(n int, p float64) (m int, k int) {
+  if p <= 0 || p >= 1 {
+    panic("NewBloomFilter: false positive rate not in (0, 1)")
+  }
+  if n < 1 {
+    n = 1
+  }
+  m = int(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+  if m < 64 {
+    m = 64
+  }
+  k = int(math.Round(float64(m) / float64(n) * math.Ln2))
+  if k < 1 {
+    k = 1
+  }
+  return m, k
+}
+
+// bloomPositions calls f for the k positions of elem in a filter with m bits
+// until f returns false. Positions are derived from a single hash value by
+// double hashing. bloomPositions returns false if f returned false.
+func bloomPositions(hash Hashfunction, elem interface{}, m, k int, f func (int) bool) bool {
+  h := uint64(hash(elem))
+  h1 := mix64(h)
+  h2 := mix64(h ^ 0x9e3779b97f4a7c15) | 1
+  for i := 0; i < k; i++ {
+    if !f(int((h1 + uint64(i) * h2) % uint64(m))) {
+      return false
+    }
+  }
+  return true
+}
+
+func mix64(h uint64) uint64 {
+  h ^= h >> 33
+  h *= 0xff51afd7ed558ccd
+  h ^= h >> 33
+  h *= 0xc4ceb9fe1a85ec53
+  h ^= h >> 33
+  return h
+}
+
+// bloomEstimate estimates the number of elements of a filter with m bits and
+// k hash functions of which x bits are set.
+func bloomEstimate(m, k, x int) int {
+  if x >= m {
+    return int(float64(m) / float64(k))
+  }
+  return int(math.Round(-float64(m) / float64(k) * math.Log(1 - float64(x) / float64(m))))
+}
+
+func bloomString(name string, m, k int) string {
+  return name + "[bits=" + strconv.Itoa(m) + ", hashes=" + strconv.Itoa(k) + "]"
+}
+
+func newBloomFilter(m, k int, hash Hashfunction) *bloomFilter {
+  res := &bloomFilter{hash: hash, m: m, k: k, words: make([]uint64, (m + 63) / 64)}
+  res.CollectionDerived = EmbeddedCollection(res)
+  return res
+}
+
+type bloomFilter struct {
+  hash Hashfunction
+  m int
+  k int
+  words []uint64
+  CollectionDerived
+}
+
+func (this *bloomFilter) Contains(elem interface{}) bool {
+  return bloomPositions(this.hash, elem, this.m, this.k, func (i int) bool {
+    return this.words[i / 64] & (1 << uint(i % 64)) != 0
+  })
+}
+
+func (this *bloomFilter) Include(elements ...interface{}) {
+  for _, elem := range elements {
+    bloomPositions(this.hash, elem, this.m, this.k, func (i int) bool {
+      this.words[i / 64] |= 1 << uint(i % 64)
+      return true
+    })
+  }
+}
+
+func (this *bloomFilter) IncludeFrom(coll Container) {
+  for iter := coll.Elements(); iter.HasNext(); {
+    this.Include(iter.Next())
+  }
+}
+
+func (this *bloomFilter) Clear() {
+  for i := range this.words {
+    this.words[i] = 0
+  }
+}
+
+func (this *bloomFilter) Bits() int {
+  return this.m
+}
+
+func (this *bloomFilter) Hashes() int {
+  return this.k
+}
+
+func (this *bloomFilter) setBits() int {
+  res := 0
+  for _, word := range this.words {
+    res += bits.OnesCount64(word)
+  }
+  return res
+}
+
+func (this *bloomFilter) ApproximateSize() int {
+  return bloomEstimate(this.m, this.k, this.setBits())
+}
+
+func (this *bloomFilter) FalsePositiveRate() float64 {
+  return math.Pow(float64(this.setBits()) / float64(this.m), float64(this.k))
+}
+
+func (this *bloomFilter) IsCompatible(other BloomFilter) bool {
+  return this.m == other.Bits() && this.k == other.Hashes()
+}
+
+func (this *bloomFilter) Union(other BloomFilter) BloomFilter {
+  if !this.IsCompatible(other) {
+    panic("BloomFilter.Union: incompatible filters")
+  }
+  res := this.copy()
+  switch o := other.(type) {
+    case *bloomFilter:
+      for i, word := range o.words {
+        res.words[i] |= word
+      }
+    case *countingBloomFilter:
+      for i, count := range o.counters {
+        if count > 0 {
+          res.words[i / 64] |= 1 << uint(i % 64)
+        }
+      }
+    default:
+      panic("BloomFilter.Union: unsupported filter")
+  }
+  return res
+}
+
+func (this *bloomFilter) copy() *bloomFilter {
+  res := newBloomFilter(this.m, this.k, this.hash)
+  copy(res.words, this.words)
+  return res
+}
+
+func (this *bloomFilter) Copy() BloomFilter {
+  return this.copy()
+}
+
+func (this *bloomFilter) String() string {
+  return bloomString("BloomFilter", this.m, this.k)
+}
+
+func newCountingBloomFilter(m, k int, hash Hashfunction) *countingBloomFilter {
+  res := &countingBloomFilter{hash: hash, m: m, k: k, counters: make([]uint8, m)}
+  res.CollectionDerived = EmbeddedCollection(res)
+  return res
+}
+
+type countingBloomFilter struct {
+  hash Hashfunction
+  m int
+  k int
+  counters []uint8
+  CollectionDerived
+}
+
+func (this *countingBloomFilter) Contains(elem interface{}) bool {
+  return bloomPositions(this.hash, elem, this.m, this.k, func (i int) bool {
+    return this.counters[i] > 0
+  })
+}
+
+func (this *countingBloomFilter) Include(elements ...interface{}) {
+  for _, elem := range elements {
+    bloomPositions(this.hash, elem, this.m, this.k, func (i int) bool {
+      if this.counters[i] < math.MaxUint8 {
+        this.counters[i]++
+      }
+      return true
+    })
+  }
+}
+
+func (this *countingBloomFilter) IncludeFrom(coll Container) {
+  for iter := coll.Elements(); iter.HasNext(); {
+    this.Include(iter.Next())
+  }
+}
+
+func (this *countingBloomFilter) Exclude(elements ...interface{}) {
+  for _, elem := range elements {
+    if this.Contains(elem) {
+      bloomPositions(this.hash, elem, this.m, this.k, func (i int) bool {
+        if this.counters[i] < math.MaxUint8 {
+          this.counters[i]--
+        }
+        return true
+      })
+    }
+  }
+}
+
+func (this *countingBloomFilter) Clear() {
+  for i := range this.counters {
+    this.counters[i] = 0
+  }
+}
+
+func (this *countingBloomFilter) Bits() int {
+  return this.m
+}
+
+func (this *countingBloomFilter) Hashes() int {
+  return this.k
+}
+
+func (this *countingBloomFilter) setBits() int {
+  res := 0
+  for _, count := range this.counters {
+    if count > 0 {
+      res++
+    }
+  }
+  return res
+}
+
+func (this *countingBloomFilter) ApproximateSize() int {
+  return bloomEstimate(this.m, this.k, this.setBits())
+}
+
+func (this *countingBloomFilter) FalsePositiveRate() float64 {
+  return math.Pow(float64(this.setBits()) / float64(this.m), float64(this.k))
+}
+
+func (this *countingBloomFilter) IsCompatible(other BloomFilter) bool {
+  return this.m == other.Bits() && this.k == other.Hashes()
+}
+
+// Union of two counting bloom filters adds up their counters. A counting
+// filter can only be combined with another counting filter.
+func (this *countingBloomFilter) Union(other BloomFilter) BloomFilter {
+  o, counting := other.(*countingBloomFilter)
+  if !counting {
+    panic("CountingBloomFilter.Union: filter is not a counting filter")
+  } else if !this.IsCompatible(other) {
+    panic("CountingBloomFilter.Union: incompatible filters")
+  }
+  res := this.copy()
+  for i, count := range o.counters {
+    if sum := int(res.counters[i]) + int(count); sum < math.MaxUint8 {
+      res.counters[i] = uint8(sum)
+    } else {
+      res.counters[i] = math.MaxUint8
+    }
+  }
+  return res
+}
+
+func (this *countingBloomFilter) copy() *countingBloomFilter {
+  res := newCountingBloomFilter(this.m, this.k, this.hash)
+  copy(res.counters, this.counters)
+  return res
+}
+
+func (this *countingBloomFilter) Copy() BloomFilter {
+  return this.copy()
+}
+
+func (this *countingBloomFilter) String() string {
+  return bloomString("CountingBloomFilter", this.m, this.k)
+}
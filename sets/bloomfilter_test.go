@@ -0,0 +1,96 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func falsePositives(filter BloomFilter, from, to int) int {
+  res := 0
+  for i := from; i < to; i++ {
+    if filter.Contains(i) {
+      res++
+    }
+  }
+  return res
+}
+
+func TestBloomFilter(t *testing.T) {
+  filter := NewBloomFilter(1000, 0.01, UniversalHash)
+  elements := BitSet.New()
+  for i := 0; i < 1000; i++ {
+    elements.Include(i)
+  }
+  filter.IncludeFrom(elements)
+  if !filter.ContainsAllFrom(elements) {
+    t.Fatalf("Bloom filter has false negatives")
+  }
+  if fp := falsePositives(filter, 1000, 11000); fp > 200 {
+    t.Errorf("Expected about 100 false positives; was %d", fp)
+  }
+  if size := filter.ApproximateSize(); size < 900 || size > 1100 {
+    t.Errorf("Expected approximate size of about 1000; was %d", size)
+  }
+  if rate := filter.FalsePositiveRate(); rate > 0.02 {
+    t.Errorf("Expected false positive rate of about 0.01; was %f", rate)
+  }
+  filter.Clear()
+  if filter.ContainsSome(1, 2, 3) || filter.ApproximateSize() != 0 {
+    t.Errorf("Expected filter to be empty after Clear")
+  }
+}
+
+func TestBloomFilterUnion(t *testing.T) {
+  f1 := NewBloomFilter(100, 0.01, UniversalHash)
+  f2 := NewBloomFilter(100, 0.01, UniversalHash)
+  f1.Include(1, 2, 3)
+  f2.Include(4, 5, 6)
+  union := f1.Union(f2)
+  if !union.ContainsAll(1, 2, 3, 4, 5, 6) || f1.Contains(4) || f2.Contains(1) {
+    t.Errorf("Union is not combining filters correctly")
+  }
+  defer func() {
+    if recover() == nil {
+      t.Errorf("Expected union of incompatible filters to panic")
+    }
+  }()
+  f1.Union(NewBloomFilter(1000, 0.01, UniversalHash))
+}
+
+func TestCountingBloomFilter(t *testing.T) {
+  filter := NewCountingBloomFilter(1000, 0.01, UniversalHash)
+  for i := 0; i < 1000; i++ {
+    filter.Include(i)
+  }
+  for i := 0; i < 1000; i += 2 {
+    filter.Exclude(i)
+  }
+  for i := 1; i < 1000; i += 2 {
+    if !filter.Contains(i) {
+      t.Fatalf("Counting bloom filter has false negative %d", i)
+    }
+  }
+  if fp := falsePositives(filter, 0, 1000) - 500; fp > 20 {
+    t.Errorf("Expected about 5 false positives for excluded elements; was %d", fp)
+  }
+  other := NewCountingBloomFilter(1000, 0.01, UniversalHash)
+  other.Include(0)
+  union := filter.Union(other).(CountingBloomFilter)
+  union.Exclude(1)
+  if !union.Contains(0) || union.Contains(1) || !filter.Contains(1) {
+    t.Errorf("Union is not combining counting filters correctly")
+  }
+}
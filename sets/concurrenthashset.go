@@ -0,0 +1,132 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/impl"
+
+
+// ConcurrentSet is a MutableSet which can be accessed concurrently from
+// multiple goroutines without external synchronization. Single-element
+// operations are atomic; operations involving multiple elements, like
+// IncludeFrom or IntersectWith, are not. Iterators are weakly consistent.
+type ConcurrentSet interface {
+  MutableSet
+
+  // IncludeIfAbsent atomically includes elem and returns true if elem was
+  // not an element of the set before.
+  IncludeIfAbsent(elem interface{}) bool
+}
+
+// ConcurrentHashSet creates sets which implement ConcurrentSet.
+var ConcurrentHashSet MutableSetClass =
+    ConcurrentHashSetClass(UniversalHash, UniversalEquality, 16)
+
+// ConcurrentHashSetClass returns a class for concurrent hash sets which
+// stripe their elements across at least concurrency independently locked
+// hash tables.
+func ConcurrentHashSetClass(hash Hashfunction,
+                            equals Equality,
+                            concurrency int) MutableSetClass {
+  return &concurrentHashSetClass{hash, equals, concurrency}
+}
+
+type concurrentHashSetClass struct {
+  hash Hashfunction
+  equals Equality
+  concurrency int
+}
+
+func (this *concurrentHashSetClass) Embed(obj MutableSet) MutableSet {
+  res := new(concurrentHashSet)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableSetDerived = EmbeddedMutableSet(obj)
+  res.table = NewConcurrentHashTable(this.concurrency, 17 * this.concurrency, 80,
+                                     this.hash, this.equals)
+  return res
+}
+
+func (this *concurrentHashSetClass) New(elements ...interface{}) MutableSet {
+  res := this.Embed(nil)
+  res.Include(elements...)
+  return res
+}
+
+func (this *concurrentHashSetClass) From(coll Container) MutableSet {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+type concurrentHashSet struct {
+  obj MutableSet
+  table *ConcurrentHashTable
+  MutableSetDerived
+}
+
+func (this *concurrentHashSet) Size() int {
+  return this.table.Size()
+}
+
+func (this *concurrentHashSet) Contains(elem interface{}) bool {
+  _, exists := this.table.Get(elem)
+  return exists
+}
+
+func (this *concurrentHashSet) Elements() Iterator {
+  return &concurrentHashSetIterator{this.table.Iterator()}
+}
+
+func (this *concurrentHashSet) Class() MutableSetClass {
+  return ConcurrentHashSetClass(this.table.Hash(),
+                                this.table.Equality(),
+                                this.table.Concurrency())
+}
+
+func (this *concurrentHashSet) Include(elements ...interface{}) {
+  for _, elem := range elements {
+    this.table.PutIfAbsent(elem, nil)
+  }
+}
+
+func (this *concurrentHashSet) IncludeIfAbsent(elem interface{}) bool {
+  _, loaded := this.table.PutIfAbsent(elem, nil)
+  return !loaded
+}
+
+func (this *concurrentHashSet) Exclude(elements ...interface{}) {
+  for _, elem := range elements {
+    this.table.Delete(elem)
+  }
+}
+
+func (this *concurrentHashSet) Clear() {
+  this.table.Clear()
+}
+
+type concurrentHashSetIterator struct {
+  entryIter *ConcurrentHashEntryIterator
+}
+
+func (this *concurrentHashSetIterator) HasNext() bool {
+  return this.entryIter.HasNext()
+}
+
+func (this *concurrentHashSetIterator) Next() interface{} {
+  return this.entryIter.Next().Key
+}
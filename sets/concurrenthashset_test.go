@@ -0,0 +1,47 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "sync"
+import "testing"
+
+
+func TestConcurrentHashSetClass(t *testing.T) {
+  set := ConcurrentHashSet.New().(ConcurrentSet)
+  var wg sync.WaitGroup
+  added := make([]int, 4)
+  for g := 0; g < 4; g++ {
+    wg.Add(1)
+    go func(g int) {
+      defer wg.Done()
+      for i := 0; i < 500; i++ {
+        if set.IncludeIfAbsent(i) {
+          added[g]++
+        }
+      }
+    }(g)
+  }
+  wg.Wait()
+  checkSize(t, set, 500, "set")
+  if added[0] + added[1] + added[2] + added[3] != 500 {
+    t.Errorf("Expected exactly 500 successful IncludeIfAbsent calls")
+  }
+  set.Exclude(0, 1, 2)
+  checkSize(t, set, 497, "set")
+  for iter := set.Elements(); iter.HasNext(); {
+    set.Exclude(iter.Next())
+  }
+  checkSize(t, set, 0, "set")
+}
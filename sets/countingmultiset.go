@@ -0,0 +1,226 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/impl"
+
+
+var HashMultiset MultisetClass = HashMultisetClass(UniversalHash, UniversalEquality)
+
+var SortedMultiset MultisetClass = SortedMultisetClass(UniversalComparison)
+
+// HashMultisetClass returns a class for multisets which keep track of the
+// number of occurrences of their elements in a hash table.
+func HashMultisetClass(hash Hashfunction, equals Equality) MultisetClass {
+  return &countingMultisetClass{func () countTable {
+    return &hashCountTable{NewHashTable(17, 80, hash, equals)}
+  }}
+}
+
+// SortedMultisetClass returns a class for multisets which keep track of the
+// number of occurrences of their elements in a balanced tree. These multisets
+// return their elements in ascending order.
+func SortedMultisetClass(comp Comparison) MultisetClass {
+  return &countingMultisetClass{func () countTable {
+    return &treeCountTable{NewAvlTree(comp)}
+  }}
+}
+
+type countingMultisetClass struct {
+  newTable func () countTable
+}
+
+func (this *countingMultisetClass) Embed(obj MutableMultiset) MutableMultiset {
+  res := new(countingMultiset)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.class = this
+  res.MutableMultisetDerived = EmbeddedMutableMultiset(obj)
+  res.table = this.newTable()
+  res.size = 0
+  return res
+}
+
+func (this *countingMultisetClass) New(elements ...interface{}) MutableMultiset {
+  res := this.Embed(nil)
+  res.Include(elements...)
+  return res
+}
+
+func (this *countingMultisetClass) From(coll Container) MutableMultiset {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+type countingMultiset struct {
+  obj MutableMultiset
+  class MultisetClass
+  table countTable
+  size int
+  MutableMultisetDerived
+}
+
+func (this *countingMultiset) Size() int {
+  return this.size
+}
+
+func (this *countingMultiset) Count(elem interface{}) int {
+  return this.table.count(elem)
+}
+
+func (this *countingMultiset) Contains(elem interface{}) bool {
+  return this.table.count(elem) > 0
+}
+
+func (this *countingMultiset) Elements() Iterator {
+  return NewMultisetIterator(this.table.elements(), this)
+}
+
+func (this *countingMultiset) ElementSet() DependentSet {
+  res := new(countTableElementSet)
+  res.SetDerived = EmbeddedDependentSet(res)
+  res.table = this.table
+  return res
+}
+
+func (this *countingMultiset) Class() MultisetClass {
+  return this.class
+}
+
+func (this *countingMultiset) Add(elem interface{}, n int) {
+  if n < 0 {
+    panic("countingMultiset.Add: negative number of occurrences")
+  }
+  if n > 0 {
+    this.table.set(elem, this.table.count(elem) + n)
+    this.size += n
+  }
+}
+
+func (this *countingMultiset) Remove(elem interface{}, n int) {
+  if n < 0 {
+    panic("countingMultiset.Remove: negative number of occurrences")
+  }
+  count := this.table.count(elem)
+  if n > count {
+    n = count
+  }
+  if n > 0 {
+    this.table.set(elem, count - n)
+    this.size -= n
+  }
+}
+
+func (this *countingMultiset) Clear() {
+  this.table.clear()
+  this.size = 0
+}
+
+// countTableElementSet is a view of the distinct elements of a countTable.
+type countTableElementSet struct {
+  SetDerived
+  table countTable
+}
+
+func (this *countTableElementSet) Size() int {
+  return this.table.size()
+}
+
+func (this *countTableElementSet) Contains(elem interface{}) bool {
+  return this.table.count(elem) > 0
+}
+
+func (this *countTableElementSet) Elements() Iterator {
+  return this.table.elements()
+}
+
+// countTable abstracts the data structure which maps the distinct elements
+// of a countingMultiset to their number of occurrences. Setting the count
+// of an element to 0 removes the element.
+type countTable interface {
+  size() int
+  count(elem interface{}) int
+  set(elem interface{}, n int)
+  elements() Iterator
+  clear()
+}
+
+type hashCountTable struct {
+  table *HashTable
+}
+
+func (this *hashCountTable) size() int {
+  return this.table.Size()
+}
+
+func (this *hashCountTable) count(elem interface{}) int {
+  if entry := this.table.FindEntry(elem); entry != nil {
+    return entry.Value.(int)
+  }
+  return 0
+}
+
+func (this *hashCountTable) set(elem interface{}, n int) {
+  if n == 0 {
+    this.table.DeleteEntry(elem)
+  } else if entry := this.table.FindEntry(elem); entry != nil {
+    entry.Value = n
+  } else {
+    this.table.AddEntry(elem, n)
+  }
+}
+
+func (this *hashCountTable) elements() Iterator {
+  return &hashSetIterator{this.table.Iterator()}
+}
+
+func (this *hashCountTable) clear() {
+  this.table.Clear()
+}
+
+type treeCountTable struct {
+  tree *AvlTree
+}
+
+func (this *treeCountTable) size() int {
+  return this.tree.Size()
+}
+
+func (this *treeCountTable) count(elem interface{}) int {
+  if node := this.tree.FindNode(elem); node != nil {
+    return node.Value.(int)
+  }
+  return 0
+}
+
+func (this *treeCountTable) set(elem interface{}, n int) {
+  if n == 0 {
+    this.tree.DeleteKey(elem)
+  } else if node, added := this.tree.InsertNode(elem, n); !added {
+    node.Value = n
+  }
+}
+
+func (this *treeCountTable) elements() Iterator {
+  return &treeSetIterator{this.tree.Iterator()}
+}
+
+func (this *treeCountTable) clear() {
+  this.tree.Clear()
+}
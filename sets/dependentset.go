@@ -119,6 +119,135 @@ func (this *differenceSet) Elements() Iterator {
   return NewFilterIterator(Negate(this.snd.Func()), this.fst.Elements())
 }
 
+// Symmetric set difference
+
+func newSymmetricDifferenceSet(fst Set, snd Set) DependentSet {
+  res := new(symmetricDifferenceSet)
+  res.SetDerived = EmbeddedDependentSet(res)
+  res.fst = fst
+  res.snd = snd
+  return res
+}
+
+type symmetricDifferenceSet struct {
+  SetDerived
+  fst Set
+  snd Set
+}
+
+func (this *symmetricDifferenceSet) Size() int {
+  return CountElements(this.Elements())
+}
+
+func (this *symmetricDifferenceSet) Contains(elem interface{}) bool {
+  return this.fst.Contains(elem) != this.snd.Contains(elem)
+}
+
+func (this *symmetricDifferenceSet) Elements() Iterator {
+  return NewCompositeIterator(
+      NewFilterIterator(Negate(this.snd.Func()), this.fst.Elements()),
+      NewFilterIterator(Negate(this.fst.Func()), this.snd.Elements()))
+}
+
+// Power set (the elements are read-only sets)
+
+func newPowerSet(base Set) DependentSet {
+  res := new(powerSet)
+  res.SetDerived = EmbeddedDependentSet(res)
+  res.base = base
+  return res
+}
+
+type powerSet struct {
+  SetDerived
+  base Set
+}
+
+func (this *powerSet) Size() int {
+  n := this.base.Size()
+  if n >= 63 {
+    panic("powerSet.Size: size not representable")
+  }
+  return 1 << uint(n)
+}
+
+func (this *powerSet) Contains(elem interface{}) bool {
+  if set, valid := elem.(Set); valid {
+    return set.ForAll(this.base.Func())
+  }
+  return false
+}
+
+func (this *powerSet) Elements() Iterator {
+  class := ListSet
+  if mutable, valid := this.base.(MutableSet); valid {
+    class = mutable.Class()
+  }
+  return &powerSetIterator{Enum.From(this.base), class, 0, this.Size()}
+}
+
+type powerSetIterator struct {
+  elements FiniteContainer
+  class MutableSetClass
+  next int
+  end int
+}
+
+func (this *powerSetIterator) HasNext() bool {
+  return this.next < this.end
+}
+
+func (this *powerSetIterator) Next() interface{} {
+  if !this.HasNext() {
+    panic("powerSetIterator.Next: no next element")
+  }
+  res := this.class.New()
+  i := 0
+  this.elements.ForEach(func (elem interface{}) {
+    if this.next & (1 << uint(i)) != 0 {
+      res.Include(elem)
+    }
+    i++
+  })
+  this.next++
+  return res.ReadOnly()
+}
+
+// Cartesian product (the elements are pairs)
+
+func newProductSet(fst Set, snd Set) DependentSet {
+  res := new(productSet)
+  res.SetDerived = EmbeddedDependentSet(res)
+  res.fst = fst
+  res.snd = snd
+  return res
+}
+
+type productSet struct {
+  SetDerived
+  fst Set
+  snd Set
+}
+
+func (this *productSet) Size() int {
+  return this.fst.Size() * this.snd.Size()
+}
+
+func (this *productSet) Contains(elem interface{}) bool {
+  if pair, valid := elem.(Pair); valid {
+    return this.fst.Contains(pair.First()) && this.snd.Contains(pair.Second())
+  }
+  return false
+}
+
+func (this *productSet) Elements() Iterator {
+  return this.fst.FlatMap(func (x interface{}) Iterator {
+    return NewMappedIterator(func (y interface{}) interface{} {
+      return NewPair(x, y)
+    }, this.snd.Elements())
+  }).Elements()
+}
+
 // Set proxy (to hide potential functionality for mutating the set)
 
 func newWrappedSet(encapsulated Set, immutable bool) DependentSet {
@@ -0,0 +1,232 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/impl"
+
+
+// DisjointSets maintains a partition of elements into disjoint sets. Each set
+// is identified by one of its elements, its representative. Sets are merged
+// with Union; they can never be split again. The implementation is a
+// disjoint-set forest using union by rank and path compression, such that
+// all operations take nearly constant amortized time.
+type DisjointSets interface {
+
+  // Size returns the number of elements in all sets.
+  Size() int
+
+  // Count returns the number of disjoint sets.
+  Count() int
+
+  // MakeSet adds a new singleton set for every given element which is not
+  // yet contained in one of the sets.
+  MakeSet(elements ...interface{})
+
+  // Contains returns true if elem is an element of one of the sets.
+  Contains(elem interface{}) bool
+
+  // Find returns the representative of the set containing elem. If elem is
+  // not an element of any set, exists is false.
+  Find(elem interface{}) (repr interface{}, exists bool)
+
+  // Union merges the sets containing a and b. Elements not contained in any
+  // set yet are added first. Union returns false if a and b were in the same
+  // set already.
+  Union(a, b interface{}) bool
+
+  // Connected returns true if a and b are elements of the same set.
+  Connected(a, b interface{}) bool
+
+  // SetOf returns the set containing elem, or nil if elem is not an element
+  // of any set. The returned set is an immutable snapshot.
+  SetOf(elem interface{}) Set
+
+  // Partition returns a container of all disjoint sets. The container is
+  // derived from the current state every time it is iterated over; each
+  // set it returns is an immutable snapshot.
+  Partition() Container
+
+  // Clear removes all elements and sets.
+  Clear()
+
+  String() string
+}
+
+// NewDisjointSets returns a new empty DisjointSets object whose elements are
+// compared using the given hash function and equality.
+func NewDisjointSets(hash Hashfunction, equals Equality) DisjointSets {
+  return &disjointSets{NewHashTable(17, 80, hash, equals), 0}
+}
+
+type disjointSets struct {
+  nodes *HashTable
+  count int
+}
+
+// disjointNode is a node of the disjoint-set forest. Besides the forest
+// structure, all nodes of one set are linked in a circular list via next,
+// such that the members of a set can be enumerated.
+type disjointNode struct {
+  elem interface{}
+  parent *disjointNode
+  next *disjointNode
+  rank int
+}
+
+func (this *disjointSets) Size() int {
+  return this.nodes.Size()
+}
+
+func (this *disjointSets) Count() int {
+  return this.count
+}
+
+func (this *disjointSets) node(elem interface{}) *disjointNode {
+  if entry := this.nodes.FindEntry(elem); entry != nil {
+    return entry.Value.(*disjointNode)
+  }
+  return nil
+}
+
+func (this *disjointSets) makeNode(elem interface{}) *disjointNode {
+  node := this.node(elem)
+  if node == nil {
+    node = &disjointNode{elem: elem}
+    node.parent = node
+    node.next = node
+    this.nodes.AddEntry(elem, node)
+    this.count++
+  }
+  return node
+}
+
+func (this *disjointSets) MakeSet(elements ...interface{}) {
+  for _, elem := range elements {
+    this.makeNode(elem)
+  }
+}
+
+func (this *disjointSets) Contains(elem interface{}) bool {
+  return this.node(elem) != nil
+}
+
+// root returns the root of the tree containing node and compresses the path
+// from node to the root.
+func (this *disjointSets) root(node *disjointNode) *disjointNode {
+  root := node
+  for root.parent != root {
+    root = root.parent
+  }
+  for node != root {
+    next := node.parent
+    node.parent = root
+    node = next
+  }
+  return root
+}
+
+func (this *disjointSets) Find(elem interface{}) (repr interface{}, exists bool) {
+  if node := this.node(elem); node != nil {
+    return this.root(node).elem, true
+  }
+  return nil, false
+}
+
+func (this *disjointSets) Union(a, b interface{}) bool {
+  x := this.root(this.makeNode(a))
+  y := this.root(this.makeNode(b))
+  if x == y {
+    return false
+  }
+  if x.rank < y.rank {
+    x, y = y, x
+  }
+  y.parent = x
+  if x.rank == y.rank {
+    x.rank++
+  }
+  x.next, y.next = y.next, x.next
+  this.count--
+  return true
+}
+
+func (this *disjointSets) Connected(a, b interface{}) bool {
+  x, y := this.node(a), this.node(b)
+  return x != nil && y != nil && this.root(x) == this.root(y)
+}
+
+func (this *disjointSets) members(node *disjointNode) Set {
+  res := HashSetClass(this.nodes.Hash(), this.nodes.Equality()).New(node.elem)
+  for member := node.next; member != node; member = member.next {
+    res.Include(member.elem)
+  }
+  return res.ReadOnly()
+}
+
+func (this *disjointSets) SetOf(elem interface{}) Set {
+  if node := this.node(elem); node != nil {
+    return this.members(node)
+  }
+  return nil
+}
+
+func (this *disjointSets) Partition() Container {
+  res := &disjointSetsPartition{this, nil}
+  res.Container = EmbeddedContainer(res)
+  return res
+}
+
+func (this *disjointSets) Clear() {
+  this.nodes.Clear()
+  this.count = 0
+}
+
+func (this *disjointSets) String() string {
+  return this.Partition().String()
+}
+
+type disjointSetsPartition struct {
+  sets *disjointSets
+  Container
+}
+
+func (this *disjointSetsPartition) Elements() Iterator {
+  return &disjointSetsIterator{this.sets, this.sets.nodes.Iterator(), nil}
+}
+
+type disjointSetsIterator struct {
+  sets *disjointSets
+  entryIter *HashEntryIterator
+  root *disjointNode
+}
+
+func (this *disjointSetsIterator) HasNext() bool {
+  for this.root == nil && this.entryIter.HasNext() {
+    if node := this.entryIter.Next().Value.(*disjointNode); node.parent == node {
+      this.root = node
+    }
+  }
+  return this.root != nil
+}
+
+func (this *disjointSetsIterator) Next() interface{} {
+  if !this.HasNext() {
+    panic("disjointSetsIterator.Next: no next element")
+  }
+  res := this.sets.members(this.root)
+  this.root = nil
+  return res
+}
@@ -0,0 +1,71 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func TestDisjointSets(t *testing.T) {
+  ds := NewDisjointSets(UniversalHash, UniversalEquality)
+  ds.MakeSet(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+  if ds.Size() != 10 || ds.Count() != 10 {
+    t.Fatalf("Expected 10 singleton sets; was %d sets of %d elements", ds.Count(), ds.Size())
+  }
+  for i := 0; i < 10; i += 2 {
+    ds.Union(i, (i + 4) % 10)
+  }
+  if !ds.Union(1, 3) || ds.Union(3, 1) {
+    t.Errorf("Union does not report merges correctly")
+  }
+  if ds.Count() != 5 {
+    t.Errorf("Expected 5 sets; was %d", ds.Count())
+  }
+  if !ds.Connected(0, 8) || !ds.Connected(1, 3) || ds.Connected(0, 1) || ds.Connected(0, 10) {
+    t.Errorf("Connected does not reflect unions")
+  }
+  r0, _ := ds.Find(0)
+  r6, _ := ds.Find(6)
+  if r0 != r6 {
+    t.Errorf("Expected 0 and 6 to have the same representative")
+  }
+  if _, exists := ds.Find(10); exists {
+    t.Errorf("Expected 10 not to be found")
+  }
+  if set := ds.SetOf(4); set.Size() != 5 || !set.ContainsAll(0, 2, 4, 6, 8) {
+    t.Errorf("Unexpected set %v containing 4", set)
+  }
+  if set := ds.SetOf(5); set.Size() != 1 || !set.Contains(5) {
+    t.Errorf("Unexpected set %v containing 5", set)
+  }
+  if ds.SetOf(10) != nil {
+    t.Errorf("Expected no set for 10")
+  }
+  sizes := 0
+  ds.Partition().ForEach(func (x interface{}) {
+    sizes += x.(Set).Size()
+  })
+  if sizes != 10 || CountElements(ds.Partition().Elements()) != 5 {
+    t.Errorf("Partition does not cover all elements")
+  }
+  ds.Union(11, 12)
+  if ds.Size() != 12 || ds.Count() != 6 || !ds.Connected(11, 12) {
+    t.Errorf("Union does not add new elements")
+  }
+  ds.Clear()
+  if ds.Size() != 0 || ds.Count() != 0 || !ds.Partition().IsEmpty() {
+    t.Errorf("Expected no sets after Clear")
+  }
+}
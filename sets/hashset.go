@@ -23,12 +23,25 @@ var HashSet MutableSetClass = HashSetClass(UniversalHash, UniversalEquality)
 var ImmutableHashSet SetClass = ImmutableSet(HashSet)
 
 func HashSetClass(hash Hashfunction, equals Equality) MutableSetClass {
-  return &hashSetClass{hash, equals}
+  return HashSetClassWithTable(hash, equals, DefaultHashTable)
+}
+
+// HashSetClassWithTable returns a class of hash sets which are backed by hash
+// tables configured by table. With open addressing, sets do not allocate
+// memory per element and shrink when elements get excluded.
+func HashSetClassWithTable(hash Hashfunction,
+                           equals Equality,
+                           table HashTableConfig) MutableSetClass {
+  if table.OpenAddressing {
+    return &openHashSetClass{hash, equals, table}
+  }
+  return &hashSetClass{hash, equals, table}
 }
 
 type hashSetClass struct {
   hash Hashfunction
   equals Equality
+  table HashTableConfig
 }
 
 func (this *hashSetClass) Embed(obj MutableSet) MutableSet {
@@ -38,7 +51,8 @@ func (this *hashSetClass) Embed(obj MutableSet) MutableSet {
   }
   res.obj = obj
   res.MutableSetDerived = EmbeddedMutableSet(obj)
-  res.table = NewHashTable(17, 80, this.hash, this.equals)
+  res.class = this
+  res.table = this.table.NewTable(this.hash, this.equals)
   return res
 }
 
@@ -56,6 +70,7 @@ func (this *hashSetClass) From(coll Container) MutableSet {
 
 type hashSet struct {
   obj MutableSet
+  class *hashSetClass
   table *HashTable
   MutableSetDerived
 }
@@ -73,7 +88,7 @@ func (this *hashSet) Elements() Iterator {
 }
 
 func (this *hashSet) Class() MutableSetClass {
-  return HashSetClass(this.table.Hash(), this.table.Equality())
+  return this.class
 }
 
 func (this *hashSet) Include(elements ...interface{}) {
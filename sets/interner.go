@@ -0,0 +1,144 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package sets
+
+import "unique"
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/impl"
+
+
+// Interner is a Set of canonical values. Intern returns, for every value, the
+// canonical instance of all values equal to it, making it the canonical
+// instance if there is none yet. Interning values like strings allows equal
+// values to share memory and to be compared cheaply.
+//
+// Interners rely on package unique and are only available with Go 1.23 or
+// later.
+type Interner interface {
+  Set
+
+  // Intern includes value in this set if no equal value is an element yet,
+  // and returns the element equal to value.
+  Intern(value interface{}) interface{}
+
+  // Clear removes all canonical values from this set. Values which are
+  // interned afterwards might not be identical to values interned before.
+  Clear()
+}
+
+// NewInterner returns an interner which determines canonical values with the
+// unique package. Values are equal if they are equal with respect to Go's ==
+// operator; interning a value which is not comparable panics. Canonical
+// values are shared with all other users of the unique package.
+func NewInterner() Interner {
+  res := &uniqueInterner{handles: make(map[unique.Handle[interface{}]]bool)}
+  res.SetDerived = EmbeddedSet(res)
+  return res
+}
+
+type uniqueInterner struct {
+  handles map[unique.Handle[interface{}]]bool
+  SetDerived
+}
+
+func (this *uniqueInterner) Size() int {
+  return len(this.handles)
+}
+
+// Contains returns true if elem was interned. Values which are not comparable
+// are never contained.
+func (this *uniqueInterner) Contains(elem interface{}) (res bool) {
+  defer func () {
+    if recover() != nil {
+      res = false
+    }
+  }()
+  return this.handles[unique.Make(elem)]
+}
+
+func (this *uniqueInterner) Elements() Iterator {
+  values := make([]interface{}, 0, len(this.handles))
+  for handle := range this.handles {
+    values = append(values, handle.Value())
+  }
+  return &internerIterator{values}
+}
+
+func (this *uniqueInterner) Intern(value interface{}) interface{} {
+  handle := unique.Make(value)
+  this.handles[handle] = true
+  return handle.Value()
+}
+
+func (this *uniqueInterner) Clear() {
+  this.handles = make(map[unique.Handle[interface{}]]bool)
+}
+
+// NewHashInterner returns an interner which compares values with the given
+// hash function and equality. It supports values which are not comparable
+// with Go's == operator.
+func NewHashInterner(hash Hashfunction, equals Equality) Interner {
+  res := &hashInterner{table: NewHashTable(17, 80, hash, equals)}
+  res.SetDerived = EmbeddedSet(res)
+  return res
+}
+
+type hashInterner struct {
+  table *HashTable
+  SetDerived
+}
+
+func (this *hashInterner) Size() int {
+  return this.table.Size()
+}
+
+func (this *hashInterner) Contains(elem interface{}) bool {
+  return this.table.FindEntry(elem) != nil
+}
+
+func (this *hashInterner) Elements() Iterator {
+  return &hashSetIterator{this.table.Iterator()}
+}
+
+func (this *hashInterner) Intern(value interface{}) interface{} {
+  if entry := this.table.FindEntry(value); entry != nil {
+    return entry.Key
+  }
+  this.table.AddEntry(value, nil)
+  return value
+}
+
+func (this *hashInterner) Clear() {
+  this.table.Clear()
+}
+
+type internerIterator struct {
+  values []interface{}
+}
+
+func (this *internerIterator) HasNext() bool {
+  return len(this.values) > 0
+}
+
+func (this *internerIterator) Next() interface{} {
+  if len(this.values) == 0 {
+    panic("internerIterator.Next: no next element")
+  }
+  res := this.values[0]
+  this.values = this.values[1:]
+  return res
+}
@@ -0,0 +1,56 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package sets
+
+import "strings"
+import "testing"
+import "unsafe"
+import . "github.com/objecthub/containerkit"
+
+
+func TestInterner(t *testing.T) {
+  interner := NewInterner()
+  a := interner.Intern(strings.Repeat("ab", 3)).(string)
+  b := interner.Intern("a" + strings.Repeat("ba", 2) + "b").(string)
+  if unsafe.StringData(a) != unsafe.StringData(b) {
+    t.Errorf("Expected equal strings to be interned to the same instance")
+  }
+  interner.Intern(1)
+  interner.Intern(1)
+  checkSetSize(t, interner, 2, "interner")
+  if !interner.ContainsAll("ababab", 1) || interner.Contains(2) || interner.Contains([]int{1}) {
+    t.Errorf("Contains does not reflect interned values")
+  }
+  if !interner.IsSubsetOf(HashSet.New("ababab", 1, 2)) {
+    t.Errorf("Expected interned values to be a subset")
+  }
+  interner.Clear()
+  checkSetSize(t, interner, 0, "interner")
+}
+
+func TestHashInterner(t *testing.T) {
+  interner := NewHashInterner(UniversalHash, UniversalEquality)
+  p := NewPair(1, 2)
+  if interner.Intern(p) != p || interner.Intern(NewPair(1, 2)) != p {
+    t.Errorf("Expected equal pairs to be interned to the first instance")
+  }
+  interner.Intern(NewPair(2, 1))
+  checkSetSize(t, interner, 2, "interner")
+  if !interner.Contains(NewPair(2, 1)) || interner.Contains(NewPair(1, 1)) {
+    t.Errorf("Contains does not reflect interned values")
+  }
+}
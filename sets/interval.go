@@ -0,0 +1,185 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import . "github.com/objecthub/containerkit"
+import "github.com/objecthub/containerkit/util"
+
+
+// Interval represents all values between a lower and an upper bound of a
+// domain ordered by a Comparison. Each bound is either included in the
+// interval (closed) or excluded (open). Interval values do not refer to
+// a Comparison; methods depending on the order of the domain require one
+// to be passed explicitly.
+type Interval struct {
+  Lower interface{}
+  Upper interface{}
+  LowerClosed bool
+  UpperClosed bool
+}
+
+// ClosedInterval returns the interval [lower, upper].
+func ClosedInterval(lower, upper interface{}) Interval {
+  return Interval{lower, upper, true, true}
+}
+
+// HalfOpenInterval returns the interval [lower, upper).
+func HalfOpenInterval(lower, upper interface{}) Interval {
+  return Interval{lower, upper, true, false}
+}
+
+// OpenInterval returns the interval (lower, upper).
+func OpenInterval(lower, upper interface{}) Interval {
+  return Interval{lower, upper, false, false}
+}
+
+// IsEmpty returns true if there is no value between the bounds of this
+// interval.
+func (this Interval) IsEmpty(comp Comparison) bool {
+  c := comp(this.Lower, this.Upper)
+  return c > 0 || (c == 0 && !(this.LowerClosed && this.UpperClosed))
+}
+
+// Contains returns true if x is within the bounds of this interval.
+func (this Interval) Contains(comp Comparison, x interface{}) bool {
+  return !this.below(comp, x) && !this.above(comp, x)
+}
+
+// below returns true if all values of this interval are smaller than x.
+func (this Interval) below(comp Comparison, x interface{}) bool {
+  c := comp(this.Upper, x)
+  return c < 0 || (c == 0 && !this.UpperClosed)
+}
+
+// above returns true if all values of this interval are bigger than x.
+func (this Interval) above(comp Comparison, x interface{}) bool {
+  c := comp(x, this.Lower)
+  return c < 0 || (c == 0 && !this.LowerClosed)
+}
+
+// Encloses returns true if all values of other are contained in this
+// interval.
+func (this Interval) Encloses(comp Comparison, other Interval) bool {
+  return compareLower(comp, this, other) <= 0 && compareUpper(comp, this, other) >= 0
+}
+
+// Precedes returns true if all values of this interval are smaller than the
+// values of other, i.e. if the two intervals do not overlap and this interval
+// is to the left of other.
+func (this Interval) Precedes(comp Comparison, other Interval) bool {
+  c := comp(this.Upper, other.Lower)
+  return c < 0 || (c == 0 && !(this.UpperClosed && other.LowerClosed))
+}
+
+// Overlaps returns true if this interval and other have at least one value
+// in common.
+func (this Interval) Overlaps(comp Comparison, other Interval) bool {
+  return !this.Precedes(comp, other) && !other.Precedes(comp, this)
+}
+
+// Touches returns true if this interval and other overlap or if they are
+// adjacent, such that their union is an interval again.
+func (this Interval) Touches(comp Comparison, other Interval) bool {
+  return !this.separated(comp, other) && !other.separated(comp, this)
+}
+
+// separated returns true if this interval precedes other and there is a gap
+// between the two.
+func (this Interval) separated(comp Comparison, other Interval) bool {
+  c := comp(this.Upper, other.Lower)
+  return c < 0 || (c == 0 && !this.UpperClosed && !other.LowerClosed)
+}
+
+// Intersection returns the interval of values contained in both this interval
+// and other. The result is empty if the two intervals do not overlap.
+func (this Interval) Intersection(comp Comparison, other Interval) Interval {
+  res := this
+  if compareLower(comp, other, this) > 0 {
+    res.Lower, res.LowerClosed = other.Lower, other.LowerClosed
+  }
+  if compareUpper(comp, other, this) < 0 {
+    res.Upper, res.UpperClosed = other.Upper, other.UpperClosed
+  }
+  return res
+}
+
+// Span returns the smallest interval enclosing both this interval and other.
+func (this Interval) Span(comp Comparison, other Interval) Interval {
+  res := this
+  if compareLower(comp, other, this) < 0 {
+    res.Lower, res.LowerClosed = other.Lower, other.LowerClosed
+  }
+  if compareUpper(comp, other, this) > 0 {
+    res.Upper, res.UpperClosed = other.Upper, other.UpperClosed
+  }
+  return res
+}
+
+// Difference returns the values of this interval which are smaller than all
+// values of other (left) and the values which are bigger than all values of
+// other (right). Both results might be empty intervals.
+func (this Interval) Difference(comp Comparison, other Interval) (left, right Interval) {
+  left = Interval{this.Lower, other.Lower, this.LowerClosed, !other.LowerClosed}
+  if compareUpper(comp, left, this) > 0 {
+    left.Upper, left.UpperClosed = this.Upper, this.UpperClosed
+  }
+  right = Interval{other.Upper, this.Upper, !other.UpperClosed, this.UpperClosed}
+  if compareLower(comp, right, this) < 0 {
+    right.Lower, right.LowerClosed = this.Lower, this.LowerClosed
+  }
+  return left, right
+}
+
+func (this Interval) String() string {
+  res := util.NewStringBuilder()
+  if this.LowerClosed {
+    res.AppendStr("[")
+  } else {
+    res.AppendStr("(")
+  }
+  res.Append(this.Lower, ", ", this.Upper)
+  if this.UpperClosed {
+    res.AppendStr("]")
+  } else {
+    res.AppendStr(")")
+  }
+  return res.String()
+}
+
+// compareLower compares the lower bounds of two intervals. A closed bound is
+// smaller than an open bound at the same value.
+func compareLower(comp Comparison, a, b Interval) int {
+  if c := comp(a.Lower, b.Lower); c != 0 {
+    return c
+  } else if a.LowerClosed == b.LowerClosed {
+    return 0
+  } else if a.LowerClosed {
+    return -1
+  }
+  return 1
+}
+
+// compareUpper compares the upper bounds of two intervals. A closed bound is
+// bigger than an open bound at the same value.
+func compareUpper(comp Comparison, a, b Interval) int {
+  if c := comp(a.Upper, b.Upper); c != 0 {
+    return c
+  } else if a.UpperClosed == b.UpperClosed {
+    return 0
+  } else if a.UpperClosed {
+    return 1
+  }
+  return -1
+}
@@ -0,0 +1,275 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "sort"
+import . "github.com/objecthub/containerkit"
+
+
+// IntervalSet is a Collection of values of a domain ordered by a Comparison.
+// Values are not stored individually; instead, an interval set maintains a
+// sorted sequence of disjoint intervals. Adjacent and overlapping intervals
+// get coalesced, e.g. adding [1, 3) and [3, 5] results in [1, 5].
+type IntervalSet interface {
+  Collection
+
+  // Comparison returns the comparison function defining the order of the
+  // domain.
+  Comparison() Comparison
+
+  // Count returns the number of disjoint intervals of this set.
+  Count() int
+
+  // IsEmpty returns true if this set does not contain any values.
+  IsEmpty() bool
+
+  // Intervals returns a container of the disjoint intervals of this set in
+  // ascending order. The container is a live view of this set.
+  Intervals() Container
+
+  // Span returns the smallest interval enclosing all values of this set. The
+  // result is undefined if this set is empty.
+  Span() Interval
+
+  // ContainsInterval returns true if all values of iv are contained in this
+  // set.
+  ContainsInterval(iv Interval) bool
+
+  // Overlaps returns true if at least one value of iv is contained in this
+  // set.
+  Overlaps(iv Interval) bool
+
+  // Add includes all values of the given intervals in this set.
+  Add(intervals ...Interval)
+
+  // Remove excludes all values of the given intervals from this set.
+  Remove(intervals ...Interval)
+
+  // Union returns a new interval set containing all values of this set and
+  // other.
+  Union(other IntervalSet) IntervalSet
+
+  // Intersection returns a new interval set containing all values which are
+  // contained in both this set and other.
+  Intersection(other IntervalSet) IntervalSet
+
+  // Complement returns a new interval set containing all values within bounds
+  // which are not contained in this set.
+  Complement(bounds Interval) IntervalSet
+
+  // Copy returns a new interval set with the same values.
+  Copy() IntervalSet
+
+  // Clear removes all values from this set.
+  Clear()
+
+  String() string
+}
+
+// NewIntervalSet returns a new interval set over the domain ordered by comp
+// which contains all values of the given intervals.
+func NewIntervalSet(comp Comparison, intervals ...Interval) IntervalSet {
+  res := &intervalSet{comp: comp}
+  res.CollectionDerived = EmbeddedCollection(res)
+  res.Add(intervals...)
+  return res
+}
+
+type intervalSet struct {
+  comp Comparison
+  intervals []Interval
+  CollectionDerived
+}
+
+func (this *intervalSet) Comparison() Comparison {
+  return this.comp
+}
+
+func (this *intervalSet) Count() int {
+  return len(this.intervals)
+}
+
+func (this *intervalSet) IsEmpty() bool {
+  return len(this.intervals) == 0
+}
+
+// find returns the index of the first interval which is not below x.
+func (this *intervalSet) find(x interface{}) int {
+  return sort.Search(len(this.intervals), func (i int) bool {
+    return !this.intervals[i].below(this.comp, x)
+  })
+}
+
+func (this *intervalSet) Contains(elem interface{}) bool {
+  i := this.find(elem)
+  return i < len(this.intervals) && this.intervals[i].Contains(this.comp, elem)
+}
+
+func (this *intervalSet) Intervals() Container {
+  res := &intervalContainer{this, nil}
+  res.Container = EmbeddedContainer(res)
+  return res
+}
+
+func (this *intervalSet) Span() Interval {
+  return this.intervals[0].Span(this.comp, this.intervals[len(this.intervals) - 1])
+}
+
+func (this *intervalSet) ContainsInterval(iv Interval) bool {
+  if iv.IsEmpty(this.comp) {
+    return true
+  }
+  i := this.find(iv.Lower)
+  return i < len(this.intervals) && this.intervals[i].Encloses(this.comp, iv)
+}
+
+func (this *intervalSet) Overlaps(iv Interval) bool {
+  if iv.IsEmpty(this.comp) {
+    return false
+  }
+  i := sort.Search(len(this.intervals), func (i int) bool {
+    return !this.intervals[i].Precedes(this.comp, iv)
+  })
+  return i < len(this.intervals) && this.intervals[i].Overlaps(this.comp, iv)
+}
+
+func (this *intervalSet) Add(intervals ...Interval) {
+  for _, iv := range intervals {
+    if iv.IsEmpty(this.comp) {
+      continue
+    }
+    i := sort.Search(len(this.intervals), func (i int) bool {
+      return !this.intervals[i].separated(this.comp, iv)
+    })
+    j := i
+    for j < len(this.intervals) && this.intervals[j].Touches(this.comp, iv) {
+      iv = iv.Span(this.comp, this.intervals[j])
+      j++
+    }
+    this.splice(i, j, iv)
+  }
+}
+
+func (this *intervalSet) Remove(intervals ...Interval) {
+  for _, iv := range intervals {
+    if iv.IsEmpty(this.comp) {
+      continue
+    }
+    i := sort.Search(len(this.intervals), func (i int) bool {
+      return !this.intervals[i].Precedes(this.comp, iv)
+    })
+    j := i
+    var rest []Interval
+    for j < len(this.intervals) && this.intervals[j].Overlaps(this.comp, iv) {
+      left, right := this.intervals[j].Difference(this.comp, iv)
+      if !left.IsEmpty(this.comp) {
+        rest = append(rest, left)
+      }
+      if !right.IsEmpty(this.comp) {
+        rest = append(rest, right)
+      }
+      j++
+    }
+    this.splice(i, j, rest...)
+  }
+}
+
+// splice replaces the intervals at indices [i, j) with the given intervals.
+func (this *intervalSet) splice(i, j int, intervals ...Interval) {
+  res := make([]Interval, 0, len(this.intervals) - (j - i) + len(intervals))
+  res = append(res, this.intervals[:i]...)
+  res = append(res, intervals...)
+  this.intervals = append(res, this.intervals[j:]...)
+}
+
+func (this *intervalSet) Union(other IntervalSet) IntervalSet {
+  res := this.copy()
+  for iter := other.Intervals().Elements(); iter.HasNext(); {
+    res.Add(iter.Next().(Interval))
+  }
+  return res
+}
+
+func (this *intervalSet) Intersection(other IntervalSet) IntervalSet {
+  res := NewIntervalSet(this.comp).(*intervalSet)
+  iter := other.Intervals().Elements()
+  if !iter.HasNext() {
+    return res
+  }
+  that := iter.Next().(Interval)
+  for i := 0; i < len(this.intervals); {
+    if iv := this.intervals[i].Intersection(this.comp, that); !iv.IsEmpty(this.comp) {
+      res.intervals = append(res.intervals, iv)
+    }
+    if compareUpper(this.comp, this.intervals[i], that) < 0 {
+      i++
+    } else if iter.HasNext() {
+      that = iter.Next().(Interval)
+    } else {
+      break
+    }
+  }
+  return res
+}
+
+func (this *intervalSet) Complement(bounds Interval) IntervalSet {
+  res := NewIntervalSet(this.comp, bounds)
+  res.Remove(this.intervals...)
+  return res
+}
+
+func (this *intervalSet) copy() *intervalSet {
+  res := NewIntervalSet(this.comp).(*intervalSet)
+  res.intervals = append(res.intervals, this.intervals...)
+  return res
+}
+
+func (this *intervalSet) Copy() IntervalSet {
+  return this.copy()
+}
+
+func (this *intervalSet) Clear() {
+  this.intervals = nil
+}
+
+func (this *intervalSet) String() string {
+  return "{" + this.Intervals().String() + "}"
+}
+
+type intervalContainer struct {
+  set *intervalSet
+  Container
+}
+
+func (this *intervalContainer) Elements() Iterator {
+  return &intervalIterator{this.set.intervals, 0}
+}
+
+type intervalIterator struct {
+  intervals []Interval
+  next int
+}
+
+func (this *intervalIterator) HasNext() bool {
+  return this.next < len(this.intervals)
+}
+
+func (this *intervalIterator) Next() interface{} {
+  if this.next >= len(this.intervals) {
+    panic("intervalIterator.Next: no next element")
+  }
+  this.next++
+  return this.intervals[this.next - 1]
+}
@@ -0,0 +1,81 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func checkIntervals(t *testing.T, set IntervalSet, expected string) {
+  if set.String() != expected {
+    t.Errorf("Expected interval set %s; was %s", expected, set)
+  }
+}
+
+func TestInterval(t *testing.T) {
+  comp := UniversalComparison
+  if !HalfOpenInterval(1, 1).IsEmpty(comp) || ClosedInterval(1, 1).IsEmpty(comp) {
+    t.Errorf("IsEmpty does not respect closed bounds")
+  }
+  if !HalfOpenInterval(1, 3).Precedes(comp, ClosedInterval(3, 4)) ||
+     ClosedInterval(1, 3).Precedes(comp, ClosedInterval(3, 4)) {
+    t.Errorf("Precedes does not respect closed bounds")
+  }
+  left, right := ClosedInterval(0, 10).Difference(comp, OpenInterval(3, 5))
+  if left.String() != "[0, 3]" || right.String() != "[5, 10]" {
+    t.Errorf("Unexpected difference %v, %v", left, right)
+  }
+}
+
+func TestIntervalSet(t *testing.T) {
+  ports := NewIntervalSet(UniversalComparison, HalfOpenInterval(80, 90), ClosedInterval(100, 200))
+  ports.Add(ClosedInterval(90, 95), ClosedInterval(150, 300), OpenInterval(20, 10))
+  checkIntervals(t, ports, "{[80, 95], [100, 300]}")
+  if !ports.Contains(95) || ports.Contains(96) || !ports.ContainsAll(80, 100, 300) {
+    t.Errorf("Contains does not reflect intervals")
+  }
+  ports.Remove(OpenInterval(90, 120), ClosedInterval(250, 400))
+  checkIntervals(t, ports, "{[80, 90], [120, 250)}")
+  if !ports.ContainsInterval(ClosedInterval(130, 140)) || ports.ContainsInterval(ClosedInterval(85, 130)) {
+    t.Errorf("ContainsInterval does not reflect intervals")
+  }
+  if !ports.Overlaps(OpenInterval(0, 81)) || ports.Overlaps(OpenInterval(90, 120)) {
+    t.Errorf("Overlaps does not reflect intervals")
+  }
+  if span := ports.Span(); span.String() != "[80, 250)" {
+    t.Errorf("Unexpected span %v", span)
+  }
+  if ports.Count() != 2 || ports.Intervals().IsEmpty() {
+    t.Errorf("Unexpected number of intervals %d", ports.Count())
+  }
+  ports.Clear()
+  if !ports.IsEmpty() || ports.Contains(80) {
+    t.Errorf("Expected interval set to be empty after Clear")
+  }
+}
+
+func TestIntervalSetOperations(t *testing.T) {
+  a := NewIntervalSet(UniversalComparison, ClosedInterval(0, 10), ClosedInterval(20, 30))
+  b := NewIntervalSet(UniversalComparison, HalfOpenInterval(5, 25), ClosedInterval(28, 40))
+  checkIntervals(t, a.Union(b), "{[0, 40]}")
+  checkIntervals(t, a.Intersection(b), "{[5, 10], [20, 25), [28, 30]}")
+  checkIntervals(t, b.Intersection(a), "{[5, 10], [20, 25), [28, 30]}")
+  checkIntervals(t, a.Complement(ClosedInterval(-5, 35)), "{[-5, 0), (10, 20), (30, 35]}")
+  checkIntervals(t, a, "{[0, 10], [20, 30]}")
+  c := a.Copy()
+  c.Add(OpenInterval(10, 20))
+  checkIntervals(t, c, "{[0, 30]}")
+  checkIntervals(t, a, "{[0, 10], [20, 30]}")
+}
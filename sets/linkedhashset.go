@@ -0,0 +1,108 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/impl"
+
+
+// LinkedHashSet is a hash set which iterates over its elements in the order
+// in which they were included. Including an element again does not change
+// its position.
+var LinkedHashSet MutableSetClass = LinkedHashSetClass(UniversalHash, UniversalEquality)
+
+var ImmutableLinkedHashSet SetClass = ImmutableSet(LinkedHashSet)
+
+func LinkedHashSetClass(hash Hashfunction, equals Equality) MutableSetClass {
+  return &linkedHashSetClass{hash, equals}
+}
+
+type linkedHashSetClass struct {
+  hash Hashfunction
+  equals Equality
+}
+
+func (this *linkedHashSetClass) Embed(obj MutableSet) MutableSet {
+  res := new(linkedHashSet)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableSetDerived = EmbeddedMutableSet(obj)
+  res.table = NewHashTable(17, 80, this.hash, this.equals)
+  res.order = NewDoubleLinkedList()
+  return res
+}
+
+func (this *linkedHashSetClass) New(elements ...interface{}) MutableSet {
+  res := this.Embed(nil)
+  res.Include(elements...)
+  return res
+}
+
+func (this *linkedHashSetClass) From(coll Container) MutableSet {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+// linkedHashSet maps every element to its *Element in the insertion order
+// list.
+type linkedHashSet struct {
+  obj MutableSet
+  table *HashTable
+  order *DoubleLinkedList
+  MutableSetDerived
+}
+
+func (this *linkedHashSet) Size() int {
+  return this.table.Size()
+}
+
+func (this *linkedHashSet) Contains(elem interface{}) bool {
+  return this.table.FindEntry(elem) != nil
+}
+
+func (this *linkedHashSet) Elements() Iterator {
+  return this.order.Iterator()
+}
+
+func (this *linkedHashSet) Class() MutableSetClass {
+  return LinkedHashSetClass(this.table.Hash(), this.table.Equality())
+}
+
+func (this *linkedHashSet) Include(elements ...interface{}) {
+  for _, key := range elements {
+    if this.table.FindEntry(key) == nil {
+      elem := NewElement(key)
+      this.table.AddEntry(key, elem)
+      this.order.InsertBack(elem)
+    }
+  }
+}
+
+func (this *linkedHashSet) Exclude(elements ...interface{}) {
+  for _, key := range elements {
+    if entry := this.table.FindEntry(key); entry != nil {
+      this.order.Remove(entry.Value.(*Element))
+      this.table.DeleteEntry(key)
+    }
+  }
+}
+
+func (this *linkedHashSet) Clear() {
+  this.table.Clear()
+  this.order = NewDoubleLinkedList()
+}
@@ -0,0 +1,30 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "testing"
+
+
+func TestLinkedHashSetClass(t *testing.T) {
+  set := LinkedHashSet.New(5, 3, 9, 1, 3)
+  checkElements(t, set, []interface{}{5, 3, 9, 1}, "set")
+  set.Exclude(3, 7)
+  set.Include(3, 4, 5)
+  checkElements(t, set, []interface{}{5, 9, 1, 3, 4}, "set")
+  checkElements(t, set.Copy(), []interface{}{5, 9, 1, 3, 4}, "copy")
+  set.Clear()
+  set.Include(2)
+  checkElements(t, set, []interface{}{2}, "set")
+}
@@ -98,13 +98,13 @@ func (this *listSet) Exclude(elements ...interface{}) {
       if this.eq(this.list.Head, elements[i]) {
         this.list = this.list.Tail
         this.size--
+        continue
       }
-      for list := this.list; list.Tail != nil; {
+      for list := this.list; list.Tail != nil; list = list.Tail {
         if this.eq(list.Tail.Head, elements[i]) {
           list.Tail = list.Tail.Tail
           this.size--
-        } else {
-          list = list.Tail
+          break
         }
       }
     }
@@ -0,0 +1,293 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import . "github.com/objecthub/containerkit"
+
+
+// MultisetBase defines the minimal functionality of a multiset (also called
+// a bag), i.e. a collection in which every element may occur multiple times.
+// Size returns the number of elements including duplicates and Elements
+// returns every element as often as it occurs. Contains returns true if an
+// element occurs at least once.
+type MultisetBase interface {
+  FiniteContainerBase
+  CollectionBase
+
+  // Count returns the number of occurrences of elem.
+  Count(elem interface{}) int
+
+  // ElementSet returns a live view of the distinct elements of the multiset.
+  ElementSet() DependentSet
+}
+
+// MultisetDerived defines methods whose implementation can be generically
+// derived from the methods defined by the MultisetBase interface.
+type MultisetDerived interface {
+  FiniteContainerDerived
+  CollectionDerived
+
+  // Union returns a dependent multiset in which every element occurs as
+  // often as it occurs at most in this and the other multiset.
+  Union(other Multiset) DependentMultiset
+
+  // Sum returns a dependent multiset in which the number of occurrences of
+  // every element is the sum of the occurrences in this and the other multiset.
+  Sum(other Multiset) DependentMultiset
+
+  // Intersection returns a dependent multiset in which every element occurs
+  // as often as it occurs at least in this and the other multiset.
+  Intersection(other Multiset) DependentMultiset
+
+  // Difference returns a dependent multiset in which the occurrences of every
+  // element in the other multiset are subtracted from the occurrences in this
+  // multiset.
+  Difference(other Multiset) DependentMultiset
+}
+
+type Multiset interface {
+  MultisetBase
+  MultisetDerived
+}
+
+// DependentMultiset is a Multiset which is derived from other multisets.
+type DependentMultiset interface {
+  Multiset
+}
+
+// MutableMultisetBase defines the minimal functionality required for
+// supporting the full MutableMultiset interface.
+type MutableMultisetBase interface {
+  MultisetBase
+  Class() MultisetClass
+
+  // Add adds n occurrences of elem to the multiset.
+  Add(elem interface{}, n int)
+
+  // Remove removes n occurrences of elem from the multiset. If elem occurs
+  // less than n times, all occurrences are removed.
+  Remove(elem interface{}, n int)
+
+  Clear()
+}
+
+// MutableMultisetDerived defines methods whose implementation can be
+// generically derived from the methods defined by the MutableMultisetBase
+// interface.
+type MutableMultisetDerived interface {
+  MultisetDerived
+  Include(elements ...interface{})
+  IncludeFrom(coll Container)
+  Copy() MutableMultiset
+}
+
+type MutableMultiset interface {
+  MutableMultisetBase
+  MutableMultisetDerived
+}
+
+// MultisetClass defines the functionality of MutableMultiset implementations,
+// ie. records that act as MutableMultiset factories, providing an Embed, New,
+// and From method.
+type MultisetClass interface {
+  Embed(obj MutableMultiset) MutableMultiset
+  New(elements ...interface{}) MutableMultiset
+  From(coll Container) MutableMultiset
+}
+
+func EmbeddedMultiset(obj Multiset) Multiset {
+  return &multisetTrait{obj,
+                        obj,
+                        EmbeddedFiniteContainer(obj),
+                        EmbeddedCollection(obj)}
+}
+
+type multisetTrait struct {
+  obj Multiset
+  MultisetBase
+  FiniteContainerDerived
+  CollectionDerived
+}
+
+func (this *multisetTrait) Union(other Multiset) DependentMultiset {
+  return newCombinedMultiset(this.obj, other, this.obj.ElementSet().Union(other.ElementSet()),
+      func (x, y int) int {
+        if x > y {
+          return x
+        }
+        return y
+      })
+}
+
+func (this *multisetTrait) Sum(other Multiset) DependentMultiset {
+  return newCombinedMultiset(this.obj, other, this.obj.ElementSet().Union(other.ElementSet()),
+      func (x, y int) int {
+        return x + y
+      })
+}
+
+func (this *multisetTrait) Intersection(other Multiset) DependentMultiset {
+  return newCombinedMultiset(this.obj, other, this.obj.ElementSet().Intersection(other.ElementSet()),
+      func (x, y int) int {
+        if x < y {
+          return x
+        }
+        return y
+      })
+}
+
+func (this *multisetTrait) Difference(other Multiset) DependentMultiset {
+  return newCombinedMultiset(this.obj, other, this.obj.ElementSet(),
+      func (x, y int) int {
+        if x > y {
+          return x - y
+        }
+        return 0
+      })
+}
+
+func (this *multisetTrait) String() string {
+  return "{" + this.FiniteContainerDerived.String() + "}"
+}
+
+func EmbeddedMutableMultiset(obj MutableMultiset) MutableMultiset {
+  return &mutableMultisetTrait{obj, obj, EmbeddedMultiset(obj)}
+}
+
+type mutableMultisetTrait struct {
+  obj MutableMultiset
+  MutableMultisetBase
+  MultisetDerived
+}
+
+func (this *mutableMultisetTrait) Include(elements ...interface{}) {
+  for _, elem := range elements {
+    this.obj.Add(elem, 1)
+  }
+}
+
+func (this *mutableMultisetTrait) IncludeFrom(coll Container) {
+  for iter := coll.Elements(); iter.HasNext(); {
+    this.obj.Add(iter.Next(), 1)
+  }
+}
+
+func (this *mutableMultisetTrait) Copy() MutableMultiset {
+  return this.obj.Class().From(this.obj)
+}
+
+// NewMultisetIterator returns an iterator which returns every element of
+// the given distinct elements as often as it occurs in the given multiset.
+func NewMultisetIterator(distinct Iterator, ms MultisetBase) Iterator {
+  return &multisetIterator{distinct, ms, nil, 0}
+}
+
+type multisetIterator struct {
+  distinct Iterator
+  ms MultisetBase
+  current interface{}
+  remaining int
+}
+
+func (this *multisetIterator) HasNext() bool {
+  for this.remaining == 0 && this.distinct.HasNext() {
+    this.current = this.distinct.Next()
+    this.remaining = this.ms.Count(this.current)
+  }
+  return this.remaining > 0
+}
+
+func (this *multisetIterator) Next() interface{} {
+  if !this.HasNext() {
+    panic("multisetIterator.Next: no next element")
+  }
+  this.remaining--
+  return this.current
+}
+
+// Multiset combinations
+
+func newCombinedMultiset(fst Multiset,
+                         snd Multiset,
+                         candidates Set,
+                         op func (x, y int) int) DependentMultiset {
+  res := new(combinedMultiset)
+  res.MultisetDerived = EmbeddedMultiset(res)
+  res.fst = fst
+  res.snd = snd
+  res.candidates = candidates
+  res.op = op
+  return res
+}
+
+type combinedMultiset struct {
+  MultisetDerived
+  fst Multiset
+  snd Multiset
+  candidates Set
+  op func (x, y int) int
+}
+
+func (this *combinedMultiset) Count(elem interface{}) int {
+  return this.op(this.fst.Count(elem), this.snd.Count(elem))
+}
+
+func (this *combinedMultiset) Contains(elem interface{}) bool {
+  return this.Count(elem) > 0
+}
+
+func (this *combinedMultiset) Size() int {
+  res := 0
+  for iter := this.candidates.Elements(); iter.HasNext(); {
+    res += this.Count(iter.Next())
+  }
+  return res
+}
+
+func (this *combinedMultiset) Elements() Iterator {
+  return NewMultisetIterator(this.candidates.Elements(), this)
+}
+
+func (this *combinedMultiset) ElementSet() DependentSet {
+  res := new(multisetElementSet)
+  res.SetDerived = EmbeddedDependentSet(res)
+  res.ms = this
+  res.candidates = this.candidates
+  return res
+}
+
+func (this *combinedMultiset) String() string {
+  return "<" + this.MultisetDerived.String() + ">"
+}
+
+// multisetElementSet is a view of the distinct elements of a multiset whose
+// elements are a subset of a set of candidates.
+type multisetElementSet struct {
+  SetDerived
+  ms Multiset
+  candidates Set
+}
+
+func (this *multisetElementSet) Size() int {
+  return CountElements(this.Elements())
+}
+
+func (this *multisetElementSet) Contains(elem interface{}) bool {
+  return this.ms.Count(elem) > 0
+}
+
+func (this *multisetElementSet) Elements() Iterator {
+  return NewFilterIterator(this.Contains, this.candidates.Elements())
+}
@@ -0,0 +1,87 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func checkCounts(t *testing.T, ms Multiset, expected map[interface{}]int, name string) {
+  size := 0
+  distinct := 0
+  for elem, count := range expected {
+    if ms.Count(elem) != count {
+      t.Errorf("Expected count of %v in multiset %s to be %d; was %d",
+               elem, name, count, ms.Count(elem))
+    }
+    if ms.Contains(elem) != (count > 0) {
+      t.Errorf("Contains(%v) of multiset %s is inconsistent with Count", elem, name)
+    }
+    if count > 0 {
+      distinct++
+    }
+    size += count
+  }
+  if ms.Size() != size || CountElements(ms.Elements()) != size {
+    t.Errorf("Expected size of multiset %s to be %d; was %d", name, size, ms.Size())
+  }
+  if ms.ElementSet().Size() != distinct {
+    t.Errorf("Expected %d distinct elements in multiset %s; was %d",
+             distinct, name, ms.ElementSet().Size())
+  }
+}
+
+func TestHashMultisetClass(t *testing.T) {
+  ms := HashMultiset.New("a", "b", "a", "c", "a")
+  checkCounts(t, ms, map[interface{}]int{"a": 3, "b": 1, "c": 1, "d": 0}, "ms")
+  elements := ms.ElementSet()
+  ms.Add("d", 4)
+  ms.Remove("a", 2)
+  ms.Remove("b", 5)
+  checkCounts(t, ms, map[interface{}]int{"a": 1, "b": 0, "c": 1, "d": 4}, "ms")
+  if elements.Contains("b") || !elements.Contains("d") || elements.Size() != 3 {
+    t.Errorf("Element set does not reflect changes of the multiset")
+  }
+  checkCounts(t, ms.Copy(), map[interface{}]int{"a": 1, "c": 1, "d": 4}, "copy")
+  ms.Clear()
+  checkCounts(t, ms, map[interface{}]int{"a": 0, "d": 0}, "ms")
+}
+
+func TestSortedMultisetClass(t *testing.T) {
+  ms := SortedMultiset.New(3, 1, 2, 1, 3, 3)
+  checkElements(t, ms.ElementSet(), []interface{}{1, 2, 3}, "element set")
+  expected := []interface{}{1, 1, 2, 3, 3, 3}
+  i := 0
+  for iter := ms.Elements(); iter.HasNext(); i++ {
+    if elem := iter.Next(); elem != expected[i] {
+      t.Errorf("Unexpected element %v at position %d", elem, i)
+    }
+  }
+}
+
+func TestMultisetOperations(t *testing.T) {
+  ms1 := HashMultiset.New(1, 1, 1, 2, 2, 3)
+  ms2 := SortedMultiset.New(1, 2, 2, 2, 4)
+  checkCounts(t, ms1.Union(ms2), map[interface{}]int{1: 3, 2: 3, 3: 1, 4: 1}, "union")
+  checkCounts(t, ms1.Sum(ms2), map[interface{}]int{1: 4, 2: 5, 3: 1, 4: 1}, "sum")
+  checkCounts(t, ms1.Intersection(ms2), map[interface{}]int{1: 1, 2: 2, 3: 0, 4: 0}, "intersection")
+  difference := ms1.Difference(ms2)
+  checkCounts(t, difference, map[interface{}]int{1: 2, 2: 0, 3: 1, 4: 0}, "difference")
+  ms2.Remove(2, 3)
+  checkCounts(t, difference, map[interface{}]int{1: 2, 2: 2, 3: 1, 4: 0}, "difference")
+  if !ms1.ContainsAll(1, 2, 3) || ms1.ContainsSome(4, 5) {
+    t.Errorf("Collection methods are inconsistent with Contains")
+  }
+}
@@ -17,11 +17,40 @@ package sets
 import . "github.com/objecthub/containerkit"
 
 
+// MutableSetObserver is notified about changes of observed sets. Observers
+// may implement SetBatchObserver and SetClearObserver to be notified about
+// IncludeFrom and Clear with a single event.
 type MutableSetObserver interface {
   Include(subject MutableSet, elem interface{})
   Exclude(subject MutableSet, elem interface{})
 }
 
+// SetBatchObserver is an optional extension of MutableSetObserver. IncludeFrom
+// is reported to observers implementing it as a single IncludeBatch event
+// listing all included elements; other observers get an Include event for
+// every element.
+type SetBatchObserver interface {
+  IncludeBatch(subject MutableSet, elements []interface{})
+}
+
+// SetClearObserver is an optional extension of MutableSetObserver. Clearing
+// a set is reported to observers implementing it as a single Clear event;
+// other observers get an Exclude event for every element.
+type SetClearObserver interface {
+  Clear(subject MutableSet)
+}
+
+// ObservedSet is implemented by sets created by ObservableSet classes. It
+// allows observers to be added and removed at any time.
+type ObservedSet interface {
+  MutableSet
+  AddObserver(observer MutableSetObserver)
+  RemoveObserver(observer MutableSetObserver)
+}
+
+// ObservableSet returns a class whose sets notify observers about changes.
+// The sets are initially observed by the MutableSetObserver elements of
+// observers, which may be nil.
 func ObservableSet(class MutableSetClass, observers Container) MutableSetClass {
   return &observableSetClass{class, observers}
 }
@@ -37,6 +66,11 @@ func (this *observableSetClass) Embed(obj MutableSet) MutableSet {
     obj = res
   }
   res.obj = obj
+  if this.observers != nil {
+    this.observers.ForEach(func (o interface{}) {
+      res.AddObserver(o.(MutableSetObserver))
+    })
+  }
   res.MutableSet = this.class.Embed(obj)
   return res
 }
@@ -55,24 +89,87 @@ func (this *observableSetClass) From(coll Container) MutableSet {
 
 type observableSet struct {
   obj MutableSet
-  observers Container
+  observers Observers
+  depth int
+  batch []interface{}
   MutableSet
 }
 
+func (this *observableSet) AddObserver(observer MutableSetObserver) {
+  this.observers.Add(observer)
+}
+
+func (this *observableSet) RemoveObserver(observer MutableSetObserver) {
+  this.observers.Remove(observer)
+}
+
 func (this *observableSet) Include(elements... interface{}) {
   this.MutableSet.Include(elements...)
-  this.observers.ForEach(func (o interface{}) {
+  if this.depth > 0 {
+    this.batch = append(this.batch, elements...)
+    return
+  }
+  for _, o := range this.observers {
     for _, e := range elements {
       o.(MutableSetObserver).Include(this.obj, e)
     }
-  })
+  }
 }
 
 func (this *observableSet) Exclude(elements... interface{}) {
   this.MutableSet.Exclude(elements...)
-  this.observers.ForEach(func (o interface{}) {
-    for _, e := range elements {
-      o.(MutableSetObserver).Exclude(this.obj, e)
+  if this.depth == 0 {
+    for _, o := range this.observers {
+      for _, e := range elements {
+        o.(MutableSetObserver).Exclude(this.obj, e)
+      }
     }
-  })
+  }
+}
+
+func (this *observableSet) IncludeFrom(coll Container) {
+  this.depth++
+  this.MutableSet.IncludeFrom(coll)
+  this.depth--
+  if this.depth == 0 {
+    batch := this.batch
+    this.batch = nil
+    for _, o := range this.observers {
+      if bo, ok := o.(SetBatchObserver); ok {
+        bo.IncludeBatch(this.obj, batch)
+      } else {
+        for _, e := range batch {
+          o.(MutableSetObserver).Include(this.obj, e)
+        }
+      }
+    }
+  }
+}
+
+func (this *observableSet) Clear() {
+  var elements []interface{}
+  if this.depth == 0 {
+    for _, o := range this.observers {
+      if _, ok := o.(SetClearObserver); !ok {
+        for iter := this.MutableSet.Elements(); iter.HasNext(); {
+          elements = append(elements, iter.Next())
+        }
+        break
+      }
+    }
+  }
+  this.depth++
+  this.MutableSet.Clear()
+  this.depth--
+  if this.depth == 0 {
+    for _, o := range this.observers {
+      if co, ok := o.(SetClearObserver); ok {
+        co.Clear(this.obj)
+      } else {
+        for _, e := range elements {
+          o.(MutableSetObserver).Exclude(this.obj, e)
+        }
+      }
+    }
+  }
 }
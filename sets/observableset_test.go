@@ -0,0 +1,76 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "fmt"
+import "strings"
+import "testing"
+
+
+type setRecorder struct {
+  events []string
+}
+
+func (this *setRecorder) Include(subject MutableSet, elem interface{}) {
+  this.events = append(this.events, fmt.Sprintf("+%v", elem))
+}
+
+func (this *setRecorder) Exclude(subject MutableSet, elem interface{}) {
+  this.events = append(this.events, fmt.Sprintf("-%v", elem))
+}
+
+// batchSetRecorder also records batch and clear events.
+type batchSetRecorder struct {
+  setRecorder
+}
+
+func (this *batchSetRecorder) IncludeBatch(subject MutableSet, elements []interface{}) {
+  this.events = append(this.events, fmt.Sprintf("+%v", elements))
+}
+
+func (this *batchSetRecorder) Clear(subject MutableSet) {
+  this.events = append(this.events, "clear")
+}
+
+func TestObservableSetClass(t *testing.T) {
+  r1 := new(setRecorder)
+  r2 := new(batchSetRecorder)
+  set := ObservableSet(HashSet, ListSet.New(r1)).New(1).(ObservedSet)
+  set.AddObserver(r2)
+  set.Include(2)
+  set.RemoveObserver(r1)
+  set.Exclude(1)
+  set.IncludeFrom(ListSet.New(3))
+  set.Clear()
+  checkSize(t, set, 0, "set")
+  if events := strings.Join(r1.events, " "); events != "+1 +2" {
+    t.Errorf("Unexpected events %s of first observer", events)
+  }
+  if events := strings.Join(r2.events, " "); events != "+2 -1 +[3] clear" {
+    t.Errorf("Unexpected events %s of second observer", events)
+  }
+}
+
+func TestObservableSetFallbackEvents(t *testing.T) {
+  recorder := new(setRecorder)
+  set := ObservableSet(TreeSet, nil).New(1).(ObservedSet)
+  set.AddObserver(recorder)
+  set.IncludeFrom(TreeSet.New(2, 3))
+  set.Clear()
+  expected := "+2 +3 -1 -2 -3"
+  if events := strings.Join(recorder.events, " "); events != expected {
+    t.Errorf("Unexpected events %s; expected %s", events, expected)
+  }
+}
@@ -0,0 +1,109 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/impl"
+
+
+// OpenHashSet is a class of hash sets which are backed by hash tables with
+// open addressing.
+var OpenHashSet MutableSetClass = HashSetClassWithTable(UniversalHash, UniversalEquality,
+    HashTableConfig{OpenAddressing: true, Capacity: 16, LoadFactor: 80})
+
+var ImmutableOpenHashSet SetClass = ImmutableSet(OpenHashSet)
+
+type openHashSetClass struct {
+  hash Hashfunction
+  equals Equality
+  table HashTableConfig
+}
+
+func (this *openHashSetClass) Embed(obj MutableSet) MutableSet {
+  res := new(openHashSet)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableSetDerived = EmbeddedMutableSet(obj)
+  res.class = this
+  res.table = this.table.NewOpenTable(this.hash, this.equals)
+  return res
+}
+
+func (this *openHashSetClass) New(elements ...interface{}) MutableSet {
+  res := this.Embed(nil)
+  res.Include(elements...)
+  return res
+}
+
+func (this *openHashSetClass) From(coll Container) MutableSet {
+  res := this.Embed(nil)
+  res.IncludeFrom(coll)
+  return res
+}
+
+type openHashSet struct {
+  obj MutableSet
+  class *openHashSetClass
+  table *OpenHashTable
+  MutableSetDerived
+}
+
+func (this *openHashSet) Size() int {
+  return this.table.Size()
+}
+
+func (this *openHashSet) Contains(elem interface{}) bool {
+  _, exists := this.table.Get(elem)
+  return exists
+}
+
+func (this *openHashSet) Elements() Iterator {
+  return &openHashSetIterator{this.table.Iterator()}
+}
+
+func (this *openHashSet) Class() MutableSetClass {
+  return this.class
+}
+
+func (this *openHashSet) Include(elements ...interface{}) {
+  for _, elem := range elements {
+    this.table.Put(elem, nil)
+  }
+}
+
+func (this *openHashSet) Exclude(elements ...interface{}) {
+  for _, elem := range elements {
+    this.table.Delete(elem)
+  }
+}
+
+func (this *openHashSet) Clear() {
+  this.table.Clear()
+}
+
+type openHashSetIterator struct {
+  iter *OpenHashIterator
+}
+
+func (this *openHashSetIterator) HasNext() bool {
+  return this.iter.HasNext()
+}
+
+func (this *openHashSetIterator) Next() interface{} {
+  key, _ := this.iter.Next()
+  return key
+}
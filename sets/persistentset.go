@@ -0,0 +1,155 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/impl"
+
+
+// PersistentSet is an immutable Set which can be used to efficiently derive
+// modified versions of itself. The derived sets share most of their
+// representation with the original set.
+type PersistentSet interface {
+  Set
+
+  // With returns a new set which contains the given elements in addition
+  // to the elements of this set.
+  With(elements ...interface{}) PersistentSet
+
+  // Without returns a new set which contains all elements of this set
+  // except for the given elements.
+  Without(elements ...interface{}) PersistentSet
+}
+
+// PersistentSetClass defines the functionality of PersistentSet
+// implementations. In addition to the SetClass methods, it provides
+// factory methods returning PersistentSet values.
+type PersistentSetClass interface {
+  SetClass
+  Empty() PersistentSet
+  NewPersistent(elements ...interface{}) PersistentSet
+  FromPersistent(coll Container) PersistentSet
+}
+
+var PersistentHashSet PersistentSetClass =
+    PersistentHashSetClass(UniversalHash, UniversalEquality)
+
+func PersistentHashSetClass(hash Hashfunction, equals Equality) PersistentSetClass {
+  return &persistentHashSetClass{hash, equals}
+}
+
+type persistentHashSetClass struct {
+  hash Hashfunction
+  equals Equality
+}
+
+func (this *persistentHashSetClass) Embed(obj Set) Set {
+  return newPersistentHashSet(obj, NewHamt(this.hash, this.equals))
+}
+
+func (this *persistentHashSetClass) New(elements ...interface{}) Set {
+  return this.NewPersistent(elements...)
+}
+
+func (this *persistentHashSetClass) From(coll Container) Set {
+  return this.FromPersistent(coll)
+}
+
+func (this *persistentHashSetClass) Empty() PersistentSet {
+  return newPersistentHashSet(nil, NewHamt(this.hash, this.equals))
+}
+
+func (this *persistentHashSetClass) NewPersistent(elements ...interface{}) PersistentSet {
+  return this.Empty().With(elements...)
+}
+
+func (this *persistentHashSetClass) FromPersistent(coll Container) PersistentSet {
+  trie := NewHamt(this.hash, this.equals)
+  for iter := coll.Elements(); iter.HasNext(); {
+    trie = trie.With(iter.Next(), nil)
+  }
+  return newPersistentHashSet(nil, trie)
+}
+
+func newPersistentHashSet(obj Set, trie *Hamt) *persistentHashSet {
+  res := new(persistentHashSet)
+  if obj == nil {
+    obj = res
+  }
+  res.SetDerived = EmbeddedSet(obj)
+  res.trie = trie
+  return res
+}
+
+type persistentHashSet struct {
+  trie *Hamt
+  SetDerived
+}
+
+func (this *persistentHashSet) Size() int {
+  return this.trie.Size()
+}
+
+func (this *persistentHashSet) Contains(elem interface{}) bool {
+  return this.trie.FindEntry(elem) != nil
+}
+
+func (this *persistentHashSet) Elements() Iterator {
+  return &persistentHashSetIterator{this.trie.Iterator()}
+}
+
+func (this *persistentHashSet) With(elements ...interface{}) PersistentSet {
+  trie := this.trie
+  for _, elem := range elements {
+    if trie.FindEntry(elem) == nil {
+      trie = trie.With(elem, nil)
+    }
+  }
+  if trie == this.trie {
+    return this
+  }
+  return newPersistentHashSet(nil, trie)
+}
+
+func (this *persistentHashSet) Without(elements ...interface{}) PersistentSet {
+  trie := this.trie
+  for _, elem := range elements {
+    trie = trie.Without(elem)
+  }
+  if trie == this.trie {
+    return this
+  }
+  return newPersistentHashSet(nil, trie)
+}
+
+func (this *persistentHashSet) ReadOnly() DependentSet {
+  return this
+}
+
+func (this *persistentHashSet) Freeze() FiniteContainer {
+  return this
+}
+
+type persistentHashSetIterator struct {
+  entryIter *HamtIterator
+}
+
+func (this *persistentHashSetIterator) HasNext() bool {
+  return this.entryIter.HasNext()
+}
+
+func (this *persistentHashSetIterator) Next() interface{} {
+  return this.entryIter.Next().Key
+}
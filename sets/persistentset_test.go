@@ -0,0 +1,50 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "testing"
+
+
+func checkSetSize(t *testing.T, s Set, size int, name string) {
+  if s.Size() != size {
+    t.Errorf("Expected size of set %s to be %d; was %d", name, size, s.Size())
+  }
+}
+
+func TestPersistentHashSetClass(t *testing.T) {
+  s1 := PersistentHashSet.NewPersistent(1, 2, 3)
+  s2 := s1.With(4, 1)
+  s3 := s2.Without(2, 5)
+  checkSetSize(t, s1, 3, "s1")
+  checkSetSize(t, s2, 4, "s2")
+  checkSetSize(t, s3, 3, "s3")
+  if s1.Contains(4) || !s2.Contains(4) || !s2.Contains(2) || s3.Contains(2) {
+    t.Errorf("Persistent sets are not independent of each other")
+  }
+  if s3.Without(42) != s3 || s1.With(1, 2) != s1 {
+    t.Errorf("Expected unchanged persistent set to be returned")
+  }
+  empty := PersistentHashSet.Empty()
+  checkSetSize(t, empty, 0, "empty")
+  checkSetSize(t, PersistentHashSet.From(s2.Union(HashSet.New(7))), 5, "union")
+  lower := func (x interface{}) int {
+    return int(x.(string)[0] | 0x20)
+  }
+  caseInsensitive := PersistentHashSetClass(lower, func (x, y interface{}) bool {
+    return lower(x) == lower(y)
+  })
+  s4 := caseInsensitive.NewPersistent("a", "B").With("A", "b", "c")
+  checkSetSize(t, s4, 3, "s4")
+}
@@ -29,6 +29,12 @@ type SetDerived interface {
   Union(set Set) DependentSet
   Intersection(set Set) DependentSet
   Difference(set Set) DependentSet
+  SymmetricDifference(set Set) DependentSet
+  IsSubsetOf(set Set) bool
+  IsSupersetOf(set Set) bool
+  IsDisjoint(set Set) bool
+  PowerSet() DependentSet
+  CartesianProduct(set Set) DependentSet
 }
 
 type Set interface {
@@ -72,6 +78,39 @@ func (this *setTrait) Difference(set Set) DependentSet {
   return newDifferenceSet(this.obj, set)
 }
 
+func (this *setTrait) SymmetricDifference(set Set) DependentSet {
+  return newSymmetricDifferenceSet(this.obj, set)
+}
+
+func (this *setTrait) IsSubsetOf(set Set) bool {
+  if this.obj.Size() > set.Size() {
+    return false
+  }
+  return this.obj.ForAll(set.Func())
+}
+
+func (this *setTrait) IsSupersetOf(set Set) bool {
+  if this.obj.Size() < set.Size() {
+    return false
+  }
+  return set.ForAll(this.obj.Func())
+}
+
+func (this *setTrait) IsDisjoint(set Set) bool {
+  if this.obj.Size() > set.Size() {
+    return this.obj.ContainsNoneFrom(set)
+  }
+  return set.ContainsNoneFrom(this.obj)
+}
+
+func (this *setTrait) PowerSet() DependentSet {
+  return newPowerSet(this.obj)
+}
+
+func (this *setTrait) CartesianProduct(set Set) DependentSet {
+  return newProductSet(this.obj, set)
+}
+
 func (this *setTrait) String() string {
   return "{" + this.FiniteContainerDerived.String() + "}"
 }
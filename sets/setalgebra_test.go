@@ -0,0 +1,84 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func TestSymmetricDifference(t *testing.T) {
+  s1 := HashSet.New(1, 2, 3)
+  s2 := ListSet.New(3, 4)
+  diff := s1.SymmetricDifference(s2)
+  checkSetSize(t, diff, 3, "diff")
+  if !diff.ContainsAll(1, 2, 4) || diff.Contains(3) {
+    t.Errorf("Unexpected elements in symmetric difference")
+  }
+  s2.Include(1)
+  if diff.Contains(1) {
+    t.Errorf("Symmetric difference does not reflect changes of the underlying set")
+  }
+}
+
+func TestSubsetPredicates(t *testing.T) {
+  s1 := HashSet.New(1, 2)
+  s2 := HashSet.New(1, 2, 3)
+  s3 := TreeSet.New(4, 5)
+  if !s1.IsSubsetOf(s2) || s2.IsSubsetOf(s1) || !s1.IsSubsetOf(s1) {
+    t.Errorf("Unexpected result of IsSubsetOf")
+  }
+  if !s2.IsSupersetOf(s1) || s1.IsSupersetOf(s2) {
+    t.Errorf("Unexpected result of IsSupersetOf")
+  }
+  if !s1.IsDisjoint(s3) || !s3.IsDisjoint(s2) || s1.IsDisjoint(s2) {
+    t.Errorf("Unexpected result of IsDisjoint")
+  }
+  if !HashSet.New().IsSubsetOf(s3) || !HashSet.New().IsDisjoint(HashSet.New()) {
+    t.Errorf("Unexpected result of predicates on empty sets")
+  }
+}
+
+func TestPowerSet(t *testing.T) {
+  base := HashSet.New(1, 2, 3)
+  power := base.PowerSet()
+  checkSetSize(t, power, 8, "power")
+  count := 0
+  power.ForEach(func (elem interface{}) {
+    subset := elem.(Set)
+    if !subset.IsSubsetOf(base) || !power.Contains(subset) {
+      t.Errorf("Power set contains unexpected element %v", subset)
+    }
+    count += subset.Size()
+  })
+  if count != 12 {
+    t.Errorf("Expected the subsets to have 12 elements in total; had %d", count)
+  }
+  if !power.Contains(ListSet.New(1, 3)) || power.Contains(ListSet.New(1, 4)) ||
+     power.Contains(42) {
+    t.Errorf("Unexpected result of Contains on power set")
+  }
+}
+
+func TestCartesianProduct(t *testing.T) {
+  product := HashSet.New(1, 2).CartesianProduct(TreeSet.New("a", "b", "c"))
+  checkSetSize(t, product, 6, "product")
+  if CountElements(product.Elements()) != 6 {
+    t.Errorf("Expected product to iterate over 6 pairs")
+  }
+  if !product.Contains(NewPair(2, "c")) || product.Contains(NewPair(3, "a")) ||
+     product.Contains("a") {
+    t.Errorf("Unexpected result of Contains on cartesian product")
+  }
+}
@@ -0,0 +1,87 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import . "github.com/objecthub/containerkit"
+
+
+// SortedSet is a Set whose elements are ordered by a Comparison function.
+// Its iterator returns the elements in ascending order. In addition to the
+// Set functionality, SortedSet provides methods for navigating the set and
+// for creating views of ranges of the set.
+type SortedSet interface {
+  Set
+
+  // Comparison returns the function that defines the order of the elements.
+  Comparison() Comparison
+
+  // First returns the smallest element. It panics if the set is empty.
+  First() interface{}
+
+  // Last returns the largest element. It panics if the set is empty.
+  Last() interface{}
+
+  // Floor returns the largest element less than or equal to elem.
+  Floor(elem interface{}) (res interface{}, exists bool)
+
+  // Ceiling returns the smallest element greater than or equal to elem.
+  Ceiling(elem interface{}) (res interface{}, exists bool)
+
+  // Lower returns the largest element strictly less than elem.
+  Lower(elem interface{}) (res interface{}, exists bool)
+
+  // Higher returns the smallest element strictly greater than elem.
+  Higher(elem interface{}) (res interface{}, exists bool)
+
+  // SubSet returns a live view of all elements ranging from 'from'
+  // (inclusive) to 'to' (exclusive).
+  SubSet(from, to interface{}) DependentSortedSet
+
+  // HeadSet returns a live view of all elements strictly less than 'to'.
+  HeadSet(to interface{}) DependentSortedSet
+
+  // TailSet returns a live view of all elements greater than or equal to 'from'.
+  TailSet(from interface{}) DependentSortedSet
+}
+
+// DependentSortedSet is a SortedSet which is a view of another SortedSet.
+type DependentSortedSet interface {
+  SortedSet
+}
+
+// MutableSortedSet is a SortedSet that can be changed by including and
+// excluding elements.
+type MutableSortedSet interface {
+  MutableSet
+  SortedSet
+
+  // PollFirst removes and returns the smallest element. Like PollFirstEntry
+  // of sorted maps, it returns nil if the set is empty.
+  PollFirst() interface{}
+
+  // PollLast removes and returns the largest element. Like PollLastEntry of
+  // sorted maps, it returns nil if the set is empty.
+  PollLast() interface{}
+}
+
+// SortedSetClass defines the functionality of MutableSortedSet implementations.
+// In addition to the MutableSetClass methods, which return MutableSet values,
+// it provides factory methods returning MutableSortedSet values.
+type SortedSetClass interface {
+  MutableSetClass
+  Comparison() Comparison
+  NewSorted(elements ...interface{}) MutableSortedSet
+  FromSorted(coll Container) MutableSortedSet
+}
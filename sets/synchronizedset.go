@@ -70,6 +70,9 @@ type unsynchronizedSet interface {
   Union(set Set) DependentSet
   Intersection(set Set) DependentSet
   Difference(set Set) DependentSet
+  SymmetricDifference(set Set) DependentSet
+  PowerSet() DependentSet
+  CartesianProduct(set Set) DependentSet
 }
 
 type synchronizedSet struct {
@@ -128,6 +131,24 @@ func (this *synchronizedSet) ContainsSomeFrom(elements Container) bool {
   return this.unsync.ContainsSomeFrom(elements)
 }
 
+func (this *synchronizedSet) IsSubsetOf(set Set) bool {
+  this.mutex.RLock()
+  defer this.mutex.RUnlock()
+  return this.unsync.IsSubsetOf(set)
+}
+
+func (this *synchronizedSet) IsSupersetOf(set Set) bool {
+  this.mutex.RLock()
+  defer this.mutex.RUnlock()
+  return this.unsync.IsSupersetOf(set)
+}
+
+func (this *synchronizedSet) IsDisjoint(set Set) bool {
+  this.mutex.RLock()
+  defer this.mutex.RUnlock()
+  return this.unsync.IsDisjoint(set)
+}
+
 func (this *synchronizedSet) Include(elements ...interface{}) {
   this.mutex.Lock()
   defer this.mutex.Unlock()
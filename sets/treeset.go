@@ -0,0 +1,203 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import . "github.com/objecthub/containerkit"
+import . "github.com/objecthub/containerkit/impl"
+
+
+var TreeSet SortedSetClass = TreeSetClass(UniversalComparison)
+
+var ImmutableTreeSet SetClass = ImmutableSet(TreeSet)
+
+func TreeSetClass(comp Comparison) SortedSetClass {
+  return &treeSetClass{comp}
+}
+
+type treeSetClass struct {
+  comp Comparison
+}
+
+func (this *treeSetClass) Comparison() Comparison {
+  return this.comp
+}
+
+func (this *treeSetClass) Embed(obj MutableSet) MutableSet {
+  res := new(treeSet)
+  if obj == nil {
+    obj = res
+  }
+  res.obj = obj
+  res.MutableSetDerived = EmbeddedMutableSet(obj)
+  res.treeRange = treeRange{NewTreeRange(NewAvlTree(this.comp), TreeBound{}, TreeBound{})}
+  return res
+}
+
+func (this *treeSetClass) New(elements ...interface{}) MutableSet {
+  return this.NewSorted(elements...)
+}
+
+func (this *treeSetClass) From(coll Container) MutableSet {
+  return this.FromSorted(coll)
+}
+
+func (this *treeSetClass) NewSorted(elements ...interface{}) MutableSortedSet {
+  res := this.Embed(nil).(*treeSet)
+  res.Include(elements...)
+  return res
+}
+
+func (this *treeSetClass) FromSorted(coll Container) MutableSortedSet {
+  res := this.Embed(nil).(*treeSet)
+  res.IncludeFrom(coll)
+  return res
+}
+
+type treeSet struct {
+  obj MutableSet
+  treeRange
+  MutableSetDerived
+}
+
+func (this *treeSet) Class() MutableSetClass {
+  return TreeSetClass(this.Tree().Comparison())
+}
+
+func (this *treeSet) Include(elements ...interface{}) {
+  for _, elem := range elements {
+    this.Tree().InsertNode(elem, nil)
+  }
+}
+
+func (this *treeSet) Exclude(elements ...interface{}) {
+  for _, elem := range elements {
+    this.Tree().DeleteKey(elem)
+  }
+}
+
+func (this *treeSet) Clear() {
+  this.Tree().Clear()
+}
+
+func (this *treeSet) PollFirst() interface{} {
+  node := this.Tree().FirstNode()
+  if node == nil {
+    return nil
+  }
+  this.Tree().DeleteNode(node)
+  return node.Key
+}
+
+func (this *treeSet) PollLast() interface{} {
+  node := this.Tree().LastNode()
+  if node == nil {
+    return nil
+  }
+  this.Tree().DeleteNode(node)
+  return node.Key
+}
+
+// Range views
+
+func newTreeSubSet(rng TreeRange) DependentSortedSet {
+  res := new(treeSubSet)
+  res.SetDerived = EmbeddedDependentSet(res)
+  res.treeRange = treeRange{rng}
+  return res
+}
+
+type treeSubSet struct {
+  SetDerived
+  treeRange
+}
+
+// treeRange implements the read-only functionality of sorted sets on top of
+// a TreeRange.
+type treeRange struct {
+  TreeRange
+}
+
+func (this *treeRange) Contains(elem interface{}) bool {
+  return this.FindNode(elem) != nil
+}
+
+func (this *treeRange) Elements() Iterator {
+  return &treeSetIterator{this.Nodes(true)}
+}
+
+func (this *treeRange) Comparison() Comparison {
+  return this.Tree().Comparison()
+}
+
+func (this *treeRange) First() interface{} {
+  if node := this.FirstNode(); node != nil {
+    return node.Key
+  }
+  panic("SortedSet.First: set empty")
+}
+
+func (this *treeRange) Last() interface{} {
+  if node := this.LastNode(); node != nil {
+    return node.Key
+  }
+  panic("SortedSet.Last: set empty")
+}
+
+func (this *treeRange) Floor(elem interface{}) (res interface{}, exists bool) {
+  return nodeKey(this.FloorNode(elem, true))
+}
+
+func (this *treeRange) Ceiling(elem interface{}) (res interface{}, exists bool) {
+  return nodeKey(this.CeilingNode(elem, true))
+}
+
+func (this *treeRange) Lower(elem interface{}) (res interface{}, exists bool) {
+  return nodeKey(this.FloorNode(elem, false))
+}
+
+func (this *treeRange) Higher(elem interface{}) (res interface{}, exists bool) {
+  return nodeKey(this.CeilingNode(elem, false))
+}
+
+func (this *treeRange) SubSet(from, to interface{}) DependentSortedSet {
+  return newTreeSubSet(this.Restrict(InclusiveBound(from), ExclusiveBound(to)))
+}
+
+func (this *treeRange) HeadSet(to interface{}) DependentSortedSet {
+  return newTreeSubSet(this.Restrict(TreeBound{}, ExclusiveBound(to)))
+}
+
+func (this *treeRange) TailSet(from interface{}) DependentSortedSet {
+  return newTreeSubSet(this.Restrict(InclusiveBound(from), TreeBound{}))
+}
+
+func nodeKey(node *TreeNode) (interface{}, bool) {
+  if node == nil {
+    return nil, false
+  }
+  return node.Key, true
+}
+
+type treeSetIterator struct {
+  nodeIter *TreeNodeIterator
+}
+
+func (this *treeSetIterator) HasNext() bool {
+  return this.nodeIter.HasNext()
+}
+
+func (this *treeSetIterator) Next() interface{} {
+  return this.nodeIter.Next().Key
+}
@@ -0,0 +1,93 @@
+// Copyright 2014 Matthias Zenger. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sets
+
+import "testing"
+import . "github.com/objecthub/containerkit"
+
+
+func checkElements(t *testing.T, s Set, expected []interface{}, name string) {
+  i := 0
+  for iter := s.Elements(); iter.HasNext(); i++ {
+    elem := iter.Next()
+    if i >= len(expected) || elem != expected[i] {
+      t.Errorf("Unexpected element %v at position %d of set %s", elem, i, name)
+      return
+    }
+  }
+  if i != len(expected) || s.Size() != len(expected) {
+    t.Errorf("Expected set %s to have %d elements; had %d", name, len(expected), i)
+  }
+}
+
+func checkNavigation(t *testing.T, res interface{}, exists bool, expected interface{}, name string) {
+  if (expected == nil && exists) || (expected != nil && (!exists || res != expected)) {
+    t.Errorf("Expected %s to return %v; was %v", name, expected, res)
+  }
+}
+
+func TestTreeSetClass(t *testing.T) {
+  s1 := TreeSet.NewSorted(5, 3, 9, 1, 7, 3)
+  checkElements(t, s1, []interface{}{1, 3, 5, 7, 9}, "s1")
+  if s1.First() != 1 || s1.Last() != 9 {
+    t.Errorf("Unexpected first or last element of s1")
+  }
+  res, exists := s1.Floor(4)
+  checkNavigation(t, res, exists, 3, "Floor(4)")
+  res, exists = s1.Floor(0)
+  checkNavigation(t, res, exists, nil, "Floor(0)")
+  res, exists = s1.Ceiling(5)
+  checkNavigation(t, res, exists, 5, "Ceiling(5)")
+  res, exists = s1.Lower(5)
+  checkNavigation(t, res, exists, 3, "Lower(5)")
+  res, exists = s1.Higher(9)
+  checkNavigation(t, res, exists, nil, "Higher(9)")
+  if s1.PollFirst() != 1 || s1.PollLast() != 9 {
+    t.Errorf("Unexpected result of PollFirst or PollLast")
+  }
+  checkElements(t, s1, []interface{}{3, 5, 7}, "s1")
+  if TreeSet.NewSorted().PollFirst() != nil || TreeSet.NewSorted().PollLast() != nil {
+    t.Errorf("Expected PollFirst and PollLast to return nil for an empty set")
+  }
+  s2 := TreeSetClass(InvertComparison(UniversalComparison)).NewSorted(1, 2, 3)
+  checkElements(t, s2, []interface{}{3, 2, 1}, "s2")
+}
+
+func TestTreeSetViews(t *testing.T) {
+  s := TreeSet.NewSorted(1, 2, 3, 4, 5, 6, 7, 8)
+  sub := s.SubSet(3, 7)
+  head := s.HeadSet(4)
+  tail := s.TailSet(6)
+  checkElements(t, sub, []interface{}{3, 4, 5, 6}, "sub")
+  checkElements(t, head, []interface{}{1, 2, 3}, "head")
+  checkElements(t, tail, []interface{}{6, 7, 8}, "tail")
+  s.Exclude(3, 6)
+  s.Include(0, 10)
+  checkElements(t, sub, []interface{}{4, 5}, "sub")
+  checkElements(t, head, []interface{}{0, 1, 2}, "head")
+  checkElements(t, tail, []interface{}{7, 8, 10}, "tail")
+  if sub.Contains(2) || !sub.Contains(4) || sub.First() != 4 || sub.Last() != 5 {
+    t.Errorf("Unexpected membership or navigation in sub")
+  }
+  res, exists := sub.Floor(100)
+  checkNavigation(t, res, exists, 5, "sub.Floor(100)")
+  res, exists = sub.Higher(0)
+  checkNavigation(t, res, exists, 4, "sub.Higher(0)")
+  res, exists = sub.Lower(4)
+  checkNavigation(t, res, exists, nil, "sub.Lower(4)")
+  checkElements(t, sub.TailSet(5), []interface{}{5}, "sub.TailSet(5)")
+  checkElements(t, sub.HeadSet(100), []interface{}{4, 5}, "sub.HeadSet(100)")
+  checkElements(t, s.SubSet(5, 5), []interface{}{}, "empty subset")
+}
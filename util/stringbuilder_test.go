@@ -19,7 +19,7 @@ import "testing"
 
 func checkString(t *testing.T, str string, expected string, name string) {
   if str != expected {
-    t.Errorf("Expected result \"%s\" of string builder %s to match \"%s%\"", str, name, expected)
+    t.Errorf("Expected result \"%s\" of string builder %s to match \"%s\"", str, name, expected)
   }
 }
 